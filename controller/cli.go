@@ -1,10 +1,12 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 
+	"github.com/qmu/mcc/model"
 	"github.com/spf13/cobra"
 )
 
@@ -28,16 +30,21 @@ func Run() (err error) {
 				fmt.Println("mcc version " + Version)
 				os.Exit(0)
 			}
-			if config == "" {
-				if _, err := os.Stat("./mcc.yml"); err == nil {
-					config = "./mcc.yml"
-				} else {
-					fmt.Println("Error: check \"mcc.yml\" exists in the current directory, or use -c to set its path")
-					os.Exit(1)
-				}
+			path, err := resolveConfigPath(config)
+			if err != nil {
+				fmt.Println("Error: " + err.Error())
+				os.Exit(1)
 			}
 
-			if err := NewController(Version, ConfigSchemaVersion, config, false); err != nil {
+			if err := NewController(Version, ConfigSchemaVersion, path, false); err != nil {
+				if cerr, ok := err.(*model.ConfigError); ok {
+					fmt.Println("==================================================================")
+					fmt.Println("CONFIGURATION ERROR")
+					fmt.Println(".....................")
+					writeConfigError(os.Stdout, cerr)
+					fmt.Println("==================================================================")
+					os.Exit(1)
+				}
 				log.Panicln(err)
 				os.Exit(1)
 			}
@@ -46,6 +53,7 @@ func Run() (err error) {
 
 	RootCmd.PersistentFlags().StringVarP(&config, "config", "c", "", "path to a yaml config")
 	RootCmd.PersistentFlags().BoolP("version", "v", false, "print the version")
+	RootCmd.AddCommand(newValidateCmd(&config))
 	cobra.OnInitialize()
 
 	if err = RootCmd.Execute(); err != nil {
@@ -53,3 +61,14 @@ func Run() (err error) {
 	}
 	return err
 }
+
+// resolveConfigPath returns the path of the config, "./mcc.yml" is used if it's not given
+func resolveConfigPath(config string) (path string, err error) {
+	if config != "" {
+		return config, nil
+	}
+	if _, err = os.Stat("./mcc.yml"); err != nil {
+		return "", errors.New("check \"mcc.yml\" exists in the current directory, or use -c to set its path")
+	}
+	return "./mcc.yml", nil
+}
@@ -2,24 +2,40 @@ package controller
 
 import (
 	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
 
 	ui "github.com/gizak/termui"
 	"github.com/qmu/mcc/github"
 	"github.com/qmu/mcc/model"
 	"github.com/qmu/mcc/widget"
+	"github.com/qmu/mcc/widget/listable"
+	fsnotify "gopkg.in/fsnotify.v1"
 )
 
+// reloadEvent is the custom event which reloads the config on the event loop
+const reloadEvent = "/usr/reload"
+
 // Controller controls termui's widget layout and keybindings
 type Controller struct {
-	execPath    string
-	viewManager *model.ViewManager
-	client      *github.Client
+	execPath      string
+	configPath    string
+	loaderOption  *model.ConfigLoaderOption
+	viewManager   *model.ViewManager
+	errorPopup    *listable.Popup
+	reloadTimer   *time.Timer
+	pendingReload bool       // the config was changed while a popup was opened
+	mutex         sync.Mutex // guards viewManager, errorPopup and pendingReload
+	reloadMutex   sync.Mutex // the events are handled in goroutines, a reload runs one by one
+	githubMutex   sync.Mutex // the GitHub widgets are set up one by one not to start the device flow twice
 }
 
 // NewController constructs a new Controller
 func NewController(appVersion string, configSchemaVersion string, configPath string, debug bool) (err error) {
 	d := new(Controller)
 	d.execPath = filepath.Dir(configPath)
+	d.configPath = configPath
 
 	// initialize termui
 	if err = ui.Init(); err != nil {
@@ -27,12 +43,13 @@ func NewController(appVersion string, configSchemaVersion string, configPath str
 	}
 	defer ui.Close()
 
-	d.viewManager, err = model.NewViewManager(&model.ConfigLoaderOption{
+	d.loaderOption = &model.ConfigLoaderOption{
 		ExecPath:            d.execPath,
 		ConfigPath:          configPath,
 		AppVersion:          appVersion,
 		ConfigSchemaVersion: configSchemaVersion,
-	})
+	}
+	d.viewManager, err = model.NewViewManager(d.loaderOption)
 	if err != nil {
 		return
 	}
@@ -40,19 +57,173 @@ func NewController(appVersion string, configSchemaVersion string, configPath str
 	// layout first tab
 	d.viewManager.SwitchTab(0)
 	d.setKeyBindings()
+	listable.SetBaseKeyBindings(func() {
+		d.setKeyBindings()
+		// the reload waited for the popups to be closed
+		d.mutex.Lock()
+		pending := d.pendingReload
+		d.mutex.Unlock()
+		if pending {
+			go ui.SendCustomEvt(reloadEvent, nil)
+		}
+	})
+	// the handlers under /usr/ are not reset by popups
+	ui.Handle(reloadEvent, func(ui.Event) {
+		d.reload()
+	})
 
 	// init asynchronously
-	if d.viewManager.HasWidget("github_issue") {
-		go d.renderGitHubIssueWidgets()
+	if d.hasGitHubWidget() {
+		go d.renderGitHubWidgets()
 	}
 
 	if debug {
 		return
 	}
+	watcher, err := d.watchConfig()
+	if err != nil {
+		return
+	}
+	defer watcher.Close()
 	ui.Loop()
 	return
 }
 
+// watchConfig reloads the dashboard when the config file is written.
+// the directory is watched because editors often replace the file on saving
+func (d *Controller) watchConfig() (watcher *fsnotify.Watcher, err error) {
+	watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return
+	}
+	if err = watcher.Add(filepath.Dir(d.configPath)); err != nil {
+		watcher.Close()
+		return
+	}
+	target := filepath.Clean(d.configPath)
+	go func() {
+		for {
+			select {
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(e.Name) != target || e.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				// debounce, editors write a file in several steps
+				if d.reloadTimer != nil {
+					d.reloadTimer.Stop()
+				}
+				d.reloadTimer = time.AfterFunc(300*time.Millisecond, func() {
+					ui.SendCustomEvt(reloadEvent, nil)
+				})
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return
+}
+
+// reload rebuilds the ViewManager from the config file and stops the widgets of the old one,
+// the last good layout keeps running if the config has errors.
+// it waits until the popups are closed not to replace their key bindings
+func (d *Controller) reload() {
+	d.reloadMutex.Lock()
+	defer d.reloadMutex.Unlock()
+
+	d.mutex.Lock()
+	deferred := listable.HasPopup() && d.errorPopup == nil
+	d.pendingReload = deferred
+	errorPopup := d.errorPopup
+	d.mutex.Unlock()
+	if deferred {
+		return
+	}
+
+	vm, err := model.NewViewManager(d.loaderOption)
+	if err != nil {
+		d.showConfigError(err)
+		return
+	}
+	if errorPopup != nil {
+		d.mutex.Lock()
+		d.errorPopup = nil
+		d.mutex.Unlock()
+		errorPopup.Close()
+	}
+
+	old := d.getViewManager()
+	tabIdx := old.GetActiveTabIndex()
+	widgetIdx := old.GetActiveWidgetIndex()
+	d.mutex.Lock()
+	d.viewManager = vm
+	d.mutex.Unlock()
+	old.MapWidgets(func(w *widget.WrapperWidget) error {
+		w.Stop()
+		return nil
+	})
+
+	listable.ResetKeyHandlers()
+	d.setKeyBindings()
+	vm.SwitchTab(tabIdx)
+	if vm.GetActiveTabIndex() != tabIdx {
+		vm.SwitchTab(0)
+	}
+	vm.ActivateWidget(widgetIdx)
+
+	if d.hasGitHubWidget() {
+		go d.renderGitHubWidgets()
+	}
+}
+
+// getViewManager returns the ViewManager of the current layout
+func (d *Controller) getViewManager() *model.ViewManager {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.viewManager
+}
+
+func (d *Controller) showConfigError(err error) {
+	body := []string{}
+	if cerr, ok := err.(*model.ConfigError); ok {
+		for _, item := range cerr.Errors {
+			pos := strconv.Itoa(item.Line) + ":" + strconv.Itoa(item.Column)
+			body = append(body, " [line "+pos+"](fg-red) "+item.Message+" [("+item.Position+")](fg-blue)")
+		}
+	} else {
+		body = append(body, " [error](fg-red) "+err.Error())
+	}
+	body = append(body, "", " [the last valid layout keeps running, fix the config or press Esc to close](fg-blue)")
+
+	d.mutex.Lock()
+	p := d.errorPopup
+	d.mutex.Unlock()
+	if p != nil {
+		p.SetBody(body)
+		return
+	}
+	p = listable.NewPopup(&listable.PopupOption{
+		Title: "CONFIGURATION ERROR - " + d.configPath,
+		Body:  body,
+	})
+	d.mutex.Lock()
+	d.errorPopup = p
+	d.mutex.Unlock()
+	p.Open(func() {
+		d.mutex.Lock()
+		if d.errorPopup == p {
+			d.errorPopup = nil
+		}
+		d.mutex.Unlock()
+		vm := d.getViewManager()
+		vm.ActivateWidget(vm.GetActiveWidgetIndex())
+	})
+}
+
 func (d *Controller) setKeyBindings() error {
 	ui.Handle("/sys/kbd/q", func(ui.Event) {
 		ui.StopLoop()
@@ -61,93 +232,130 @@ func (d *Controller) setKeyBindings() error {
 		ui.StopLoop()
 	})
 	ui.Handle("/sys/kbd/C-j", func(ui.Event) {
-		d.viewManager.NextWidget("bottom")
+		d.getViewManager().NextWidget("bottom")
 	})
 	ui.Handle("/sys/kbd/C-k", func(ui.Event) {
-		d.viewManager.NextWidget("top")
+		d.getViewManager().NextWidget("top")
 	})
 	ui.Handle("/sys/kbd/<backspace>", func(ui.Event) {
-		d.viewManager.NextWidget("left")
+		d.getViewManager().NextWidget("left")
 	})
 	ui.Handle("/sys/kbd/C-l", func(ui.Event) {
-		d.viewManager.NextWidget("right")
+		d.getViewManager().NextWidget("right")
 	})
 	ui.Handle("/sys/kbd/1", func(ui.Event) {
-		d.viewManager.SwitchTab(0)
+		d.getViewManager().SwitchTab(0)
 	})
 	ui.Handle("/sys/kbd/2", func(ui.Event) {
-		d.viewManager.SwitchTab(1)
+		d.getViewManager().SwitchTab(1)
 	})
 	ui.Handle("/sys/kbd/3", func(ui.Event) {
-		d.viewManager.SwitchTab(2)
+		d.getViewManager().SwitchTab(2)
 	})
 	ui.Handle("/sys/kbd/4", func(ui.Event) {
-		d.viewManager.SwitchTab(3)
+		d.getViewManager().SwitchTab(3)
 	})
 	ui.Handle("/sys/kbd/5", func(ui.Event) {
-		d.viewManager.SwitchTab(4)
+		d.getViewManager().SwitchTab(4)
 	})
 	ui.Handle("/sys/kbd/6", func(ui.Event) {
-		d.viewManager.SwitchTab(5)
+		d.getViewManager().SwitchTab(5)
 	})
 	ui.Handle("/sys/kbd/7", func(ui.Event) {
-		d.viewManager.SwitchTab(6)
+		d.getViewManager().SwitchTab(6)
 	})
 	ui.Handle("/sys/kbd/8", func(ui.Event) {
-		d.viewManager.SwitchTab(7)
+		d.getViewManager().SwitchTab(7)
 	})
 	ui.Handle("/sys/kbd/9", func(ui.Event) {
-		d.viewManager.SwitchTab(8)
+		d.getViewManager().SwitchTab(8)
 	})
 	ui.Handle("/sys/wnd/resize", func(e ui.Event) {
 		ui.Body.Width = ui.TermWidth()
 		ui.Body.Align()
 		ui.Clear()
-		ui.Render(ui.Body)
+		listable.RenderBody()
 	})
-	if d.viewManager.HasWidget("docker_status") {
-		ui.Handle("/timer/1s", func(e ui.Event) {
-			err := d.viewManager.MapWidgets(func(w *widget.WrapperWidget) (err error) {
-				if w.Is("docker_status") {
-					w.Activate()
-				}
-				return
-			})
-			if err != nil {
-				return
-			}
-		})
-	}
-
 	return nil
 }
 
-func (d *Controller) renderGitHubIssueWidgets() {
-	// initialize GitHub Client
-	host := d.viewManager.GetGithubHost()
-	if host == "" {
-		host = "github.com"
-	}
-	c, err := github.NewClient(d.execPath, host)
-	if err != nil {
-		err = d.viewManager.MapWidgets(func(w *widget.WrapperWidget) (err error) {
-			if w.Is("github_issue") {
-				w.Disable()
-			}
-			return
-		})
-	} else {
-		if err = c.Init(); err != nil {
+func (d *Controller) hasGitHubWidget() bool {
+	return d.getViewManager().HasWidget("github_issue") || d.getViewManager().HasWidget("github_pull_requests")
+}
+
+func (d *Controller) renderGitHubWidgets() {
+	d.githubMutex.Lock()
+	defer d.githubMutex.Unlock()
+	// a GitHub client for each repository
+	options := map[string]*widget.AdditionalWidgetOption{}
+	d.getViewManager().MapWidgets(func(w *widget.WrapperWidget) (err error) {
+		if !w.Is("github_issue") && !w.Is("github_pull_requests") {
 			return
 		}
-		d.client = c
-	}
-	err = d.viewManager.MapWidgets(func(w *widget.WrapperWidget) (err error) {
-		if w.Is("github_issue") && !w.IsDisabled() {
-			w.SetOption(&widget.AdditionalWidgetOption{
-				GithubClient: d.client,
-			})
+		repo := w.GetRepoPath()
+		opt, ok := options[repo]
+		if !ok {
+			opt = d.newGithubOption(repo)
+			options[repo] = opt
+		}
+		if opt == nil {
+			w.Disable()
+		} else if !w.IsDisabled() {
+			w.SetOption(opt)
 		}
 		return
 	})
 }
+
+// newGithubOption initializes the GitHub client of the repository,
+// it returns nil if the repository has no GitHub remote
+func (d *Controller) newGithubOption(repo string) *widget.AdditionalWidgetOption {
+	c, err := github.NewClient(&github.ClientOption{
+		ExecPath:     repo,
+		Host:         d.getViewManager().GetGithubHost(),
+		TokenCommand: d.getViewManager().GetGithubTokenCommand(),
+		ClientID:     d.getViewManager().GetGithubClientID(),
+		CABundle:     d.getViewManager().GetGithubCABundle(),
+		Remote:       d.getViewManager().GetGithubRemote(),
+	})
+	if err != nil {
+		return nil
+	}
+	if err = c.Init(); err != nil && c.HasDeviceFlow() {
+		err = d.authorizeDevice(c)
+	}
+	if err != nil {
+		return &widget.AdditionalWidgetOption{GithubError: err}
+	}
+	return &widget.AdditionalWidgetOption{GithubClient: c}
+}
+
+// authorizeDevice shows the code of the OAuth device flow in a popup while the dashboard keeps running,
+// the popup is closed when the user authorized mcc
+func (d *Controller) authorizeDevice(c *github.Client) error {
+	var p *listable.Popup
+	err := c.AuthorizeDevice(func(verificationURI string, userCode string) {
+		p = listable.NewPopup(&listable.PopupOption{
+			Title: "GITHUB AUTHORIZATION",
+			Body: []string{
+				" GitHub widget is configured in the yaml",
+				" Please authorize mcc with your GitHub account",
+				"",
+				"   1. open " + verificationURI,
+				"   2. enter the code [" + userCode + "](fg-green)",
+				"",
+				" [the GitHub widgets are loaded after the authorization](fg-blue)",
+			},
+			Width:  "60%",
+			Height: "40%",
+		})
+		p.Open(func() {
+			vm := d.getViewManager()
+			vm.ActivateWidget(vm.GetActiveWidgetIndex())
+		})
+	})
+	if p != nil {
+		p.Close()
+	}
+	return err
+}
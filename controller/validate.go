@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/qmu/mcc/model"
+	"github.com/spf13/cobra"
+)
+
+// newValidateCmd constructs the "validate" subcommand which lints a config without starting termui
+func newValidateCmd(config *string) (cmd *cobra.Command) {
+	var format string
+	cmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate a yaml config and exit non-zero if it has errors",
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ok := false
+			path, err := resolveConfigPath(*config)
+			if err == nil {
+				ok, err = validateConfig(cmd.OutOrStdout(), path, format)
+			}
+			// a lint reports the error like the config errors instead of panicking in main
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStderr(), err)
+				os.Exit(1)
+			}
+			if !ok {
+				os.Exit(1)
+			}
+			return
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "output format, \"text\" or \"json\"")
+	return
+}
+
+// validateConfig loads the config headlessly and writes the result in the format
+func validateConfig(w io.Writer, configPath string, format string) (ok bool, err error) {
+	if format != "text" && format != "json" {
+		return false, errors.New("--format should be \"text\" or \"json\"")
+	}
+	_, lerr := model.NewLoader(&model.ConfigLoaderOption{
+		ExecPath:            filepath.Dir(configPath),
+		ConfigPath:          configPath,
+		AppVersion:          Version,
+		ConfigSchemaVersion: ConfigSchemaVersion,
+	})
+	cerr, isConfigError := lerr.(*model.ConfigError)
+	if lerr != nil && !isConfigError {
+		// the config could not be loaded at all, e.g. a yaml syntax error
+		cerr = &model.ConfigError{
+			ConfigPath: configPath,
+			Errors:     []*model.ConfigErrorItem{{Message: lerr.Error()}},
+		}
+	}
+
+	if format == "json" {
+		if cerr == nil {
+			cerr = &model.ConfigError{ConfigPath: configPath, Errors: []*model.ConfigErrorItem{}}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(cerr); err != nil {
+			return
+		}
+		return len(cerr.Errors) == 0, nil
+	}
+
+	if cerr == nil {
+		fmt.Fprintln(w, configPath+": OK")
+		return true, nil
+	}
+	writeConfigError(w, cerr)
+	fmt.Fprintln(w, strconv.Itoa(len(cerr.Errors))+" error(s) found in "+configPath)
+	return false, nil
+}
+
+func writeConfigError(w io.Writer, cerr *model.ConfigError) {
+	for _, item := range cerr.Errors {
+		if item.Position == "" {
+			fmt.Fprintln(w, cerr.ConfigPath+": "+item.Message)
+			continue
+		}
+		fmt.Fprintln(w, item.String(cerr.ConfigPath))
+	}
+}
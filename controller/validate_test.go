@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/qmu/mcc/model"
+)
+
+func TestValidateConfig(t *testing.T) {
+	ConfigSchemaVersion = "1.1.0"
+
+	var out bytes.Buffer
+	ok, err := validateConfig(&out, "../_example/example.yml", "text")
+	if err != nil || !ok {
+		t.Fatalf("example.yml should be valid: %v %v", out.String(), err)
+	}
+
+	out.Reset()
+	ok, err = validateConfig(&out, "../_example/test_invalid.yml", "text")
+	if err != nil || ok {
+		t.Fatalf("test_invalid.yml should be invalid: %v", err)
+	}
+	if !strings.Contains(out.String(), "../_example/test_invalid.yml:13:3: ") {
+		t.Fatalf("the error of tail_file should have its line and column: %v", out.String())
+	}
+
+	out.Reset()
+	ok, err = validateConfig(&out, "../_example/test_invalid.yml", "json")
+	if err != nil || ok {
+		t.Fatalf("test_invalid.yml should be invalid: %v", err)
+	}
+	cerr := new(model.ConfigError)
+	if err = json.Unmarshal(out.Bytes(), cerr); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(cerr.Errors) != 4 {
+		t.Fatalf("test_invalid.yml should have 4 errors: %v", out.String())
+	}
+	if cerr.Errors[2].Line != 25 || cerr.Errors[2].Column != 17 {
+		t.Fatalf("the undefined widget should be at 25:17: %v", out.String())
+	}
+
+	if _, err = validateConfig(&out, "../_example/example.yml", "xml"); err == nil {
+		t.Fatalf("unknown format should be an error")
+	}
+}
@@ -0,0 +1,102 @@
+package docker
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	go_docker "github.com/fsouza/go-dockerclient"
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// ClientOption is the option argument for NewClient
+type ClientOption struct {
+	Host string // docker_host in the config, DOCKER_HOST or a found socket is used if it's empty
+}
+
+// NewClient constructs a Docker API client of the endpoint,
+// it talks TLS with the certificates in DOCKER_CERT_PATH if DOCKER_TLS_VERIFY is set like the docker CLI
+func NewClient(opt *ClientOption) (c *go_docker.Client, err error) {
+	host, err := ResolveHost(opt.Host)
+	if err != nil {
+		return
+	}
+	if os.Getenv("DOCKER_TLS_VERIFY") == "" || isSocket(host) {
+		return go_docker.NewClient(host)
+	}
+	certPath := os.Getenv("DOCKER_CERT_PATH")
+	if certPath == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return nil, err
+		}
+		certPath = filepath.Join(home, ".docker")
+	}
+	return go_docker.NewTLSClient(host,
+		filepath.Join(certPath, "cert.pem"),
+		filepath.Join(certPath, "key.pem"),
+		filepath.Join(certPath, "ca.pem"))
+}
+
+// ResolveHost returns the endpoint of the Docker API, host is preferred to DOCKER_HOST,
+// and the socket of docker, rootless docker or podman which exists is used if neither is set
+func ResolveHost(host string) (string, error) {
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = "unix://" + findSocket(defaultSockets())
+	}
+	return host, ValidateHost(host)
+}
+
+// ValidateHost checks the endpoint is like "unix:///var/run/docker.sock" or "tcp://127.0.0.1:2376"
+func ValidateHost(host string) error {
+	u, err := url.Parse(host)
+	if err != nil {
+		return errors.New("invalid docker host " + host + ": " + err.Error())
+	}
+	switch u.Scheme {
+	case "unix", "npipe":
+		if u.Path == "" {
+			return errors.New("docker host " + host + " has no path of the socket")
+		}
+	case "tcp", "http", "https":
+		if u.Host == "" {
+			return errors.New("docker host " + host + " has no host")
+		}
+	default:
+		return errors.New("docker host " + host + " should be unix://, npipe://, tcp://, http:// or https://")
+	}
+	return nil
+}
+
+func isSocket(host string) bool {
+	u, err := url.Parse(host)
+	return err == nil && (u.Scheme == "unix" || u.Scheme == "npipe")
+}
+
+// defaultSockets are the sockets of docker, rootless docker and podman in the order of the preference
+func defaultSockets() (sockets []string) {
+	sockets = []string{"/var/run/docker.sock"}
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = "/run/user/" + strconv.Itoa(os.Getuid())
+	}
+	return append(sockets,
+		filepath.Join(runtimeDir, "docker.sock"),
+		filepath.Join(runtimeDir, "podman", "podman.sock"),
+		"/run/podman/podman.sock")
+}
+
+// findSocket returns the first socket which exists, the first one if none of them exists
+func findSocket(sockets []string) string {
+	for _, s := range sockets {
+		if _, err := os.Stat(s); err == nil {
+			return s
+		}
+	}
+	return sockets[0]
+}
@@ -0,0 +1,93 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveHost(t *testing.T) {
+	defer os.Setenv("DOCKER_HOST", os.Getenv("DOCKER_HOST"))
+
+	// docker_host is preferred to DOCKER_HOST
+	os.Setenv("DOCKER_HOST", "tcp://10.0.0.1:2375")
+	if host, err := ResolveHost("unix:///run/podman/podman.sock"); err != nil || host != "unix:///run/podman/podman.sock" {
+		t.Fatalf("docker_host should be used but %s, error: %v", host, err)
+	}
+	if host, err := ResolveHost(""); err != nil || host != "tcp://10.0.0.1:2375" {
+		t.Fatalf("DOCKER_HOST should be used but %s, error: %v", host, err)
+	}
+	os.Setenv("DOCKER_HOST", "10.0.0.1:2375")
+	if _, err := ResolveHost(""); err == nil {
+		t.Fatal("DOCKER_HOST without the scheme should be an error")
+	}
+
+	cases := map[string]bool{
+		"unix:///var/run/docker.sock": true,
+		"npipe:////./pipe/docker":     true,
+		"tcp://127.0.0.1:2376":        true,
+		"https://docker.example.com":  true,
+		"unix://":                     false,
+		"tcp://":                      false,
+		"ssh://user@host":             false,
+	}
+	for host, valid := range cases {
+		if err := ValidateHost(host); (err == nil) != valid {
+			t.Fatalf("%s should be valid: %v but the error is %v", host, valid, err)
+		}
+	}
+}
+
+func TestFindSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mcc-docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	docker := filepath.Join(dir, "docker.sock")
+	podman := filepath.Join(dir, "podman", "podman.sock")
+
+	if s := findSocket([]string{docker, podman}); s != docker {
+		t.Fatalf("the first socket should be used if none exists but %s", s)
+	}
+	os.MkdirAll(filepath.Dir(podman), 0755)
+	ioutil.WriteFile(podman, nil, 0644)
+	if s := findSocket([]string{docker, podman}); s != podman {
+		t.Fatalf("the socket of podman should be found but %s", s)
+	}
+}
+
+func TestNewClientWithTLS(t *testing.T) {
+	defer os.Setenv("DOCKER_TLS_VERIFY", os.Getenv("DOCKER_TLS_VERIFY"))
+	defer os.Setenv("DOCKER_CERT_PATH", os.Getenv("DOCKER_CERT_PATH"))
+	dir, err := ioutil.TempDir("", "mcc-docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("DOCKER_TLS_VERIFY", "1")
+	os.Setenv("DOCKER_CERT_PATH", dir)
+	// TLS is used for a TCP endpoint
+	c, err := NewClient(&ClientOption{Host: "tcp://127.0.0.1:2376"})
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if c.TLSConfig == nil {
+		t.Fatal("TLS should be used for tcp://127.0.0.1:2376")
+	}
+	// but not for a socket
+	if c, err = NewClient(&ClientOption{Host: "unix:///var/run/docker.sock"}); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if c.TLSConfig != nil {
+		t.Fatal("TLS should not be used for the socket")
+	}
+	// the certificates are read from DOCKER_CERT_PATH
+	ioutil.WriteFile(filepath.Join(dir, "cert.pem"), []byte("broken"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "key.pem"), []byte("broken"), 0644)
+	if _, err = NewClient(&ClientOption{Host: "tcp://127.0.0.1:2376"}); err == nil {
+		t.Fatal("the broken certificate in DOCKER_CERT_PATH should be an error")
+	}
+}
@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	go_docker "github.com/fsouza/go-dockerclient"
+)
+
+// LogLine is a line of the logs of a container
+type LogLine struct {
+	Text   string
+	Stderr bool
+	Time   time.Time // zero unless Timestamps is set
+}
+
+// LogsOption is the option argument for FollowLogs
+type LogsOption struct {
+	ID         string    // the ID or the name of the container
+	Tail       string    // the number of the lines from the end to start with, "all" if it's empty
+	Since      time.Time // only the lines after it in seconds, all of them if it's zero
+	Timestamps bool      // read the time of each line
+}
+
+// FollowLogs streams the logs of the container like "docker logs --follow",
+// it returns when ctx is done or the container stops. onLine is not called concurrently
+func FollowLogs(ctx context.Context, c *go_docker.Client, opt *LogsOption, onLine func(*LogLine)) (err error) {
+	container, err := c.InspectContainerWithContext(opt.ID, ctx)
+	if err != nil {
+		return
+	}
+	tail := opt.Tail
+	if tail == "" {
+		tail = "all"
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	scan := func(r io.Reader, stderr bool) {
+		defer wg.Done()
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 64*1024), 1024*1024)
+		for s.Scan() {
+			mutex.Lock()
+			l := &LogLine{Text: s.Text(), Stderr: stderr}
+			if opt.Timestamps {
+				l.Time, l.Text = splitTimestamp(l.Text)
+			}
+			onLine(l)
+			mutex.Unlock()
+		}
+		// drain the rest not to block the writer
+		io.Copy(ioutil.Discard, r)
+	}
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+	wg.Add(2)
+	go scan(stdoutR, false)
+	go scan(stderrR, true)
+
+	var since int64
+	if !opt.Since.IsZero() {
+		since = opt.Since.Unix()
+	}
+	err = c.Logs(go_docker.LogsOptions{
+		Context:      ctx,
+		Container:    container.ID,
+		OutputStream: stdoutW,
+		ErrorStream:  stderrW,
+		Tail:         tail,
+		Since:        since,
+		Timestamps:   opt.Timestamps,
+		Follow:       true,
+		Stdout:       true,
+		Stderr:       true,
+		// the streams are not multiplexed with a TTY
+		RawTerminal: container.Config != nil && container.Config.Tty,
+	})
+	stdoutW.Close()
+	stderrW.Close()
+	wg.Wait()
+	if ctx.Err() != nil {
+		err = nil
+	}
+	return
+}
+
+// splitTimestamp splits the timestamp like "2018-05-01T12:00:00.123456789Z" which the Docker API prepends to a line
+func splitTimestamp(line string) (t time.Time, text string) {
+	i := strings.Index(line, " ")
+	if i < 0 {
+		i = len(line)
+	}
+	t, err := time.Parse(time.RFC3339Nano, line[:i])
+	if err != nil {
+		return time.Time{}, line
+	}
+	if i < len(line) {
+		text = line[i+1:]
+	}
+	return
+}
@@ -0,0 +1,75 @@
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// frame multiplexes the payload into the stream like the Docker API without a TTY
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestFollowLogs(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/web/json"):
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"Id": "c1", "Config": {"Tty": false}}`))
+		case strings.HasSuffix(r.URL.Path, "/containers/c1/logs"):
+			query = r.URL.RawQuery
+			w.Write(frame(1, "started\nlistening on :80\n"))
+			w.Write(frame(2, "warning: no config\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c, err := NewClient(&ClientOption{Host: "tcp://" + srv.Listener.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	err = FollowLogs(context.Background(), c, &LogsOption{ID: "web", Tail: "10"}, func(l *LogLine) {
+		if l.Stderr {
+			lines = append(lines, "stderr: "+l.Text)
+		} else {
+			lines = append(lines, "stdout: "+l.Text)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	// the order of stdout and stderr is not kept
+	sort.Strings(lines)
+	if strings.Join(lines, "\n") != "stderr: warning: no config\nstdout: listening on :80\nstdout: started" {
+		t.Fatalf("stdout and stderr should be demultiplexed but %q", lines)
+	}
+	if !strings.Contains(query, "follow=1") || !strings.Contains(query, "tail=10") {
+		t.Fatalf("the logs should be followed from the last 10 lines but %s", query)
+	}
+}
+
+func TestSplitTimestamp(t *testing.T) {
+	ts, text := splitTimestamp("2018-05-01T12:00:00.123456789Z GET / 200")
+	if text != "GET / 200" || !ts.Equal(time.Date(2018, 5, 1, 12, 0, 0, 123456789, time.UTC)) {
+		t.Fatalf("the timestamp should be split but %v, %q", ts, text)
+	}
+	if ts, text = splitTimestamp("no timestamp"); !ts.IsZero() || text != "no timestamp" {
+		t.Fatalf("the line without a timestamp should be kept but %v, %q", ts, text)
+	}
+	if ts, text = splitTimestamp("2018-05-01T12:00:00Z"); ts.IsZero() || text != "" {
+		t.Fatalf("an empty line should be empty but %v, %q", ts, text)
+	}
+}
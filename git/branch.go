@@ -0,0 +1,264 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	go_git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Branch is a local branch or a remote-tracking branch
+type Branch struct {
+	Name     string // like "master", or "origin/master" for a remote-tracking branch
+	Remote   bool
+	Head     bool // HEAD is on the branch
+	Hash     plumbing.Hash
+	When     time.Time // the date of the last commit
+	Subject  string    // the subject of the last commit
+	Upstream string    // like "origin/master", empty if the branch has no upstream
+	Ahead    int
+	Behind   int
+}
+
+// Branches lists the local branches and then the remote-tracking branches in the order of the names
+func (r *Repository) Branches() (branches []*Branch, err error) {
+	head, err := r.repo.Head()
+	if err != nil && err != plumbing.ErrReferenceNotFound {
+		return
+	}
+	refs, err := r.repo.References()
+	if err != nil {
+		return
+	}
+	defer refs.Close()
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || !ref.Name().IsBranch() && !ref.Name().IsRemote() {
+			// a symbolic reference like refs/remotes/origin/HEAD
+			return nil
+		}
+		b := &Branch{
+			Name:   ref.Name().Short(),
+			Remote: ref.Name().IsRemote(),
+			Head:   head != nil && head.Name() == ref.Name(),
+			Hash:   ref.Hash(),
+		}
+		c, err := r.repo.CommitObject(ref.Hash())
+		if err != nil {
+			return err
+		}
+		b.When = c.Committer.When
+		b.Subject = strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0]
+		if !b.Remote {
+			if err = r.fillUpstream(b); err != nil {
+				return err
+			}
+		}
+		branches = append(branches, b)
+		return nil
+	})
+	sort.Slice(branches, func(i, j int) bool {
+		if branches[i].Remote != branches[j].Remote {
+			return !branches[i].Remote
+		}
+		return branches[i].Name < branches[j].Name
+	})
+	return
+}
+
+// fillUpstream sets the upstream of the local branch with the ahead/behind counts
+func (r *Repository) fillUpstream(b *Branch) (err error) {
+	upstream, err := r.upstream(b.Name)
+	if err != nil || upstream == "" {
+		return
+	}
+	uref, err := r.repo.Reference(upstream, true)
+	if err != nil {
+		// the upstream has not been fetched yet
+		return nil
+	}
+	b.Upstream = upstream.Short()
+	b.Ahead, b.Behind, err = r.aheadBehind(b.Hash, uref.Hash())
+	return
+}
+
+// IsDirty tells if the index or the tracked files in the worktree have changes
+func (r *Repository) IsDirty() (bool, error) {
+	status, err := r.Status()
+	if err != nil {
+		return false, err
+	}
+	for _, s := range status {
+		if s.Staging != go_git.Unmodified && s.Staging != go_git.Untracked || s.Worktree != go_git.Unmodified && s.Worktree != go_git.Untracked {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Checkout switches HEAD to the branch like "git checkout". a remote-tracking branch is checked out
+// as the local branch of the same name which tracks it, and the local branch is created if it doesn't exist.
+// the local changes are carried over, it fails without changing anything if they would be overwritten
+func (r *Repository) Checkout(b *Branch) (err error) {
+	name := plumbing.ReferenceName("refs/heads/" + b.Name)
+	target := b.Hash
+	var remote, merge string
+	if b.Remote {
+		parts := strings.SplitN(b.Name, "/", 2)
+		if len(parts) != 2 {
+			return errors.New("invalid remote branch " + b.Name)
+		}
+		remote, merge = parts[0], "refs/heads/"+parts[1]
+		name = plumbing.ReferenceName(merge)
+		if ref, err := r.repo.Reference(name, true); err == nil {
+			// the local branch already exists
+			target, remote = ref.Hash(), ""
+		}
+	}
+
+	head, err := r.repo.Head()
+	if err != nil {
+		return
+	}
+	if head.Name() == name {
+		return nil
+	}
+	if err = r.switchTree(head.Hash(), target); err != nil {
+		return
+	}
+	if remote != "" {
+		if err = r.repo.Storer.SetReference(plumbing.NewHashReference(name, target)); err != nil {
+			return
+		}
+		if err = r.setUpstream(name.Short(), remote, merge); err != nil {
+			return
+		}
+	}
+	return r.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, name))
+}
+
+// setUpstream configures the upstream of the branch like "git branch -u"
+func (r *Repository) setUpstream(branch string, remote string, merge string) (err error) {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return
+	}
+	cfg.Raw.Section("branch").Subsection(branch).SetOption("remote", remote).SetOption("merge", merge)
+	return r.repo.Storer.SetConfig(cfg)
+}
+
+// switchTree updates the index and the worktree from the tree of the commit from to the one of the commit to.
+// the files which are not changed between them are kept as they are
+func (r *Repository) switchTree(from plumbing.Hash, to plumbing.Hash) (err error) {
+	fromTree, err := r.commitTree(from)
+	if err != nil {
+		return
+	}
+	toTree, err := r.commitTree(to)
+	if err != nil {
+		return
+	}
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return
+	}
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return
+	}
+
+	// the submodules are not checked out
+	files := object.Changes{}
+	for _, ch := range changes {
+		if ch.From.TreeEntry.Mode != filemode.Submodule && ch.To.TreeEntry.Mode != filemode.Submodule {
+			files = append(files, ch)
+		}
+	}
+
+	// check all the changes before touching the files
+	var conflicts []string
+	for _, ch := range files {
+		path := ch.From.Name
+		if path == "" {
+			path = ch.To.Name
+		}
+		clean, err := r.isClean(idx, path, ch.From.TreeEntry.Hash)
+		if err != nil {
+			return err
+		}
+		if !clean {
+			conflicts = append(conflicts, path)
+		}
+	}
+	if len(conflicts) > 0 {
+		return errors.New("the local changes would be overwritten: " + strings.Join(conflicts, ", "))
+	}
+
+	for _, ch := range files {
+		if ch.To.Name == "" {
+			if err = os.Remove(filepath.Join(r.path, ch.From.Name)); err != nil && !os.IsNotExist(err) {
+				return
+			}
+			if _, err = idx.Remove(ch.From.Name); err != nil && err != index.ErrEntryNotFound {
+				return
+			}
+			continue
+		}
+		e := ch.To.TreeEntry
+		if err = r.checkout(ch.To.Name, e.Hash, e.Mode); err != nil {
+			return
+		}
+		entry, err := idx.Entry(ch.To.Name)
+		if err == index.ErrEntryNotFound {
+			entry = &index.Entry{Name: ch.To.Name}
+			idx.Entries = append(idx.Entries, entry)
+		} else if err != nil {
+			return err
+		}
+		entry.Hash = e.Hash
+		entry.Mode = e.Mode
+		if fi, err := os.Lstat(filepath.Join(r.path, ch.To.Name)); err == nil {
+			entry.ModifiedAt = fi.ModTime()
+			entry.Size = uint32(fi.Size())
+		}
+	}
+	return r.repo.Storer.SetIndex(idx)
+}
+
+// isClean tells if both of the index and the worktree have the blob of the hash at the path,
+// the zero hash means that neither of them has the file
+func (r *Repository) isClean(idx *index.Index, path string, h plumbing.Hash) (bool, error) {
+	e, err := idx.Entry(path)
+	if err == index.ErrEntryNotFound {
+		if !h.IsZero() {
+			return false, nil
+		}
+	} else if err != nil {
+		return false, err
+	} else if e.Hash != h {
+		return false, nil
+	}
+	f, err := r.worktreeDiffFile(path)
+	if err != nil {
+		return false, err
+	}
+	if f == nil {
+		return h.IsZero(), nil
+	}
+	return f.hash == h, nil
+}
+
+func (r *Repository) commitTree(h plumbing.Hash) (*object.Tree, error) {
+	c, err := r.repo.CommitObject(h)
+	if err != nil {
+		return nil, err
+	}
+	return c.Tree()
+}
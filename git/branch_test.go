@@ -0,0 +1,90 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBranches(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+
+	c1 := commit(t, r, "a", 1)
+	c2 := commit(t, r, "b", 2)
+	setReference(t, r, "refs/heads/feature", c1)
+	setReference(t, r, "refs/remotes/origin/master", c1)
+	if err := r.setUpstream("master", "origin", "refs/heads/master"); err != nil {
+		t.Fatal(err)
+	}
+
+	branches, err := r.Branches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branches) != 3 {
+		t.Fatalf("there should be 3 branches but %d", len(branches))
+	}
+	f, m, o := branches[0], branches[1], branches[2]
+	if f.Name != "feature" || f.Head || f.Remote || f.Upstream != "" {
+		t.Fatalf("feature should be a local branch without upstream but %+v", f)
+	}
+	if m.Name != "master" || !m.Head || m.Hash != c2 || m.Subject != "b" || m.Upstream != "origin/master" || m.Ahead != 1 || m.Behind != 0 {
+		t.Fatalf("master should be HEAD and 1 commit ahead of origin/master but %+v", m)
+	}
+	if o.Name != "origin/master" || !o.Remote || o.Head {
+		t.Fatalf("origin/master should be a remote branch but %+v", o)
+	}
+}
+
+func TestCheckout(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+
+	c1 := commit(t, r, "a", 1)
+	c2 := commit(t, r, "b", 2)
+	setReference(t, r, "refs/remotes/origin/feature", c1)
+
+	// a change of a which is same on both branches is kept, and so is an untracked file
+	ioutil.WriteFile(filepath.Join(dir, "a"), []byte("changed"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "untracked"), []byte("untracked"), 0644)
+	if dirty, err := r.IsDirty(); err != nil || !dirty {
+		t.Fatalf("the worktree should be dirty but %v, %v", dirty, err)
+	}
+	if err := r.Checkout(&Branch{Name: "origin/feature", Remote: true, Hash: c1}); err != nil {
+		t.Fatal(err)
+	}
+	h, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Branch != "feature" || h.Upstream != "origin/feature" {
+		t.Fatalf("the local branch feature tracking origin/feature should be checked out but %+v", h)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b")); !os.IsNotExist(err) {
+		t.Fatalf("b should be removed but %v", err)
+	}
+	for name, content := range map[string]string{"a": "changed", "untracked": "untracked"} {
+		if b, _ := ioutil.ReadFile(filepath.Join(dir, name)); string(b) != content {
+			t.Fatalf("%s should be kept as %q but %q", name, content, b)
+		}
+	}
+	assertStatus(t, r, "a", ' ', 'M')
+	assertStatus(t, r, "b", ' ', ' ')
+
+	// a change of b which is removed on feature is not overwritten
+	ioutil.WriteFile(filepath.Join(dir, "a"), []byte("a"), 0644)
+	if err = r.Checkout(&Branch{Name: "master", Hash: c2}); err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "b"), []byte("changed"), 0644)
+	err = r.Checkout(&Branch{Name: "feature", Hash: c1})
+	if err == nil || !strings.Contains(err.Error(), "b") {
+		t.Fatalf("the change of b should block the checkout but %v", err)
+	}
+	if h, _ = r.Head(); h.Branch != "master" {
+		t.Fatalf("HEAD should stay on master but %s", h.Branch)
+	}
+}
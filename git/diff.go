@@ -0,0 +1,253 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+	"gopkg.in/src-d/go-git.v4/utils/binary"
+	utildiff "gopkg.in/src-d/go-git.v4/utils/diff"
+)
+
+const diffContextLines = 3
+
+// Diff returns the unified diff of the file between HEAD and the index if staged,
+// otherwise between the index and the worktree. it's empty if there is no difference
+func (r *Repository) Diff(path string, staged bool) (text string, err error) {
+	var from, to *diffFile
+	if staged {
+		if from, err = r.headDiffFile(path); err != nil {
+			return
+		}
+		to, err = r.indexDiffFile(path)
+	} else {
+		if from, err = r.indexDiffFile(path); err != nil {
+			return
+		}
+		to, err = r.worktreeDiffFile(path)
+	}
+	if err != nil {
+		return
+	}
+	return encodeDiff(from, to)
+}
+
+// encodeDiff encodes the difference of the files in the unified format like "git diff",
+// nil means that the file doesn't exist
+func encodeDiff(from *diffFile, to *diffFile) (text string, err error) {
+	if from == nil && to == nil || from != nil && to != nil && from.hash == to.hash && from.mode == to.mode {
+		return
+	}
+	buf := new(bytes.Buffer)
+	src, dst := &diffFile{hash: plumbing.ZeroHash}, &diffFile{hash: plumbing.ZeroHash}
+	fromPath, toPath := "/dev/null", "/dev/null"
+	if from != nil {
+		src = from
+		fromPath = "a/" + from.path
+	}
+	if to != nil {
+		dst = to
+		toPath = "b/" + to.path
+	}
+	path := src.path
+	if path == "" {
+		path = dst.path
+	}
+	fmt.Fprintf(buf, "diff --git a/%s b/%s\n", path, path)
+	switch {
+	case from == nil:
+		fmt.Fprintf(buf, "new file mode %o\nindex %s..%s\n", to.mode, src.hash.String()[:7], to.hash.String()[:7])
+	case to == nil:
+		fmt.Fprintf(buf, "deleted file mode %o\nindex %s..%s\n", from.mode, from.hash.String()[:7], dst.hash.String()[:7])
+	case from.mode != to.mode:
+		fmt.Fprintf(buf, "old mode %o\nnew mode %o\n", from.mode, to.mode)
+		if from.hash != to.hash {
+			fmt.Fprintf(buf, "index %s..%s\n", from.hash.String()[:7], to.hash.String()[:7])
+		}
+	default:
+		fmt.Fprintf(buf, "index %s..%s %o\n", from.hash.String()[:7], to.hash.String()[:7], to.mode)
+	}
+	if src.hash == dst.hash {
+		return buf.String(), nil
+	}
+	if src.isBinary() || dst.isBinary() {
+		fmt.Fprintf(buf, "Binary files %s and %s differ\n", fromPath, toPath)
+		return buf.String(), nil
+	}
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", fromPath, toPath)
+	writeHunks(buf, diffLines(src.content, dst.content), diffContextLines)
+	return buf.String(), nil
+}
+
+// diffLine is a line of a diff, op is ' ', '-' or '+'
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines computes the line oriented difference
+func diffLines(src string, dst string) (lines []diffLine) {
+	for _, d := range utildiff.Do(src, dst) {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		}
+		for _, l := range strings.SplitAfter(d.Text, "\n") {
+			if l != "" {
+				lines = append(lines, diffLine{op: op, text: strings.TrimSuffix(l, "\n")})
+			}
+		}
+	}
+	return
+}
+
+// writeHunks writes the changed lines with ctx lines around them,
+// the changes closer than 2*ctx lines are put in the same hunk
+func writeHunks(buf *bytes.Buffer, lines []diffLine, ctx int) {
+	// the line numbers in the old and the new file before each line
+	oldNo, newNo := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	for i, l := range lines {
+		oldNo[i+1], newNo[i+1] = oldNo[i], newNo[i]
+		if l.op != '+' {
+			oldNo[i+1]++
+		}
+		if l.op != '-' {
+			newNo[i+1]++
+		}
+	}
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		start := i - ctx
+		if start < 0 {
+			start = 0
+		}
+		// extend the hunk while the next change is close
+		end := i
+		for j := i; j < len(lines) && j <= end+2*ctx; j++ {
+			if lines[j].op != ' ' {
+				end = j
+			}
+		}
+		end += ctx + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n",
+			hunkRange(oldNo[start], oldNo[end]-oldNo[start]),
+			hunkRange(newNo[start], newNo[end]-newNo[start]))
+		for _, l := range lines[start:end] {
+			buf.WriteByte(l.op)
+			buf.WriteString(l.text + "\n")
+		}
+		i = end
+	}
+}
+
+// hunkRange formats the range of a hunk, before is the number of the lines before the hunk
+func hunkRange(before int, count int) string {
+	if count == 1 {
+		return strconv.Itoa(before + 1)
+	}
+	if count == 0 {
+		return strconv.Itoa(before) + ",0"
+	}
+	return strconv.Itoa(before+1) + "," + strconv.Itoa(count)
+}
+
+func (r *Repository) headDiffFile(path string) (f *diffFile, err error) {
+	hf, err := r.headFile(path)
+	if err != nil || hf == nil {
+		return
+	}
+	content, err := hf.Contents()
+	if err != nil {
+		return
+	}
+	return &diffFile{path: path, hash: hf.Hash, mode: hf.Mode, content: content}, nil
+}
+
+func (r *Repository) indexDiffFile(path string) (f *diffFile, err error) {
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return
+	}
+	e, err := idx.Entry(path)
+	if err == index.ErrEntryNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	blob, err := r.repo.BlobObject(e.Hash)
+	if err != nil {
+		return
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return
+	}
+	return &diffFile{path: path, hash: e.Hash, mode: e.Mode, content: string(content)}, nil
+}
+
+func (r *Repository) worktreeDiffFile(path string) (f *diffFile, err error) {
+	name := filepath.Join(r.path, path)
+	fi, err := os.Lstat(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	var content []byte
+	if fi.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(name)
+		if err != nil {
+			return nil, err
+		}
+		content = []byte(target)
+	} else if content, err = ioutil.ReadFile(name); err != nil {
+		return
+	}
+	mode, err := filemode.NewFromOSFileMode(fi.Mode())
+	if err != nil {
+		return
+	}
+	return &diffFile{
+		path:    path,
+		hash:    plumbing.ComputeHash(plumbing.BlobObject, content),
+		mode:    mode,
+		content: string(content),
+	}, nil
+}
+
+// diffFile is a version of a file
+type diffFile struct {
+	path    string
+	hash    plumbing.Hash
+	mode    filemode.FileMode
+	content string
+}
+
+func (f *diffFile) isBinary() bool {
+	b, err := binary.IsBinary(strings.NewReader(f.content))
+	return err == nil && b
+}
@@ -0,0 +1,49 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "a"), []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"), 0644)
+	w, _ := r.repo.Worktree()
+	w.Add("a")
+	commit(t, r, "b", 1)
+
+	ioutil.WriteFile(filepath.Join(dir, "a"), []byte("1\ntwo\n3\n4\n5\n6\n7\n8\n9\n10\n11\n"), 0644)
+	text, err := r.Diff("a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range []string{"--- a/a", "+++ b/a", "@@ -1,5 +1,5 @@", "-2", "+two", "@@ -8,3 +8,4 @@", "+11"} {
+		if !strings.Contains(text, "\n"+l+"\n") {
+			t.Fatalf("the unstaged diff should have %q but\n%s", l, text)
+		}
+	}
+	if text, err = r.Diff("a", true); err != nil || text != "" {
+		t.Fatalf("nothing should be staged but %q, %v", text, err)
+	}
+
+	r.Stage("a")
+	if text, err = r.Diff("a", true); err != nil || !strings.Contains(text, "\n+two\n") {
+		t.Fatalf("the staged diff should have the change but %q, %v", text, err)
+	}
+
+	// an untracked file
+	ioutil.WriteFile(filepath.Join(dir, "n"), []byte("new\n"), 0644)
+	if text, err = r.Diff("n", false); err != nil || !strings.Contains(text, "new file mode") || !strings.Contains(text, "\n@@ -0,0 +1 @@\n+new\n") {
+		t.Fatalf("the diff should show the new file but %q, %v", text, err)
+	}
+
+	// a binary file
+	ioutil.WriteFile(filepath.Join(dir, "bin"), []byte{0, 1, 2}, 0644)
+	if text, err = r.Diff("bin", false); err != nil || !strings.Contains(text, "Binary files") {
+		t.Fatalf("the diff should tell the binary file but %q, %v", text, err)
+	}
+}
@@ -0,0 +1,435 @@
+package git
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+const (
+	defaultMaxCount = 100
+	maxStatBarWidth = 40
+)
+
+// Commit is a commit in the history with the lines of the graph to draw it
+type Commit struct {
+	Hash    plumbing.Hash
+	Parents []plumbing.Hash
+	Author  string
+	Email   string
+	When    time.Time
+	Subject string
+	Message string
+	Graph   []string // the last line is of the commit, the others connect it to the previous commit
+}
+
+// LogOption is the option argument for Log
+type LogOption struct {
+	Ref      string // a branch, a tag or a hash, HEAD by default
+	Path     string // only the commits which changed the file or the directory are listed
+	MaxCount int    `mapstructure:"max_count"` // 100 by default
+}
+
+// Log lists the commits reachable from the ref, the newest first like "git log --graph"
+func (r *Repository) Log(opt *LogOption) (commits []*Commit, err error) {
+	rev := opt.Ref
+	if rev == "" {
+		rev = "HEAD"
+	}
+	from, err := r.resolve(rev)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", rev, err)
+	}
+	max := opt.MaxCount
+	if max <= 0 {
+		max = defaultMaxCount
+	}
+	path := strings.Trim(opt.Path, "/")
+
+	seen := map[plumbing.Hash]bool{}
+	q := new(commitQueue)
+	push := func(h plumbing.Hash) error {
+		if seen[h] {
+			return nil
+		}
+		seen[h] = true
+		c, err := r.repo.CommitObject(h)
+		if err != nil {
+			return err
+		}
+		heap.Push(q, &queuedCommit{hash: h, parents: c.ParentHashes, when: c.Committer.When.Unix(), commit: c})
+		return nil
+	}
+	if err = push(from); err != nil {
+		return
+	}
+	var walked []*queuedCommit
+	visible := map[plumbing.Hash]bool{}
+	for count := 0; q.Len() > 0 && count < max; {
+		qc := heap.Pop(q).(*queuedCommit)
+		touched := true
+		if path != "" {
+			if touched, err = r.touches(qc.commit, path); err != nil {
+				return
+			}
+		}
+		if touched {
+			count++
+		}
+		visible[qc.hash] = touched
+		walked = append(walked, qc)
+		for _, p := range qc.parents {
+			if err = push(p); err != nil {
+				return
+			}
+		}
+	}
+
+	// the lines of the graph connect the listed commits skipping the hidden ones like "git log --graph -- path"
+	g := new(graph)
+	hidden := map[plumbing.Hash][]plumbing.Hash{}
+	for _, qc := range walked {
+		if !visible[qc.hash] {
+			hidden[qc.hash] = qc.parents
+		}
+	}
+	for _, qc := range walked {
+		if visible[qc.hash] {
+			commits = append(commits, newCommit(qc.commit, g.add(qc.hash, visibleAncestors(qc.parents, hidden))))
+		}
+	}
+	return
+}
+
+// visibleAncestors replaces the hidden commits with their nearest visible ancestors,
+// hidden holds the parents of the hidden commits and they are replaced with the ancestors found.
+// the commits which are not walked are left, their lines continue to the bottom
+func visibleAncestors(hs []plumbing.Hash, hidden map[plumbing.Hash][]plumbing.Hash) (ancestors []plumbing.Hash) {
+	seen := map[plumbing.Hash]bool{}
+	for _, h := range hs {
+		found := []plumbing.Hash{h}
+		if parents, ok := hidden[h]; ok {
+			found = visibleAncestors(parents, hidden)
+			hidden[h] = found
+		}
+		for _, a := range found {
+			if !seen[a] {
+				seen[a] = true
+				ancestors = append(ancestors, a)
+			}
+		}
+	}
+	return
+}
+
+// resolve returns the commit of a branch, a tag or a full hash
+func (r *Repository) resolve(rev string) (h plumbing.Hash, err error) {
+	// go-git resolves only the names of the references
+	if h = plumbing.NewHash(rev); h.String() == rev {
+		return
+	}
+	resolved, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return
+	}
+	return *resolved, nil
+}
+
+func newCommit(c *object.Commit, graph []string) *Commit {
+	message := strings.TrimSpace(c.Message)
+	return &Commit{
+		Hash:    c.Hash,
+		Parents: c.ParentHashes,
+		Author:  c.Author.Name,
+		Email:   c.Author.Email,
+		When:    c.Author.When,
+		Subject: strings.SplitN(message, "\n", 2)[0],
+		Message: message,
+		Graph:   graph,
+	}
+}
+
+// touches tells if the commit changed the path from all of its parents,
+// so a merge which took the path from one of the parents is not listed like "git log -- path"
+func (r *Repository) touches(c *object.Commit, path string) (bool, error) {
+	h, err := entryHash(c, path)
+	if err != nil {
+		return false, err
+	}
+	if len(c.ParentHashes) == 0 {
+		return !h.IsZero(), nil
+	}
+	for _, ph := range c.ParentHashes {
+		p, err := r.repo.CommitObject(ph)
+		if err != nil {
+			return false, err
+		}
+		parent, err := entryHash(p, path)
+		if err != nil {
+			return false, err
+		}
+		if parent == h {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// entryHash returns the hash of the file or the directory in the tree of the commit, ZeroHash if it doesn't exist
+func entryHash(c *object.Commit, path string) (h plumbing.Hash, err error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return
+	}
+	e, err := tree.FindEntry(path)
+	if err != nil {
+		return plumbing.ZeroHash, nil
+	}
+	return e.Hash, nil
+}
+
+// Show returns the stat and the diff of the commit from its first parent like "git show --stat --patch"
+func (r *Repository) Show(h plumbing.Hash) (text string, err error) {
+	c, err := r.repo.CommitObject(h)
+	if err != nil {
+		return
+	}
+	to, err := c.Tree()
+	if err != nil {
+		return
+	}
+	var from *object.Tree
+	if len(c.ParentHashes) > 0 {
+		p, err := r.repo.CommitObject(c.ParentHashes[0])
+		if err != nil {
+			return "", err
+		}
+		if from, err = p.Tree(); err != nil {
+			return "", err
+		}
+	}
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return
+	}
+
+	var stats []fileStat
+	diff := new(bytes.Buffer)
+	for _, ch := range changes {
+		src, dst, err := ch.Files()
+		if err != nil {
+			return "", err
+		}
+		if src == nil && dst == nil {
+			// a submodule
+			continue
+		}
+		fromFile, err := newDiffFile(src)
+		if err != nil {
+			return "", err
+		}
+		toFile, err := newDiffFile(dst)
+		if err != nil {
+			return "", err
+		}
+		d, err := encodeDiff(fromFile, toFile)
+		if err != nil {
+			return "", err
+		}
+		diff.WriteString(d)
+		stats = append(stats, newFileStat(fromFile, toFile))
+	}
+	return formatStats(stats) + "\n" + diff.String(), nil
+}
+
+func newDiffFile(f *object.File) (*diffFile, error) {
+	if f == nil {
+		return nil, nil
+	}
+	content, err := f.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return &diffFile{path: f.Name, hash: f.Hash, mode: f.Mode, content: content}, nil
+}
+
+// fileStat is the numbers of the changed lines of a file
+type fileStat struct {
+	path      string
+	additions int
+	deletions int
+	binary    bool
+}
+
+func newFileStat(from *diffFile, to *diffFile) (s fileStat) {
+	src, dst := &diffFile{}, &diffFile{}
+	if from != nil {
+		src = from
+		s.path = from.path
+	}
+	if to != nil {
+		dst = to
+		s.path = to.path
+	}
+	if src.isBinary() || dst.isBinary() {
+		s.binary = true
+		return
+	}
+	for _, l := range diffLines(src.content, dst.content) {
+		switch l.op {
+		case '+':
+			s.additions++
+		case '-':
+			s.deletions++
+		}
+	}
+	return
+}
+
+// formatStats formats the stats like "git diff --stat"
+func formatStats(stats []fileStat) string {
+	longestPath, most, additions, deletions := 0, 0, 0, 0
+	for _, s := range stats {
+		if len(s.path) > longestPath {
+			longestPath = len(s.path)
+		}
+		if s.additions+s.deletions > most {
+			most = s.additions + s.deletions
+		}
+		additions += s.additions
+		deletions += s.deletions
+	}
+	digits := len(strconv.Itoa(most))
+	buf := new(bytes.Buffer)
+	for _, s := range stats {
+		fmt.Fprintf(buf, " %-*s | ", longestPath, s.path)
+		if s.binary {
+			buf.WriteString("Bin\n")
+			continue
+		}
+		plus, minus := s.additions, s.deletions
+		if most > maxStatBarWidth {
+			// scale the bar keeping at least one mark for a change
+			plus = (plus*maxStatBarWidth + most - 1) / most
+			minus = (minus*maxStatBarWidth + most - 1) / most
+		}
+		fmt.Fprintf(buf, "%*d %s%s\n", digits, s.additions+s.deletions, strings.Repeat("+", plus), strings.Repeat("-", minus))
+	}
+	fmt.Fprintf(buf, " %d %s changed", len(stats), plural(len(stats), "file", "files"))
+	if additions > 0 {
+		fmt.Fprintf(buf, ", %d %s(+)", additions, plural(additions, "insertion", "insertions"))
+	}
+	if deletions > 0 {
+		fmt.Fprintf(buf, ", %d %s(-)", deletions, plural(deletions, "deletion", "deletions"))
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+func plural(n int, one string, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// graph draws the lines of the branches like "git log --graph",
+// a column is a line waiting for the commit of the hash
+type graph struct {
+	columns []plumbing.Hash
+	pending []string // the lines to the parents of a merge, drawn before the next commit
+}
+
+// add puts the commit on the graph and returns the lines to draw,
+// the commits have to be added from the newest
+func (g *graph) add(h plumbing.Hash, parents []plumbing.Hash) (rows []string) {
+	rows, g.pending = g.pending, nil
+	if row := g.collapse(); row != "" {
+		rows = append(rows, row)
+	}
+	col := -1
+	for i, c := range g.columns {
+		if c == h {
+			col = i
+			break
+		}
+	}
+	if col < 0 {
+		// the head of a branch
+		g.columns = append(g.columns, h)
+		col = len(g.columns) - 1
+	}
+	rows = append(rows, g.line(col))
+	if len(parents) == 0 {
+		g.columns[col] = plumbing.ZeroHash
+		return
+	}
+	g.columns[col] = parents[0]
+	for i, p := range parents[1:] {
+		g.pending = append(g.pending, g.expand(col+1+i, p))
+	}
+	return
+}
+
+// line draws the commit at col
+func (g *graph) line(col int) string {
+	buf := []byte(strings.Repeat(" ", 2*len(g.columns)))
+	for k := range g.columns {
+		buf[2*k] = '|'
+	}
+	buf[2*col] = '*'
+	return strings.TrimRight(string(buf), " ")
+}
+
+// collapse removes the finished columns and the columns waiting for the same commit as a column on the left,
+// the columns on the right of them are drawn moving to the left
+func (g *graph) collapse() string {
+	buf := []byte(strings.Repeat(" ", 2*len(g.columns)))
+	columns := []plumbing.Hash{}
+	moved := false
+	for k, c := range g.columns {
+		if c.IsZero() {
+			continue
+		}
+		merged := false
+		for _, l := range columns {
+			merged = merged || l == c
+		}
+		if merged || len(columns) < k {
+			buf[2*k-1] = '/'
+			moved = true
+		} else {
+			buf[2*k] = '|'
+		}
+		if !merged {
+			columns = append(columns, c)
+		}
+	}
+	g.columns = columns
+	if !moved {
+		return ""
+	}
+	return strings.TrimRight(string(buf), " ")
+}
+
+// expand inserts the column for the other parent of a merge at col,
+// the columns on the right of it are drawn moving to the right
+func (g *graph) expand(col int, h plumbing.Hash) string {
+	buf := []byte(strings.Repeat(" ", 2*len(g.columns)+2))
+	for k := range g.columns {
+		if k < col {
+			buf[2*k] = '|'
+		} else {
+			buf[2*k+1] = '\\'
+		}
+	}
+	buf[2*col-1] = '\\'
+	g.columns = append(g.columns[:col], append([]plumbing.Hash{h}, g.columns[col:]...)...)
+	return strings.TrimRight(string(buf), " ")
+}
@@ -0,0 +1,130 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func TestLog(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+
+	c1 := commit(t, r, "a", 1)
+	c2 := commit(t, r, "b", 2)
+	c3 := commit(t, r, "c", 3, c1)
+	c4 := commit(t, r, "d", 4, c2, c3)
+
+	commits, err := r.Log(&LogOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		hash  plumbing.Hash
+		graph []string
+	}{
+		{c4, []string{"*"}},
+		{c3, []string{"|\\", "| *"}},
+		{c2, []string{"* |"}},
+		{c1, []string{"|/", "*"}},
+	}
+	if len(commits) != len(expected) {
+		t.Fatalf("the log should have %d commits but %d", len(expected), len(commits))
+	}
+	for i, e := range expected {
+		if commits[i].Hash != e.hash || !reflect.DeepEqual(commits[i].Graph, e.graph) {
+			t.Fatalf("the commit %d should be %s %q but %s %q", i, e.hash, e.graph, commits[i].Hash, commits[i].Graph)
+		}
+	}
+	if commits[0].Subject != "d" || commits[0].Author != "mcc" || len(commits[0].Parents) != 2 {
+		t.Fatalf("the merge commit is not filled %+v", commits[0])
+	}
+
+	if commits, err = r.Log(&LogOption{Ref: c2.String(), MaxCount: 1}); err != nil || len(commits) != 1 || commits[0].Hash != c2 {
+		t.Fatalf("the log should have only %s but %v, %v", c2, commits, err)
+	}
+	if commits, err = r.Log(&LogOption{Path: "c"}); err != nil || len(commits) != 1 || commits[0].Hash != c3 {
+		t.Fatalf("the log should have only %s which added c but %v, %v", c3, commits, err)
+	}
+	if _, err = r.Log(&LogOption{Ref: "no-such-branch"}); err == nil {
+		t.Fatal("an unknown ref should be an error")
+	}
+}
+
+func TestLogPath(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(r.Path(), "d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the commits which change d are listed, c2, c4 and the merge c6 are hidden
+	c1 := commit(t, r, "d/1", 1)
+	c2 := commit(t, r, "x", 2, c1)
+	c4 := commit(t, r, "y", 3, c2)
+	c3 := commit(t, r, "d/3", 4, c1)
+	c5 := commit(t, r, "d/5", 5, c4, c3)
+	c6 := commit(t, r, "z", 6, c5, c2)
+
+	commits, err := r.Log(&LogOption{Ref: c6.String(), Path: "d/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the lines skip the hidden commits, c5 is drawn as a merge of c1 and c3
+	expected := []struct {
+		hash  plumbing.Hash
+		graph []string
+	}{
+		{c5, []string{"*"}},
+		{c3, []string{"|\\", "| *"}},
+		{c1, []string{"|/", "*"}},
+	}
+	if len(commits) != len(expected) {
+		t.Fatalf("the log should have %d commits but %d", len(expected), len(commits))
+	}
+	for i, e := range expected {
+		if commits[i].Hash != e.hash || !reflect.DeepEqual(commits[i].Graph, e.graph) {
+			t.Fatalf("the commit %d should be %s %q but %s %q", i, e.hash, e.graph, commits[i].Hash, commits[i].Graph)
+		}
+	}
+	if len(commits[0].Parents) != 2 || commits[0].Parents[0] != c4 {
+		t.Fatalf("the parents of the commit should not be rewritten but %v", commits[0].Parents)
+	}
+}
+
+func TestShow(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+
+	commit(t, r, "a", 1)
+	c2 := commit(t, r, "b", 2)
+	text, err := r.Show(c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range []string{" b | 1 +", " 1 file changed, 1 insertion(+)", "new file mode 100644", "+++ b/b", "@@ -0,0 +1 @@", "+b"} {
+		if !strings.Contains(text, l+"\n") {
+			t.Fatalf("the commit should show %q but\n%s", l, text)
+		}
+	}
+}
+
+func TestFormatStats(t *testing.T) {
+	text := formatStats([]fileStat{
+		{path: "main.go", additions: 100, deletions: 20},
+		{path: "a", deletions: 1},
+		{path: "logo.png", binary: true},
+	})
+	expected := "" +
+		" main.go  | 120 " + strings.Repeat("+", 34) + strings.Repeat("-", 7) + "\n" +
+		" a        |   1 -\n" +
+		" logo.png | Bin\n" +
+		" 3 files changed, 100 insertions(+), 21 deletions(-)\n"
+	if text != expected {
+		t.Fatalf("the stats should be\n%s\nbut\n%s", expected, text)
+	}
+}
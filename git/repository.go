@@ -0,0 +1,238 @@
+package git
+
+import (
+	"bufio"
+	"container/heap"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	go_git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Repository is a go-git wrapper for a repository with a worktree
+type Repository struct {
+	path      string // the root of the worktree
+	gitDir    string
+	commonDir string
+	repo      *go_git.Repository
+	cache     aheadBehindCache
+	mutex     sync.Mutex
+}
+
+// Head is the state of HEAD shown in the header of the git widgets
+type Head struct {
+	Branch   string // the short name of the branch, or the abbreviated hash if HEAD is detached
+	Detached bool
+	Upstream string // like "origin/master", empty if the branch has no upstream
+	Ahead    int
+	Behind   int
+	Stashes  int
+}
+
+type aheadBehindCache struct {
+	local    plumbing.Hash
+	upstream plumbing.Hash
+	ahead    int
+	behind   int
+}
+
+// NewRepository opens the repository which includes the path,
+// utils.NotGitRepositoryError is returned if there is no repository
+func NewRepository(path string) (r *Repository, err error) {
+	repo, paths, err := PlainOpen(path)
+	if err != nil {
+		return
+	}
+	r = &Repository{
+		path:      paths.WorkTree,
+		gitDir:    paths.GitDir,
+		commonDir: paths.CommonDir,
+		repo:      repo,
+	}
+	return
+}
+
+// Path returns the root of the worktree
+func (r *Repository) Path() string {
+	return r.path
+}
+
+// GitDir returns the path of the git dir which has HEAD and the index of the worktree
+func (r *Repository) GitDir() string {
+	return r.gitDir
+}
+
+// CommonDir returns the path of the git dir which has the objects and the refs,
+// it differs from GitDir in a linked worktree
+func (r *Repository) CommonDir() string {
+	return r.commonDir
+}
+
+// Status returns the status of the worktree
+func (r *Repository) Status() (status go_git.Status, err error) {
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return
+	}
+	return w.Status()
+}
+
+// Head returns the current branch with the ahead/behind counts of its upstream and the number of stashes
+func (r *Repository) Head() (h *Head, err error) {
+	ref, err := r.repo.Head()
+	if err != nil {
+		return
+	}
+	h = new(Head)
+	h.Stashes = r.countStashes()
+	if !ref.Name().IsBranch() {
+		h.Detached = true
+		h.Branch = ref.Hash().String()[:7]
+		return
+	}
+	h.Branch = ref.Name().Short()
+
+	upstream, err := r.upstream(h.Branch)
+	if err != nil || upstream == "" {
+		return h, nil
+	}
+	uref, err := r.repo.Reference(upstream, true)
+	if err != nil {
+		// the upstream has not been fetched yet
+		return h, nil
+	}
+	h.Upstream = upstream.Short()
+	h.Ahead, h.Behind, err = r.aheadBehind(ref.Hash(), uref.Hash())
+	return
+}
+
+// upstream returns the reference of the upstream of the branch configured by "git branch -u"
+func (r *Repository) upstream(branch string) (name plumbing.ReferenceName, err error) {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return
+	}
+	s := cfg.Raw.Section("branch")
+	if !s.HasSubsection(branch) {
+		return
+	}
+	ss := s.Subsection(branch)
+	remote, merge := ss.Option("remote"), ss.Option("merge")
+	if remote == "" || merge == "" {
+		return
+	}
+	if remote == "." {
+		return plumbing.ReferenceName(merge), nil
+	}
+	return plumbing.ReferenceName("refs/remotes/" + remote + "/" + strings.TrimPrefix(merge, "refs/heads/")), nil
+}
+
+// countStashes counts the entries of the reflog of refs/stash
+func (r *Repository) countStashes() (n int) {
+	f, err := os.Open(filepath.Join(r.CommonDir(), "logs", "refs", "stash"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if strings.TrimSpace(s.Text()) != "" {
+			n++
+		}
+	}
+	return
+}
+
+// aheadBehind counts the commits reachable only from local and only from upstream.
+// the commits are walked from the newest until the rest are reachable from both like "git rev-list --left-right --count"
+func (r *Repository) aheadBehind(local plumbing.Hash, upstream plumbing.Hash) (ahead int, behind int, err error) {
+	if local == upstream {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.cache.local == local && r.cache.upstream == upstream {
+		return r.cache.ahead, r.cache.behind, nil
+	}
+
+	const (
+		fromLocal    = 1
+		fromUpstream = 2
+		fromBoth     = fromLocal | fromUpstream
+	)
+	flags := map[plumbing.Hash]int{}
+	q := new(commitQueue)
+	push := func(h plumbing.Hash, flag int) error {
+		if flags[h]&flag == flag {
+			return nil
+		}
+		queued := flags[h] != 0
+		flags[h] |= flag
+		if queued {
+			return nil
+		}
+		c, err := r.repo.CommitObject(h)
+		if err != nil {
+			return err
+		}
+		heap.Push(q, &queuedCommit{hash: h, parents: c.ParentHashes, when: c.Committer.When.Unix(), commit: c})
+		return nil
+	}
+	if err = push(local, fromLocal); err != nil {
+		return
+	}
+	if err = push(upstream, fromUpstream); err != nil {
+		return
+	}
+	for q.Len() > 0 && !q.allFlagged(flags, fromBoth) {
+		c := heap.Pop(q).(*queuedCommit)
+		f := flags[c.hash]
+		switch f {
+		case fromLocal:
+			ahead++
+		case fromUpstream:
+			behind++
+		}
+		for _, p := range c.parents {
+			if err = push(p, f); err != nil {
+				return
+			}
+		}
+	}
+	r.cache = aheadBehindCache{local: local, upstream: upstream, ahead: ahead, behind: behind}
+	return
+}
+
+type queuedCommit struct {
+	hash    plumbing.Hash
+	parents []plumbing.Hash
+	when    int64
+	commit  *object.Commit
+}
+
+// commitQueue is a priority queue of commits, the newest commit comes first
+type commitQueue []*queuedCommit
+
+func (q commitQueue) Len() int            { return len(q) }
+func (q commitQueue) Less(i, j int) bool  { return q[i].when > q[j].when }
+func (q commitQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x interface{}) { *q = append(*q, x.(*queuedCommit)) }
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+func (q commitQueue) allFlagged(flags map[plumbing.Hash]int, flag int) bool {
+	for _, c := range q {
+		if flags[c.hash] != flag {
+			return false
+		}
+	}
+	return true
+}
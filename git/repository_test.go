@@ -0,0 +1,108 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	go_git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// newTestRepository initializes a repository in a temporary directory
+func newTestRepository(t *testing.T) (r *Repository, dir string) {
+	dir, err := ioutil.TempDir("", "mcc-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = go_git.PlainInit(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if r, err = NewRepository(dir); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// commit writes a file and commits it at the hour, on HEAD if parents are not given
+func commit(t *testing.T, r *Repository, name string, hour int, parents ...plumbing.Hash) plumbing.Hash {
+	if err := ioutil.WriteFile(filepath.Join(r.Path(), name), []byte(name), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	when := time.Date(2018, 5, 1, hour, 0, 0, 0, time.UTC)
+	h, err := w.Commit(name, &go_git.CommitOptions{
+		Author:  &object.Signature{Name: "mcc", Email: "mcc@example.com", When: when},
+		Parents: parents,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func setReference(t *testing.T, r *Repository, name string, h plumbing.Hash) {
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(name), h)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHead(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+
+	c1 := commit(t, r, "a", 1)
+	commit(t, r, "b", 2)
+	c3 := commit(t, r, "c", 3)
+	h, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Branch != "master" || h.Upstream != "" || h.Stashes != 0 {
+		t.Fatalf("master should have no upstream but %+v", h)
+	}
+
+	// origin/master is 2 commits behind
+	setReference(t, r, "refs/remotes/origin/master", c1)
+	f, err := os.OpenFile(filepath.Join(r.GitDir(), "config"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("[branch \"master\"]\n\tremote = origin\n\tmerge = refs/heads/master\n")
+	f.Close()
+	if h, err = r.Head(); err != nil {
+		t.Fatal(err)
+	}
+	if h.Upstream != "origin/master" || h.Ahead != 2 || h.Behind != 0 {
+		t.Fatalf("master should be 2 commits ahead of origin/master but %+v", h)
+	}
+
+	// diverged, master has a commit on c1 and origin/master has c2 and c3
+	commit(t, r, "d", 4, c1)
+	setReference(t, r, "refs/remotes/origin/master", c3)
+	if h, err = r.Head(); err != nil {
+		t.Fatal(err)
+	}
+	if h.Ahead != 1 || h.Behind != 2 {
+		t.Fatalf("master should be 1 ahead and 2 behind but %+v", h)
+	}
+
+	os.MkdirAll(filepath.Join(r.GitDir(), "logs", "refs"), 0755)
+	ioutil.WriteFile(filepath.Join(r.GitDir(), "logs", "refs", "stash"), []byte("0 1 mcc stash@{0}\n1 2 mcc stash@{1}\n"), 0644)
+	setReference(t, r, "HEAD", c3)
+	if h, err = r.Head(); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Detached || h.Branch != c3.String()[:7] || h.Stashes != 2 {
+		t.Fatalf("HEAD should be detached with 2 stashes but %+v", h)
+	}
+}
@@ -0,0 +1,108 @@
+package git
+
+import (
+	"strings"
+
+	"github.com/qmu/mcc/utils"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	go_git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+	"gopkg.in/src-d/go-git.v4/storage"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// PlainOpen opens the go-git repository which includes the path. unlike go-git's one,
+// it looks up the parent directories and follows GIT_DIR and the git dir of a linked worktree
+func PlainOpen(path string) (repo *go_git.Repository, paths *utils.GitPaths, err error) {
+	paths, err = utils.FindGitRepository(path)
+	if err != nil {
+		return
+	}
+	var s storage.Storer
+	if s, err = filesystem.NewStorage(osfs.New(paths.CommonDir)); err != nil {
+		return
+	}
+	if paths.GitDir != paths.CommonDir {
+		own, err := filesystem.NewStorage(osfs.New(paths.GitDir))
+		if err != nil {
+			return nil, nil, err
+		}
+		s = &linkedStorage{Storer: s, own: own}
+	}
+	repo, err = go_git.Open(s, osfs.New(paths.WorkTree))
+	return
+}
+
+// linkedStorage is the storage of a linked worktree made by "git worktree add",
+// HEAD and the index are in the own git dir of the worktree and the others are in the common dir
+type linkedStorage struct {
+	storage.Storer
+	own storage.Storer
+}
+
+// isPerWorktree tells if the reference is in the own git dir of a linked worktree
+func isPerWorktree(name plumbing.ReferenceName) bool {
+	n := name.String()
+	return !strings.HasPrefix(n, "refs/") || strings.HasPrefix(n, "refs/bisect/") || strings.HasPrefix(n, "refs/worktree/")
+}
+
+func (s *linkedStorage) storerOf(name plumbing.ReferenceName) storage.Storer {
+	if isPerWorktree(name) {
+		return s.own
+	}
+	return s.Storer
+}
+
+// SetReference is the implementation of storer.ReferenceStorer
+func (s *linkedStorage) SetReference(ref *plumbing.Reference) error {
+	return s.storerOf(ref.Name()).SetReference(ref)
+}
+
+// CheckAndSetReference is the implementation of storer.ReferenceStorer
+func (s *linkedStorage) CheckAndSetReference(ref *plumbing.Reference, old *plumbing.Reference) error {
+	return s.storerOf(ref.Name()).CheckAndSetReference(ref, old)
+}
+
+// Reference is the implementation of storer.ReferenceStorer
+func (s *linkedStorage) Reference(name plumbing.ReferenceName) (*plumbing.Reference, error) {
+	return s.storerOf(name).Reference(name)
+}
+
+// RemoveReference is the implementation of storer.ReferenceStorer
+func (s *linkedStorage) RemoveReference(name plumbing.ReferenceName) error {
+	return s.storerOf(name).RemoveReference(name)
+}
+
+// IterReferences is the implementation of storer.ReferenceStorer,
+// the shared references come from the common dir and the others from the own git dir
+func (s *linkedStorage) IterReferences() (storer.ReferenceIter, error) {
+	var refs []*plumbing.Reference
+	for _, st := range []storage.Storer{s.Storer, s.own} {
+		iter, err := st.IterReferences()
+		if err != nil {
+			return nil, err
+		}
+		err = iter.ForEach(func(ref *plumbing.Reference) error {
+			if s.storerOf(ref.Name()) == st {
+				refs = append(refs, ref)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return storer.NewReferenceSliceIter(refs), nil
+}
+
+// Index is the implementation of storer.IndexStorer
+func (s *linkedStorage) Index() (*index.Index, error) {
+	return s.own.Index()
+}
+
+// SetIndex is the implementation of storer.IndexStorer
+func (s *linkedStorage) SetIndex(idx *index.Index) error {
+	return s.own.SetIndex(idx)
+}
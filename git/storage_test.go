@@ -0,0 +1,64 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLinkedWorktree opens a worktree laid out like "git worktree add ../linked feature"
+func TestLinkedWorktree(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+	c1 := commit(t, r, "a", 1)
+	setReference(t, r, "refs/heads/feature", c1)
+
+	linked, err := ioutil.TempDir("", "mcc-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(linked)
+	gitDir := filepath.Join(r.GitDir(), "worktrees", "linked")
+	os.MkdirAll(gitDir, 0755)
+	ioutil.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature\n"), 0644)
+	ioutil.WriteFile(filepath.Join(gitDir, "commondir"), []byte("../..\n"), 0644)
+	ioutil.WriteFile(filepath.Join(linked, ".git"), []byte("gitdir: "+gitDir+"\n"), 0644)
+	ioutil.WriteFile(filepath.Join(linked, "a"), []byte("a"), 0644)
+
+	l, err := NewRepository(linked)
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if l.GitDir() != gitDir || l.CommonDir() != r.GitDir() {
+		t.Fatalf("the git dir should be %s and the common dir %s but %s and %s", gitDir, r.GitDir(), l.GitDir(), l.CommonDir())
+	}
+	h, err := l.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Branch != "feature" {
+		t.Fatalf("HEAD of the linked worktree should be on feature but %s", h.Branch)
+	}
+	branches, err := l.Branches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branches) != 2 || branches[0].Name != "feature" || !branches[0].Head || branches[1].Head {
+		t.Fatalf("feature should be the head of the branches but %+v", branches)
+	}
+
+	// the index is of the linked worktree
+	if err = l.Stage("a"); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(gitDir, "index")); err != nil {
+		t.Fatalf("the index should be written in the git dir of the linked worktree: %v", err)
+	}
+	if dirty, err := l.IsDirty(); err != nil || dirty {
+		t.Fatalf("the linked worktree should be clean but %v, error: %v", dirty, err)
+	}
+	if h, err = r.Head(); err != nil || h.Branch != "master" {
+		t.Fatalf("HEAD of the main worktree should stay on master but %+v, error: %v", h, err)
+	}
+}
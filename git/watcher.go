@@ -0,0 +1,236 @@
+package git
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	fsnotify "gopkg.in/fsnotify.v1"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+)
+
+const defaultDebounce = 300 * time.Millisecond
+
+// Watcher calls OnChange when files in the worktree, the index, the refs or the stashes are changed.
+// the files ignored by .gitignore are not watched, and the changes in a row are notified once
+type Watcher struct {
+	watcher   *fsnotify.Watcher
+	root      string
+	gitDir    string
+	commonDir string
+	debounce  time.Duration
+	onChange  func()
+	matcher   gitignore.Matcher
+	timer     *time.Timer
+	mutex     sync.Mutex
+}
+
+// WatcherOption is the option argument for NewWatcher
+type WatcherOption struct {
+	Repository *Repository
+	Debounce   time.Duration // 300ms by default
+	RefsOnly   bool          // only HEAD, the index and the refs are watched
+	OnChange   func()
+}
+
+// NewWatcher starts watching the repository
+func NewWatcher(opt *WatcherOption) (w *Watcher, err error) {
+	w = new(Watcher)
+	w.root = opt.Repository.Path()
+	w.gitDir = opt.Repository.GitDir()
+	w.commonDir = opt.Repository.CommonDir()
+	w.onChange = opt.OnChange
+	w.debounce = opt.Debounce
+	if w.debounce == 0 {
+		w.debounce = defaultDebounce
+	}
+	w.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return
+	}
+	if !opt.RefsOnly {
+		if err = w.addWorktree(); err != nil {
+			w.watcher.Close()
+			return
+		}
+	}
+	// HEAD and index are in the git dir, packed-refs and the refs of the branches and the stash are in the common dir,
+	// which is the same directory unless the worktree is linked
+	dirs := []string{w.gitDir, w.commonDir, filepath.Join(w.commonDir, "refs")}
+	if w.commonDir == w.gitDir {
+		dirs = dirs[1:]
+	}
+	for _, dir := range dirs {
+		if err = w.addRecursively(dir, dir == w.gitDir || dir == w.commonDir); err != nil {
+			w.watcher.Close()
+			return
+		}
+	}
+	go w.loop()
+	return
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	w.mutex.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mutex.Unlock()
+	return w.watcher.Close()
+}
+
+func (w *Watcher) loop() {
+	for {
+		select {
+		case e, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.isRelevant(e) {
+				w.schedule()
+			}
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// isRelevant tells if the event changes the status,
+// it also starts watching the created directories
+func (w *Watcher) isRelevant(e fsnotify.Event) bool {
+	if e.Op == fsnotify.Chmod {
+		return false
+	}
+	for _, dir := range []string{w.gitDir, w.commonDir} {
+		if e.Name == dir || strings.HasPrefix(e.Name, dir+string(filepath.Separator)) {
+			rel, err := filepath.Rel(dir, e.Name)
+			if err != nil {
+				return false
+			}
+			return isRepositoryFile(filepath.ToSlash(rel))
+		}
+	}
+
+	rel, err := filepath.Rel(w.root, e.Name)
+	if err != nil {
+		return false
+	}
+	fi, err := os.Stat(e.Name)
+	isDir := err == nil && fi.IsDir()
+	if filepath.Base(e.Name) == ".gitignore" {
+		// the ignored directories may be changed
+		w.addWorktree()
+		return true
+	}
+	w.mutex.Lock()
+	ignored := w.matcher.Match(strings.Split(filepath.ToSlash(rel), "/"), isDir)
+	w.mutex.Unlock()
+	if ignored {
+		return false
+	}
+	if isDir && e.Op&fsnotify.Create != 0 {
+		w.addWorktree()
+	}
+	return true
+}
+
+// isRepositoryFile tells if the file in the git dir is the index, HEAD or a ref
+func isRepositoryFile(rel string) bool {
+	if strings.HasSuffix(rel, ".lock") {
+		return false
+	}
+	return rel == "index" || rel == "HEAD" || rel == "packed-refs" || strings.HasPrefix(rel, "refs/")
+}
+
+func (w *Watcher) schedule() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.debounce, w.onChange)
+}
+
+// addWorktree watches the directories of the worktree except the ignored ones
+// and reloads the patterns of .gitignore
+func (w *Watcher) addWorktree() (err error) {
+	dirs, patterns := scanWorktree(w.root, w.commonDir)
+	w.mutex.Lock()
+	w.matcher = gitignore.NewMatcher(patterns)
+	w.mutex.Unlock()
+	for _, dir := range dirs {
+		if err = w.watcher.Add(dir); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// addRecursively watches the directory and its sub directories
+func (w *Watcher) addRecursively(root string, onlyRoot bool) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if err := w.watcher.Add(path); err != nil {
+			return err
+		}
+		if onlyRoot {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// scanWorktree returns the directories which are not ignored
+// and the patterns of info/exclude and .gitignore files in them
+func scanWorktree(root string, gitDir string) (dirs []string, patterns []gitignore.Pattern) {
+	patterns = readPatterns(filepath.Join(gitDir, "info", "exclude"), nil)
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if path == gitDir || info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		var domain []string
+		if path != root {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return filepath.SkipDir
+			}
+			domain = strings.Split(filepath.ToSlash(rel), "/")
+			if gitignore.NewMatcher(patterns).Match(domain, true) {
+				return filepath.SkipDir
+			}
+		}
+		patterns = append(patterns, readPatterns(filepath.Join(path, ".gitignore"), domain)...)
+		dirs = append(dirs, path)
+		return nil
+	})
+	return
+}
+
+// readPatterns reads a file of gitignore patterns, the patterns are relative to domain
+func readPatterns(path string, domain []string) (ps []gitignore.Pattern) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		ps = append(ps, gitignore.ParsePattern(line, domain))
+	}
+	return
+}
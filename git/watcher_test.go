@@ -0,0 +1,111 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScanWorktree(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+	for _, d := range []string{"src/vendor", "node_modules/pkg", "logs"} {
+		os.MkdirAll(filepath.Join(dir, d), 0755)
+	}
+	ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte("# deps\nnode_modules/\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "src", ".gitignore"), []byte("vendor\n"), 0644)
+	os.MkdirAll(filepath.Join(r.GitDir(), "info"), 0755)
+	ioutil.WriteFile(filepath.Join(r.GitDir(), "info", "exclude"), []byte("logs\n"), 0644)
+
+	dirs, _ := scanWorktree(r.Path(), r.GitDir())
+	expected := []string{dir, filepath.Join(dir, "src")}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Fatalf("%v should be watched but %v", expected, dirs)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\n"), 0644)
+
+	changed := make(chan bool, 10)
+	w, err := NewWatcher(&WatcherOption{
+		Repository: r,
+		Debounce:   50 * time.Millisecond,
+		OnChange: func() {
+			changed <- true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	expect := func(what string, notified bool) {
+		select {
+		case <-changed:
+			if !notified {
+				t.Fatalf("%s should not be notified", what)
+			}
+		case <-time.After(500 * time.Millisecond):
+			if notified {
+				t.Fatalf("%s should be notified", what)
+			}
+		}
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "debug.log"), []byte("ignored"), 0644)
+	expect("an ignored file", false)
+
+	// the changes in a row are notified once
+	for i := 0; i < 3; i++ {
+		ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte{byte(i)}, 0644)
+	}
+	expect("a new file", true)
+	expect("the debounced changes", false)
+
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	expect("a new directory", true)
+	ioutil.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("b"), 0644)
+	expect("a file in the new directory", true)
+
+	commit(t, r, "a.txt", 1)
+	expect("a commit", true)
+}
+
+func TestWatcherRefsOnly(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+	c1 := commit(t, r, "a", 1)
+
+	changed := make(chan bool, 10)
+	w, err := NewWatcher(&WatcherOption{
+		Repository: r,
+		Debounce:   50 * time.Millisecond,
+		RefsOnly:   true,
+		OnChange: func() {
+			changed <- true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	ioutil.WriteFile(filepath.Join(dir, "b"), []byte("b"), 0644)
+	select {
+	case <-changed:
+		t.Fatal("a file in the worktree should not be notified")
+	case <-time.After(300 * time.Millisecond):
+	}
+	setReference(t, r, "refs/heads/feature", c1)
+	select {
+	case <-changed:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("a new branch should be notified")
+	}
+}
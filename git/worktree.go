@@ -0,0 +1,223 @@
+package git
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	go_git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	format "gopkg.in/src-d/go-git.v4/plumbing/format/config"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Stage adds the changes of the file to the index, a deleted file is removed from the index
+func (r *Repository) Stage(path string) (err error) {
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return
+	}
+	if _, err = os.Lstat(filepath.Join(r.path, path)); os.IsNotExist(err) {
+		_, err = w.Remove(path)
+		return
+	}
+	_, err = w.Add(path)
+	return
+}
+
+// Unstage puts the file in the index back to HEAD, the worktree is not changed
+func (r *Repository) Unstage(path string) (err error) {
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return
+	}
+	head, err := r.headFile(path)
+	if err != nil {
+		return
+	}
+	if head == nil {
+		// a new file
+		if _, err = idx.Remove(path); err != nil && err != index.ErrEntryNotFound {
+			return
+		}
+		return r.repo.Storer.SetIndex(idx)
+	}
+	e, err := idx.Entry(path)
+	if err == index.ErrEntryNotFound {
+		// a deleted file
+		e = &index.Entry{Name: path}
+		idx.Entries = append(idx.Entries, e)
+	} else if err != nil {
+		return
+	}
+	e.Hash = head.Hash
+	e.Mode = head.Mode
+	// git compares the content as the file looks modified
+	e.ModifiedAt = time.Time{}
+	return r.repo.Storer.SetIndex(idx)
+}
+
+// Discard drops the changes of the file in the worktree, and also in the index if staged is true.
+// an untracked file is deleted
+func (r *Repository) Discard(path string, staged bool) (err error) {
+	if staged {
+		if err = r.Unstage(path); err != nil {
+			return
+		}
+	}
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return
+	}
+	e, err := idx.Entry(path)
+	if err == index.ErrEntryNotFound {
+		return os.Remove(filepath.Join(r.path, path))
+	}
+	if err != nil {
+		return
+	}
+	return r.checkout(path, e.Hash, e.Mode)
+}
+
+// checkout writes the blob to the file in the worktree
+func (r *Repository) checkout(path string, h plumbing.Hash, mode filemode.FileMode) (err error) {
+	blob, err := r.repo.BlobObject(h)
+	if err != nil {
+		return
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	dst := filepath.Join(r.path, path)
+	if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return
+	}
+	if err = os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return
+	}
+	if mode == filemode.Symlink {
+		target, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(string(target), dst)
+	}
+	perm, err := mode.ToOSFileMode()
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm.Perm())
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, err = io.Copy(f, reader)
+	return
+}
+
+// headFile returns the file in the tree of HEAD, nil if HEAD doesn't have it
+func (r *Repository) headFile(path string) (f *object.File, err error) {
+	ref, err := r.repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		// no commit yet
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	c, err := r.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return
+	}
+	f, err = tree.File(path)
+	if err == object.ErrFileNotFound {
+		return nil, nil
+	}
+	return
+}
+
+// Commit records the staged changes with the message
+func (r *Repository) Commit(message string) (h plumbing.Hash, err error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return h, errors.New("the commit message is empty")
+	}
+	status, err := r.Status()
+	if err != nil {
+		return
+	}
+	staged := false
+	for _, s := range status {
+		if s.Staging != go_git.Unmodified && s.Staging != go_git.Untracked {
+			staged = true
+		}
+	}
+	if !staged {
+		return h, errors.New("nothing is staged")
+	}
+	sig, err := r.signature()
+	if err != nil {
+		return
+	}
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return
+	}
+	return w.Commit(message+"\n", &go_git.CommitOptions{Author: sig})
+}
+
+// signature returns the author from $GIT_AUTHOR_NAME and $GIT_AUTHOR_EMAIL,
+// or user.name and user.email of the repository or the global config
+func (r *Repository) signature() (sig *object.Signature, err error) {
+	name, email := os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL")
+	configs := []*format.Config{}
+	if cfg, err := r.repo.Config(); err == nil {
+		configs = append(configs, cfg.Raw)
+	}
+	if home, err := homedir.Dir(); err == nil {
+		for _, path := range []string{filepath.Join(home, ".gitconfig"), filepath.Join(home, ".config", "git", "config")} {
+			if cfg := readConfigFile(path); cfg != nil {
+				configs = append(configs, cfg)
+			}
+		}
+	}
+	for _, cfg := range configs {
+		s := cfg.Section("user")
+		if name == "" {
+			name = s.Option("name")
+		}
+		if email == "" {
+			email = s.Option("email")
+		}
+	}
+	if name == "" || email == "" {
+		return nil, errors.New("set user.name and user.email by git config")
+	}
+	return &object.Signature{Name: name, Email: email, When: time.Now()}, nil
+}
+
+func readConfigFile(path string) *format.Config {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	cfg := format.New()
+	if err := format.NewDecoder(f).Decode(cfg); err != nil {
+		return nil
+	}
+	return cfg
+}
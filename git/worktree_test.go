@@ -0,0 +1,108 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	go_git "gopkg.in/src-d/go-git.v4"
+)
+
+func assertStatus(t *testing.T, r *Repository, path string, staging go_git.StatusCode, worktree go_git.StatusCode) {
+	status, err := r.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// status.File returns an untracked status for a clean file
+	s, ok := status[path]
+	if !ok {
+		s = &go_git.FileStatus{Staging: go_git.Unmodified, Worktree: go_git.Unmodified}
+	}
+	if s.Staging != staging || s.Worktree != worktree {
+		t.Fatalf("%s should be %c%c but %c%c", path, staging, worktree, s.Staging, s.Worktree)
+	}
+}
+
+func TestStageAndUnstage(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+	commit(t, r, "a", 1)
+
+	// a modified file
+	ioutil.WriteFile(filepath.Join(dir, "a"), []byte("modified"), 0644)
+	if err := r.Stage("a"); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(t, r, "a", go_git.Modified, go_git.Unmodified)
+	if err := r.Unstage("a"); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(t, r, "a", go_git.Unmodified, go_git.Modified)
+	if err := r.Discard("a", false); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(dir, "a")); string(b) != "a" {
+		t.Fatalf("the change should be discarded but %q", b)
+	}
+	assertStatus(t, r, "a", go_git.Unmodified, go_git.Unmodified)
+
+	// a deleted file
+	os.Remove(filepath.Join(dir, "a"))
+	if err := r.Stage("a"); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(t, r, "a", go_git.Deleted, go_git.Unmodified)
+	if err := r.Unstage("a"); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(t, r, "a", go_git.Unmodified, go_git.Deleted)
+	if err := r.Discard("a", false); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(t, r, "a", go_git.Unmodified, go_git.Unmodified)
+
+	// a new file
+	ioutil.WriteFile(filepath.Join(dir, "n"), []byte("new"), 0644)
+	if err := r.Stage("n"); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(t, r, "n", go_git.Added, go_git.Unmodified)
+	if err := r.Discard("n", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "n")); !os.IsNotExist(err) {
+		t.Fatalf("the new file should be deleted but %v", err)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	r, dir := newTestRepository(t)
+	defer os.RemoveAll(dir)
+	commit(t, r, "a", 1)
+	os.Setenv("GIT_AUTHOR_NAME", "mcc")
+	os.Setenv("GIT_AUTHOR_EMAIL", "mcc@example.com")
+	defer os.Unsetenv("GIT_AUTHOR_NAME")
+	defer os.Unsetenv("GIT_AUTHOR_EMAIL")
+
+	ioutil.WriteFile(filepath.Join(dir, "a"), []byte("modified"), 0644)
+	if _, err := r.Commit("nothing"); err == nil {
+		t.Fatal("nothing should be committed without staged changes")
+	}
+	r.Stage("a")
+	if _, err := r.Commit(" \n"); err == nil {
+		t.Fatal("an empty message should be an error")
+	}
+	h, err := r.Commit("Modify a\n\nbecause of a test\n\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.repo.CommitObject(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Message != "Modify a\n\nbecause of a test\n" || c.Author.Email != "mcc@example.com" {
+		t.Fatalf("the commit should have the message and the author but %q by %s", c.Message, c.Author.Email)
+	}
+	assertStatus(t, r, "a", go_git.Unmodified, go_git.Unmodified)
+}
@@ -1,39 +1,57 @@
 package github
 
 import (
-	"bufio"
+	"bytes"
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
-	"os/signal"
 	"path/filepath"
 	"strings"
-	"syscall"
+	"time"
 
-	"github.com/github/hub/ui"
-	"github.com/github/hub/utils"
-	"github.com/gizak/termui"
 	go_github "github.com/google/go-github/github"
 	"github.com/mitchellh/go-homedir"
-	"golang.org/x/crypto/ssh/terminal"
+	"github.com/qmu/mcc/utils"
 	"golang.org/x/oauth2"
 	"gopkg.in/yaml.v1"
-	// "github.com/k0kubun/pp"
+)
+
+const (
+	tokenCommandTimeout = 10 * time.Second
+	deviceGrantType     = "urn:ietf:params:oauth:grant-type:device_code"
 )
 
 // AuthService manages GitHub user authentication
 type AuthService struct {
 	defaultConfigFile string
+	ghConfigDir       string
 	host              string
 	user              string
 	oauthToken        string
 	protocol          string
+	tokenCommand      string
+	clientID          string
+	apiBaseURL        string
+	uploadURL         string
+	webBaseURL        string // the endpoint of the OAuth device flow
+	httpClient        *http.Client
+	getenv            func(string) string
 	client            *go_github.Client
 }
 
+// AuthServiceOption is the option argument for NewAuthService
+type AuthServiceOption struct {
+	Host         string // github_url, e.g. "github.com", "https://github.example.com"
+	TokenCommand string // a command printing a token, e.g. "gh auth token"
+	ClientID     string // the client id of an OAuth App which enables the device flow
+	CABundle     string // a PEM file of the certificates trusted in addition to the system ones
+}
+
 type yamlHost struct {
 	User       string `yaml:"user"`
 	OAuthToken string `yaml:"oauth_token"`
@@ -41,162 +59,261 @@ type yamlHost struct {
 }
 type yamlConfig map[string][]yamlHost
 
+// ghHost is an entry of hosts.yml of the gh CLI
+type ghHost struct {
+	User        string `yaml:"user"`
+	OAuthToken  string `yaml:"oauth_token"`
+	GitProtocol string `yaml:"git_protocol"`
+}
+
+// deviceCode is the response of "POST /login/device/code"
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// accessToken is the response of "POST /login/oauth/access_token"
+type accessToken struct {
+	AccessToken      string `json:"access_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	Interval         int    `json:"interval"`
+}
+
 // NewAuthService constructs a new AuthService
-func NewAuthService(host string) (a *AuthService, err error) {
+func NewAuthService(opt *AuthServiceOption) (a *AuthService, err error) {
 	a = new(AuthService)
 	homeDir, err := homedir.Dir()
 	if err != nil {
 		return
 	}
 	a.defaultConfigFile = filepath.Join(homeDir, ".config", "mcc")
-	a.host = host
+	a.ghConfigDir = os.Getenv("GH_CONFIG_DIR")
+	if a.ghConfigDir == "" && os.Getenv("XDG_CONFIG_HOME") != "" {
+		a.ghConfigDir = filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "gh")
+	}
+	if a.ghConfigDir == "" {
+		a.ghConfigDir = filepath.Join(homeDir, ".config", "gh")
+	}
+	e, err := newEndpoint(opt.Host)
+	if err != nil {
+		return
+	}
+	if a.httpClient, err = newHTTPClient(opt.CABundle); err != nil {
+		return
+	}
+	a.host = e.host
+	a.apiBaseURL = e.apiBaseURL()
+	a.uploadURL = e.uploadURL()
+	a.webBaseURL = e.webBaseURL()
+	a.tokenCommand = opt.TokenCommand
+	a.clientID = opt.ClientID
+	a.getenv = os.Getenv
 	a.protocol = "https"
 	return
 }
 
-// InitClient initializes an AuthService instance
-// by loading ~/.config/mcc or basic authentication
+// InitClient initializes a client with the first valid token of
+// $GITHUB_TOKEN / $GH_TOKEN, github_token_command, hosts.yml of the gh CLI and ~/.config/mcc
 func (a *AuthService) InitClient() (client *go_github.Client, err error) {
+	var failures []string
+	for _, source := range []struct {
+		name string
+		fn   func() (string, error)
+	}{
+		{"environment variable", a.tokenFromEnv},
+		{"github_token_command", a.tokenFromCommand},
+		{"gh CLI", a.tokenFromGhConfig},
+		{a.defaultConfigFile, a.tokenFromConfig},
+	} {
+		token, terr := source.fn()
+		if terr != nil {
+			failures = append(failures, source.name+": "+terr.Error())
+			continue
+		}
+		if token == "" {
+			continue
+		}
+		a.oauthToken = token
+		if terr = a.login(); terr != nil {
+			failures = append(failures, source.name+": "+terr.Error())
+			continue
+		}
+		return a.client, nil
+	}
+	msg := "no GitHub token for " + a.host + " is found, set $GITHUB_TOKEN or github_token_command"
+	if len(failures) > 0 {
+		msg += " (" + strings.Join(failures, ", ") + ")"
+	}
+	return nil, errors.New(msg)
+}
 
-	if err = a.loadConfig(); err != nil {
+// HasDeviceFlow returns whether the device flow is available
+func (a *AuthService) HasDeviceFlow() bool {
+	return a.clientID != ""
+}
+
+// AuthorizeDevice runs the OAuth device flow, show is called with the page to open and the code to enter.
+// it returns the client after the user authorized mcc and the token is stored in ~/.config/mcc
+func (a *AuthService) AuthorizeDevice(show func(verificationURI string, userCode string)) (client *go_github.Client, err error) {
+	code, err := a.requestDeviceCode()
+	if err != nil {
 		return
 	}
-
-	if a.oauthToken == "" {
-		if err = a.authorizeClient(a.host); err != nil {
-			return
-		}
+	show(code.VerificationURI, code.UserCode)
+	token, err := a.pollAccessToken(code)
+	if err != nil {
+		return
 	}
-
+	a.oauthToken = token
 	if err = a.login(); err != nil {
-		if err = a.authorizeClient(a.host); err != nil {
-			return
-		}
+		return
 	}
-	client = a.client
-
-	return
+	if err = a.saveConfig(); err != nil {
+		return
+	}
+	return a.client, nil
 }
 
+// login makes sure the token is available and sets a.client
 func (a *AuthService) login() (err error) {
-	ctx := context.Background()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, a.httpClient)
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: a.oauthToken},
 	)
-	tc := oauth2.NewClient(ctx, ts)
-	a.client = go_github.NewClient(tc)
-
-	// make sure a.client(token) is available
-	opt := &go_github.RepositoryListByOrgOptions{Type: "public"}
-	_, _, err = a.client.Repositories.ListByOrg(ctx, "github", opt)
-
+	a.client, err = newAPIClient(oauth2.NewClient(ctx, ts), a.apiBaseURL, a.uploadURL)
+	if err != nil {
+		return
+	}
+	user, _, err := a.client.Users.Get(ctx, "")
+	if err != nil {
+		return
+	}
+	a.user = user.GetLogin()
 	return
 }
 
-func (a *AuthService) authorizeClient(host string) (err error) {
-	termui.Close()
-	ui.Println("-----------------------------------------------------------------------")
-	ui.Println("GitHub widget is configured in the yaml")
-	ui.Println("Please authenticate your GitHub account first (generating access token)")
-	ui.Println("-----------------------------------------------------------------------")
-
-	user := a.promptForUser(host)
-	pass := a.promptForPassword(host, user)
-
-	var code, token string
-	for {
-		token, err = a.createToken(user, pass, code)
-		if err == nil {
-			break
+func (a *AuthService) tokenFromEnv() (token string, err error) {
+	names := []string{"GITHUB_TOKEN", "GH_TOKEN"}
+	if a.host != "github.com" && a.host != "api.github.com" {
+		names = []string{"GITHUB_ENTERPRISE_TOKEN", "GH_ENTERPRISE_TOKEN"}
+	}
+	for _, n := range names {
+		if token = a.getenv(n); token != "" {
+			return
 		}
-		if _, ok := err.(*go_github.TwoFactorAuthError); ok {
-			if code != "" {
-				ui.Errorln("warning: invalid two-factor code")
-			}
-			code = a.promptForOTP()
-		} else {
-			break
+	}
+	return
+}
+
+func (a *AuthService) tokenFromCommand() (token string, err error) {
+	if a.tokenCommand == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+	cmd := utils.NewShellCommand(a.tokenCommand, nil)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = utils.RunKillable(ctx, cmd); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			err = errors.New(err.Error() + ": " + s)
 		}
+		return
 	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (a *AuthService) tokenFromGhConfig() (token string, err error) {
+	path := filepath.Join(a.ghConfigDir, "hosts.yml")
+	if !a.fileExists(path) {
+		return
+	}
+	d, err := ioutil.ReadFile(path)
 	if err != nil {
 		return
 	}
-	if token == "" {
-		ui.Println("-----------------------------------------------------------------------")
-		ui.Errorln("error: invalid username or password")
-		os.Exit(1)
-	} else {
-		a.saveConfig()
-		ui.Println("-----------------------------------------------------------------------")
-		ui.Println("Authentication succeeded! (access token has been stored ~/.config/mcc)")
-		ui.Println("Please restart mcc again")
-		os.Exit(0)
+	hosts := make(map[string]ghHost)
+	if err = yaml.Unmarshal(d, &hosts); err != nil {
+		return
 	}
+	// recent gh stores the token in the keyring, "gh auth token" is available for github_token_command then
+	return hosts[a.host].OAuthToken, nil
+}
 
-	return
+func (a *AuthService) tokenFromConfig() (token string, err error) {
+	a.oauthToken = ""
+	if err = a.loadConfig(); err != nil {
+		return
+	}
+	return a.oauthToken, nil
 }
 
-func (a *AuthService) createToken(user, password, twoFactorCode string) (token string, err error) {
-	tp := go_github.BasicAuthTransport{
-		Username: user,
-		Password: password,
-		OTP:      twoFactorCode,
+func (a *AuthService) requestDeviceCode() (code *deviceCode, err error) {
+	code = new(deviceCode)
+	err = a.postForm("login/device/code", url.Values{
+		"client_id": {a.clientID},
+		"scope":     {"repo"},
+	}, code)
+	if err != nil {
+		return
+	}
+	if code.DeviceCode == "" {
+		return nil, errors.New("the device flow is not available on " + a.host)
 	}
-	a.client = go_github.NewClient(tp.Client())
+	return
+}
 
-	cnt := 1
-	var result *go_github.Authorization
-	for {
-		desc, err := a.authTokenNote(cnt)
+// pollAccessToken waits for the user to enter the code
+func (a *AuthService) pollAccessToken(code *deviceCode) (token string, err error) {
+	interval := time.Duration(code.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+		res := new(accessToken)
+		err = a.postForm("login/oauth/access_token", url.Values{
+			"client_id":   {a.clientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {deviceGrantType},
+		}, res)
 		if err != nil {
-			break
-		}
-		input := &go_github.AuthorizationRequest{
-			Note:   go_github.String(desc),
-			Scopes: []go_github.Scope{go_github.ScopeRepo},
-		}
-		result, _, err = a.client.Authorizations.Create(context.Background(), input)
-
-		if err == nil {
-			token = result.GetToken()
-			a.oauthToken = token
-			a.user = result.User.GetName()
-			break
+			return
 		}
-		if cnt >= 9 {
-			break
-		} else {
-			cnt++
+		switch res.Error {
+		case "":
+			return res.AccessToken, nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval = time.Duration(res.Interval) * time.Second
 			continue
+		default:
+			return "", errors.New(res.Error + ": " + res.ErrorDescription)
 		}
 	}
-
-	return
+	return "", errors.New("the code has expired")
 }
 
-func (a *AuthService) authTokenNote(num int) (string, error) {
-	n := os.Getenv("USER")
-	if n == "" {
-		n = os.Getenv("USERNAME")
-	}
-	if n == "" {
-		whoami := exec.Command("whoami")
-		whoamiOut, err := whoami.Output()
-		if err != nil {
-			return "", err
-		}
-		n = strings.TrimSpace(string(whoamiOut))
+func (a *AuthService) postForm(path string, values url.Values, v interface{}) (err error) {
+	req, err := http.NewRequest("POST", a.webBaseURL+path, strings.NewReader(values.Encode()))
+	if err != nil {
+		return
 	}
-	h, err := os.Hostname()
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	res, err := a.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return
 	}
-
-	if num > 1 {
-		return fmt.Sprintf("hub for %s@%s %d", n, h, num), nil
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.New("POST " + a.webBaseURL + path + " returned " + res.Status)
 	}
-
-	return fmt.Sprintf("mcc for %s@%s", n, h), nil
+	return json.NewDecoder(res.Body).Decode(v)
 }
 
 func (a *AuthService) saveConfig() (err error) {
@@ -259,6 +376,9 @@ func (a *AuthService) loadConfig() (err error) {
 	if err != nil {
 		return
 	}
+	if len(yc[a.host]) == 0 {
+		return
+	}
 
 	a.user = yc[a.host][0].User
 	a.oauthToken = yc[a.host][0].OAuthToken
@@ -266,79 +386,3 @@ func (a *AuthService) loadConfig() (err error) {
 
 	return
 }
-
-func (a *AuthService) promptForUser(host string) (user string) {
-	user = os.Getenv("GITHUB_USER")
-	if user != "" {
-		return
-	}
-
-	ui.Printf("%s username: ", host)
-	user = a.scanLine()
-
-	return
-}
-
-func (a *AuthService) promptForPassword(host, user string) (pass string) {
-	pass = os.Getenv("GITHUB_PASSWORD")
-	if pass != "" {
-		return
-	}
-
-	ui.Printf("%s password for %s (never stored): ", host, user)
-	if ui.IsTerminal(os.Stdin) {
-		if password, err := getPassword(); err == nil {
-			pass = password
-		}
-	} else {
-		pass = a.scanLine()
-	}
-
-	return
-}
-
-func (a *AuthService) promptForOTP() string {
-	fmt.Print("two-factor authentication code: ")
-	return a.scanLine()
-}
-
-func (a *AuthService) scanLine() string {
-	var line string
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		line = scanner.Text()
-	}
-	utils.Check(scanner.Err())
-	return line
-}
-
-func getPassword() (string, error) {
-	stdin := int(syscall.Stdin)
-	initialTermState, err := terminal.GetState(stdin)
-	if err != nil {
-		return "", err
-	}
-
-	c := make(chan os.Signal)
-	signal.Notify(c, os.Interrupt, os.Kill)
-	go func() {
-		s := <-c
-		terminal.Restore(stdin, initialTermState)
-		switch sig := s.(type) {
-		case syscall.Signal:
-			if int(sig) == 2 {
-				fmt.Println("^C")
-			}
-		}
-		os.Exit(1)
-	}()
-
-	passBytes, err := terminal.ReadPassword(stdin)
-	if err != nil {
-		return "", err
-	}
-
-	signal.Stop(c)
-	fmt.Print("\n")
-	return string(passBytes), nil
-}
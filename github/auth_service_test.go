@@ -0,0 +1,164 @@
+package github
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestAuthService returns an AuthService which talks to a stand-in of the GitHub API
+// accepting only "valid-token", and reads the configs in a temporary directory
+func newTestAuthService(t *testing.T, opt *AuthServiceOption, env map[string]string) (a *AuthService, srv *httptest.Server, dir string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer valid-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		w.Write([]byte(`{"login":"octocat"}`))
+	})
+	pending := 1
+	mux.HandleFunc("/login/device/code", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_id") != "client-id" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(&deviceCode{
+			DeviceCode:      "device-code",
+			UserCode:        "ABCD-1234",
+			VerificationURI: "https://github.com/login/device",
+			ExpiresIn:       5,
+			Interval:        0,
+		})
+	})
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("device_code") != "device-code" || r.Form.Get("grant_type") != deviceGrantType {
+			json.NewEncoder(w).Encode(&accessToken{Error: "incorrect_device_code"})
+			return
+		}
+		if pending > 0 {
+			pending--
+			json.NewEncoder(w).Encode(&accessToken{Error: "authorization_pending"})
+			return
+		}
+		json.NewEncoder(w).Encode(&accessToken{AccessToken: "valid-token"})
+	})
+	srv = httptest.NewServer(mux)
+
+	dir, err := ioutil.TempDir("", "mcc-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err = NewAuthService(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.apiBaseURL = srv.URL + "/"
+	a.webBaseURL = srv.URL + "/"
+	a.ghConfigDir = filepath.Join(dir, "gh")
+	a.defaultConfigFile = filepath.Join(dir, "mcc")
+	a.getenv = func(key string) string { return env[key] }
+	return
+}
+
+func TestInitClientWithEnv(t *testing.T) {
+	a, srv, dir := newTestAuthService(t, &AuthServiceOption{Host: "github.com"}, map[string]string{"GH_TOKEN": "valid-token"})
+	defer srv.Close()
+	defer os.RemoveAll(dir)
+	if _, err := a.InitClient(); err != nil {
+		t.Fatalf("GH_TOKEN should be used: %v", err)
+	}
+	if a.user != "octocat" {
+		t.Fatalf("the login should be octocat but %q", a.user)
+	}
+
+	// the enterprise host doesn't use the token of github.com
+	a, srv, dir = newTestAuthService(t, &AuthServiceOption{Host: "ghe.example.com"}, map[string]string{"GITHUB_TOKEN": "valid-token"})
+	defer srv.Close()
+	defer os.RemoveAll(dir)
+	if _, err := a.InitClient(); err == nil {
+		t.Fatal("GITHUB_TOKEN should not be used for ghe.example.com")
+	}
+}
+
+func TestInitClientWithTokenCommand(t *testing.T) {
+	// an invalid token in the env falls through to github_token_command
+	a, srv, dir := newTestAuthService(t, &AuthServiceOption{
+		Host:         "github.com",
+		TokenCommand: "echo valid-token",
+	}, map[string]string{"GITHUB_TOKEN": "revoked-token"})
+	defer srv.Close()
+	defer os.RemoveAll(dir)
+	if _, err := a.InitClient(); err != nil {
+		t.Fatalf("github_token_command should be used: %v", err)
+	}
+
+	a, srv, dir = newTestAuthService(t, &AuthServiceOption{
+		Host:         "github.com",
+		TokenCommand: "echo not logged in >&2; exit 1",
+	}, nil)
+	defer srv.Close()
+	defer os.RemoveAll(dir)
+	_, err := a.InitClient()
+	if err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Fatalf("the failure of github_token_command should be reported but %v", err)
+	}
+}
+
+func TestInitClientWithGhConfig(t *testing.T) {
+	a, srv, dir := newTestAuthService(t, &AuthServiceOption{Host: "github.com"}, nil)
+	defer srv.Close()
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(a.ghConfigDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	hosts := "github.com:\n    user: octocat\n    oauth_token: valid-token\n    git_protocol: https\n"
+	if err := ioutil.WriteFile(filepath.Join(a.ghConfigDir, "hosts.yml"), []byte(hosts), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.InitClient(); err != nil {
+		t.Fatalf("hosts.yml of gh should be used: %v", err)
+	}
+}
+
+func TestInitClientWithoutToken(t *testing.T) {
+	a, srv, dir := newTestAuthService(t, &AuthServiceOption{Host: "github.com"}, nil)
+	defer srv.Close()
+	defer os.RemoveAll(dir)
+	if _, err := a.InitClient(); err == nil {
+		t.Fatal("InitClient should fail without any token")
+	}
+	if a.HasDeviceFlow() {
+		t.Fatal("the device flow should not be available without github_client_id")
+	}
+}
+
+func TestDeviceFlow(t *testing.T) {
+	a, srv, dir := newTestAuthService(t, &AuthServiceOption{Host: "github.com", ClientID: "client-id"}, nil)
+	defer srv.Close()
+	defer os.RemoveAll(dir)
+	shown := ""
+	client, err := a.AuthorizeDevice(func(verificationURI string, userCode string) {
+		shown = userCode
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shown != "ABCD-1234" || client == nil || a.oauthToken != "valid-token" {
+		t.Fatalf("the code ABCD-1234 should be shown and valid-token should be used but %q, %q", shown, a.oauthToken)
+	}
+
+	// the stored token is used next time
+	a.oauthToken = ""
+	if _, err = a.InitClient(); err != nil {
+		t.Fatalf("the token stored in %s should be used: %v", a.defaultConfigFile, err)
+	}
+}
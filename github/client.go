@@ -2,11 +2,13 @@ package github
 
 import (
 	"context"
+	"errors"
 	"regexp"
 	"strconv"
+	"sync"
 
 	go_github "github.com/google/go-github/github"
-	"github.com/qmu/mcc/utils"
+	mccgit "github.com/qmu/mcc/git"
 	"gopkg.in/src-d/go-git.v4"
 	// "github.com/k0kubun/pp"
 )
@@ -19,80 +21,161 @@ type Client struct {
 	repoOwner  string
 	repoName   string
 	branch     string
-	issueID    int
+	repo       *git.Repository
 	auth       *AuthService
+	options    *ClientOption
+	login      string // the authenticated user
+	loginMutex sync.Mutex
+	rate       go_github.Rate
+	rateMutex  sync.Mutex
+	branchMu   sync.Mutex
+}
+
+// ClientOption is the option argument for NewClient
+type ClientOption struct {
+	ExecPath     string
+	Host         string
+	TokenCommand string
+	ClientID     string
+	CABundle     string
+	Remote       string // the name of the git remote on GitHub, "origin" by default
 }
 
 // NewClient constructs a new Client
-func NewClient(execPath string, host string) (g *Client, err error) {
+func NewClient(opt *ClientOption) (g *Client, err error) {
 	g = new(Client)
-	g.dotGitPath, err = utils.GetDotGitPath(execPath)
-	g.host = host
+	g.options = opt
+	g.host = opt.Host
+	r, paths, err := mccgit.PlainOpen(opt.ExecPath)
 	if err != nil {
 		return
 	}
+	g.dotGitPath = paths.WorkTree
+	g.repo = r
+	// get branch info
+	if _, err = g.UpdateBranch(); err != nil {
+		return
+	}
+	// get github info
+	name := opt.Remote
+	if name == "" {
+		name = "origin"
+	}
+	remote, err := r.Remote(name)
+	if err != nil {
+		return nil, errors.New("git remote \"" + name + "\" is not found: " + err.Error())
+	}
+	if len(remote.Config().URLs) == 0 {
+		return nil, errors.New("git remote \"" + name + "\" has no URL")
+	}
+	rem, err := ParseRemoteURL(remote.Config().URLs[0])
+	if err != nil {
+		return
+	}
+	g.repoOwner = rem.Owner
+	g.repoName = rem.Name
+	return
+}
 
-	r, err := git.PlainOpen(g.dotGitPath)
+// Init initialize Client
+func (g *Client) Init() (err error) {
+	g.auth, err = NewAuthService(&AuthServiceOption{
+		Host:         g.host,
+		TokenCommand: g.options.TokenCommand,
+		ClientID:     g.options.ClientID,
+		CABundle:     g.options.CABundle,
+	})
 	if err != nil {
 		return
 	}
-	// get branch info
-	ref, err := r.Head()
+	client, authErr := g.auth.InitClient()
+	if authErr == nil {
+		g.client = client
+		return
+	}
+	// no token is available, a public repository can be read anonymously
+	g.client, err = newAPIClient(g.auth.httpClient, g.auth.apiBaseURL, g.auth.uploadURL)
 	if err != nil {
 		return
 	}
-	g.branch = ref.Name().Short()
-	// get github info
-	remotes, err := r.Remotes()
+	if _, _, perr := g.client.Repositories.Get(context.Background(), g.repoOwner, g.repoName); perr == nil {
+		return nil
+	}
+	return authErr
+}
+
+// HasDeviceFlow returns whether AuthorizeDevice is available after Init failed for no token
+func (g *Client) HasDeviceFlow() bool {
+	return g.auth != nil && g.auth.HasDeviceFlow()
+}
+
+// AuthorizeDevice authenticates the client by the OAuth device flow, show is called with the page to open
+// and the code to enter. it blocks until the user authorizes mcc or the code expires
+func (g *Client) AuthorizeDevice(show func(verificationURI string, userCode string)) (err error) {
+	client, err := g.auth.AuthorizeDevice(show)
 	if err != nil {
 		return
 	}
-	for _, remote := range remotes {
-		if remote.Config().Name == "origin" {
-			u := remote.Config().URLs[0]
-			rep1 := regexp.MustCompile(`.*:(.*)/.*`)
-			g.repoOwner = rep1.ReplaceAllString(u, "$1")
-			rep2 := regexp.MustCompile(`.*/(.*)\.git`)
-			g.repoName = rep2.ReplaceAllString(u, "$1")
-			break
-		}
+	g.client = client
+	return
+}
+
+// GetBranch returns the current branch name
+func (g *Client) GetBranch() string {
+	g.branchMu.Lock()
+	defer g.branchMu.Unlock()
+	return g.branch
+}
+
+// UpdateBranch reads the current branch again, changed is true if it has been switched
+func (g *Client) UpdateBranch() (changed bool, err error) {
+	ref, err := g.repo.Head()
+	if err != nil {
+		return
 	}
+	g.branchMu.Lock()
+	defer g.branchMu.Unlock()
+	changed = g.branch != ref.Name().Short()
+	g.branch = ref.Name().Short()
 	return
 }
 
-// Init initialize Client
-func (g *Client) Init() (err error) {
-	// check if it's public repository
-	ctx := context.Background()
-	g.client = go_github.NewClient(nil)
-	_, _, err = g.client.Repositories.Get(ctx, g.repoOwner, g.repoName)
-	// if it's private, authenticate first
+// IssueNumber extracts the issue number from the current branch name by the first group of issueNoRegex,
+// it returns an error if the branch name has no issue number
+func (g *Client) IssueNumber(issueNoRegex string) (n int, err error) {
+	return issueNumber(g.GetBranch(), issueNoRegex)
+}
+
+func issueNumber(branch string, issueNoRegex string) (n int, err error) {
+	rep0, err := regexp.Compile(issueNoRegex)
 	if err != nil {
-		g.auth, err = NewAuthService(g.host)
-		if err != nil {
+		return
+	}
+	for _, m := range rep0.FindAllStringSubmatch(branch, -1) {
+		if len(m) < 2 || m[1] == "" {
+			continue
+		}
+		if n, err = strconv.Atoi(m[1]); err == nil {
 			return
 		}
-		g.client, err = g.auth.InitClient()
 	}
-	return
+	return 0, errors.New("the branch " + branch + " has no issue number")
 }
 
 // GetIssue requests an issue and comments by refering current branch name which includes issueID
 func (g *Client) GetIssue(issueNoRegex string) (issue *go_github.Issue, comments []*go_github.IssueComment, err error) {
-	rep0 := regexp.MustCompile(issueNoRegex)
-	g.branch = rep0.ReplaceAllString(g.branch, "$1")
-	g.issueID, err = strconv.Atoi(g.branch)
+	issueID, err := g.IssueNumber(issueNoRegex)
 	if err != nil {
 		return
 	}
 	// get a issue
 	ctx := context.Background()
-	issue, _, err = g.client.Issues.Get(ctx, g.repoOwner, g.repoName, g.issueID)
+	issue, _, err = g.client.Issues.Get(ctx, g.repoOwner, g.repoName, issueID)
 	if err != nil {
 		return
 	}
 	opt := new(go_github.IssueListCommentsOptions)
-	comments, _, err = g.client.Issues.ListComments(ctx, g.repoOwner, g.repoName, g.issueID, opt)
+	comments, _, err = g.client.Issues.ListComments(ctx, g.repoOwner, g.repoName, issueID, opt)
 
 	return
 }
@@ -0,0 +1,26 @@
+package github
+
+import "testing"
+
+func TestIssueNumber(t *testing.T) {
+	cases := []struct {
+		branch string
+		regex  string
+		n      int
+	}{
+		{"i12-add-widget", "i([0-9]*).*", 12},
+		{"i7", "i([0-9]*).*", 7},
+		{"feature/#34", "#([0-9]+)", 34},
+	}
+	for _, c := range cases {
+		n, err := issueNumber(c.branch, c.regex)
+		if err != nil || n != c.n {
+			t.Fatalf("%s should have #%d but %d, %v", c.branch, c.n, n, err)
+		}
+	}
+	for _, branch := range []string{"master", "fix-login"} {
+		if _, err := issueNumber(branch, "i([0-9]*).*"); err == nil {
+			t.Fatalf("%s should have no issue number", branch)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package github
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	go_github "github.com/google/go-github/github"
+)
+
+// endpoint resolves the URLs of github.com or a GitHub Enterprise host from github_url
+type endpoint struct {
+	scheme string
+	host   string
+}
+
+// newEndpoint accepts "github.example.com" or "https://github.example.com/"
+func newEndpoint(githubURL string) (e *endpoint, err error) {
+	e = &endpoint{scheme: "https", host: "github.com"}
+	githubURL = strings.TrimSpace(githubURL)
+	if githubURL == "" {
+		return
+	}
+	if !strings.Contains(githubURL, "://") {
+		githubURL = "https://" + githubURL
+	}
+	u, err := url.Parse(githubURL)
+	if err != nil {
+		return
+	}
+	if u.Host == "" {
+		return nil, errors.New("github_url " + githubURL + " has no host")
+	}
+	e.scheme = u.Scheme
+	e.host = u.Host
+	return
+}
+
+func (e *endpoint) isEnterprise() bool {
+	return e.host != "github.com" && e.host != "api.github.com"
+}
+
+// webBaseURL is the base of the web pages including the OAuth endpoints
+func (e *endpoint) webBaseURL() string {
+	return e.scheme + "://" + e.host + "/"
+}
+
+// apiBaseURL is the base of REST API v3
+func (e *endpoint) apiBaseURL() string {
+	if e.isEnterprise() {
+		return e.scheme + "://" + e.host + "/api/v3/"
+	}
+	return "https://api.github.com/"
+}
+
+// uploadURL is the base of the upload API
+func (e *endpoint) uploadURL() string {
+	if e.isEnterprise() {
+		return e.scheme + "://" + e.host + "/api/uploads/"
+	}
+	return "https://uploads.github.com/"
+}
+
+// newHTTPClient returns a http.Client which trusts the certificates of caBundle
+// in addition to the system ones
+func newHTTPClient(caBundle string) (c *http.Client, err error) {
+	if caBundle == "" {
+		return http.DefaultClient, nil
+	}
+	pem, err := ioutil.ReadFile(caBundle)
+	if err != nil {
+		return
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("github_ca_bundle " + caBundle + " has no PEM certificate")
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}, nil
+}
+
+// newAPIClient constructs a go-github client pointing at the API of the endpoint
+func newAPIClient(httpClient *http.Client, apiBaseURL string, uploadURL string) (c *go_github.Client, err error) {
+	c = go_github.NewClient(httpClient)
+	if c.BaseURL, err = url.Parse(apiBaseURL); err != nil {
+		return
+	}
+	c.UploadURL, err = url.Parse(uploadURL)
+	return
+}
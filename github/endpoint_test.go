@@ -0,0 +1,69 @@
+package github
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestEndpoint(t *testing.T) {
+	cases := []struct {
+		githubURL string
+		host      string
+		api       string
+		upload    string
+		web       string
+	}{
+		{"", "github.com", "https://api.github.com/", "https://uploads.github.com/", "https://github.com/"},
+		{"github.com", "github.com", "https://api.github.com/", "https://uploads.github.com/", "https://github.com/"},
+		{"ghe.example.com", "ghe.example.com", "https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/", "https://ghe.example.com/"},
+		{"https://ghe.example.com/", "ghe.example.com", "https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/", "https://ghe.example.com/"},
+		{"http://ghe.local:8080", "ghe.local:8080", "http://ghe.local:8080/api/v3/", "http://ghe.local:8080/api/uploads/", "http://ghe.local:8080/"},
+	}
+	for _, c := range cases {
+		e, err := newEndpoint(c.githubURL)
+		if err != nil {
+			t.Fatalf("%q: %v", c.githubURL, err)
+		}
+		if e.host != c.host || e.apiBaseURL() != c.api || e.uploadURL() != c.upload || e.webBaseURL() != c.web {
+			t.Fatalf("%q should be resolved to %s, %s, %s, %s but %s, %s, %s, %s", c.githubURL,
+				c.host, c.api, c.upload, c.web, e.host, e.apiBaseURL(), e.uploadURL(), e.webBaseURL())
+		}
+	}
+}
+
+func TestNewHTTPClientWithCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	f, err := ioutil.TempFile("", "mcc-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	f.Close()
+
+	// the self-signed certificate is not trusted by default
+	if _, err = http.Get(srv.URL); err == nil {
+		t.Fatal("the certificate of the test server should not be trusted without the bundle")
+	}
+	c, err := newHTTPClient(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("the certificate in the bundle should be trusted: %v", err)
+	}
+	res.Body.Close()
+
+	if _, err = newHTTPClient(os.Args[0]); err == nil {
+		t.Fatal("a file without certificates should be an error")
+	}
+}
@@ -0,0 +1,423 @@
+package github
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	go_github "github.com/google/go-github/github"
+)
+
+const (
+	// the max of per_page, the pages are requested until the last one
+	pullRequestsPerPage = 100
+	// combined status, check runs and reviews are requested for each pull request
+	requestsPerPullRequest = 3
+	// the pull requests whose details are requested at the same time,
+	// GitHub's secondary rate limit rejects too many concurrent requests
+	pullRequestDetailWorkers = 4
+)
+
+// PullRequestFilter narrows down the open pull requests,
+// "@me" is replaced with the authenticated user
+type PullRequestFilter struct {
+	Author          string
+	ReviewRequested string `mapstructure:"review_requested"`
+	Label           string
+}
+
+// PullRequest is an open pull request with its CI and review status
+type PullRequest struct {
+	Number         int
+	Title          string
+	Author         string
+	URL            string
+	Body           string
+	Head           string
+	Base           string
+	Draft          bool
+	Labels         []string
+	Reviewers      []string // requested reviewers
+	UpdatedAt      time.Time
+	CheckState     string // "success", "failure", "pending" or "" if there is no check
+	Checks         []*Check
+	ReviewDecision string // "approved", "changes_requested", "review_required" or ""
+	Reviews        []*Review
+	Detailed       bool // false if the checks and reviews were skipped for the rate limit
+}
+
+// Check is a commit status or a check run of the head commit
+type Check struct {
+	Name  string
+	State string // "success", "failure", "pending" or "neutral"
+}
+
+// Review is the latest review of a reviewer
+type Review struct {
+	User  string
+	State string // "APPROVED", "CHANGES_REQUESTED", "COMMENTED" or "DISMISSED"
+}
+
+// ReviewComment is a review with a body or a comment on the diff of a pull request
+type ReviewComment struct {
+	User      string
+	State     string // the state of a review, empty for a comment on the diff
+	Path      string
+	Position  int
+	Body      string
+	CreatedAt time.Time
+}
+
+// pullRequest adds the fields which go-github doesn't have yet
+type pullRequest struct {
+	go_github.PullRequest
+	Draft              bool               `json:"draft"`
+	Labels             []*go_github.Label `json:"labels"`
+	RequestedReviewers []*go_github.User  `json:"requested_reviewers"`
+}
+
+type checkRuns struct {
+	CheckRuns []struct {
+		Name       string `json:"name"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+	} `json:"check_runs"`
+}
+
+// ListPullRequests returns the open pull requests matching the filter,
+// the checks and reviews are not requested while the rate limit is running out
+func (g *Client) ListPullRequests(filter *PullRequestFilter) (prs []*PullRequest, err error) {
+	ctx := context.Background()
+	raw, err := g.listOpenPullRequests(ctx, "")
+	if err != nil {
+		return
+	}
+	if filter == nil {
+		filter = new(PullRequestFilter)
+	}
+	author, err := g.resolveMe(ctx, filter.Author)
+	if err != nil {
+		return
+	}
+	reviewer, err := g.resolveMe(ctx, filter.ReviewRequested)
+	if err != nil {
+		return
+	}
+
+	shas := map[int]string{}
+	for _, r := range raw {
+		shas[r.GetNumber()] = r.Head.GetSHA()
+		pr := r.convert()
+		if author != "" && pr.Author != author {
+			continue
+		}
+		if reviewer != "" && !contains(pr.Reviewers, reviewer) {
+			continue
+		}
+		if filter.Label != "" && !contains(pr.Labels, filter.Label) {
+			continue
+		}
+		prs = append(prs, pr)
+	}
+
+	if remaining, _ := g.RateLimit(); remaining >= 0 && remaining < len(prs)*requestsPerPullRequest {
+		return prs, nil
+	}
+	// the details are requested by a few workers at a time, each request fills its own copy
+	wg := new(sync.WaitGroup)
+	details := make([]*PullRequest, len(prs))
+	errs := make([]error, len(prs))
+	slots := make(chan struct{}, pullRequestDetailWorkers)
+	for i, pr := range prs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, pr PullRequest, sha string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			_, errs[i] = g.fillPullRequest(ctx, &pr, sha)
+			details[i] = &pr
+		}(i, *pr, shas[pr.Number])
+	}
+	wg.Wait()
+	copy(prs, details)
+	for _, e := range errs {
+		if e != nil {
+			return prs, e
+		}
+	}
+	return
+}
+
+// GetBranchPullRequest returns the open pull request whose head is the current branch
+// with its review comments, pr is nil if there is no such pull request
+func (g *Client) GetBranchPullRequest() (pr *PullRequest, comments []*ReviewComment, err error) {
+	ctx := context.Background()
+	// only the branch of the repository itself, not the same name in a fork
+	raw, err := g.listOpenPullRequests(ctx, g.repoOwner+":"+g.GetBranch())
+	if err != nil || len(raw) == 0 {
+		return
+	}
+	found := raw[0]
+	pr = found.convert()
+	reviews, err := g.fillPullRequest(ctx, pr, found.Head.GetSHA())
+	if err != nil {
+		return
+	}
+
+	// the bodies of the reviews and the comments on the diff
+	for _, r := range reviews {
+		if r.GetBody() == "" {
+			continue
+		}
+		comments = append(comments, &ReviewComment{
+			User:      r.User.GetLogin(),
+			State:     r.GetState(),
+			Body:      r.GetBody(),
+			CreatedAt: r.GetSubmittedAt(),
+		})
+	}
+	diffComments, res, err := g.client.PullRequests.ListComments(ctx, g.repoOwner, g.repoName, pr.Number, nil)
+	if err != nil {
+		return
+	}
+	g.setRate(res)
+	for _, c := range diffComments {
+		comments = append(comments, &ReviewComment{
+			User:      c.User.GetLogin(),
+			Path:      c.GetPath(),
+			Position:  c.GetPosition(),
+			Body:      c.GetBody(),
+			CreatedAt: c.GetCreatedAt(),
+		})
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return
+}
+
+// listOpenPullRequests requests all the open pull requests as they are, page by page,
+// head narrows them down to a branch in the form of "owner:branch"
+func (g *Client) listOpenPullRequests(ctx context.Context, head string) (raw []*pullRequest, err error) {
+	query := "state=open&per_page=" + strconv.Itoa(pullRequestsPerPage)
+	if head != "" {
+		query += "&head=" + url.QueryEscape(head)
+	}
+	for page := 1; page != 0; {
+		req, err := g.client.NewRequest("GET", "repos/"+g.repoOwner+"/"+g.repoName+"/pulls?"+query+"&page="+strconv.Itoa(page), nil)
+		if err != nil {
+			return nil, err
+		}
+		var prs []*pullRequest
+		res, err := g.client.Do(ctx, req, &prs)
+		if err != nil {
+			return nil, err
+		}
+		g.setRate(res)
+		raw = append(raw, prs...)
+		page = res.NextPage
+	}
+	return
+}
+
+// convert makes a PullRequest without the checks and reviews
+func (r *pullRequest) convert() (pr *PullRequest) {
+	pr = &PullRequest{
+		Number:    r.GetNumber(),
+		Title:     r.GetTitle(),
+		Author:    r.User.GetLogin(),
+		URL:       r.GetHTMLURL(),
+		Body:      r.GetBody(),
+		Head:      r.Head.GetRef(),
+		Base:      r.Base.GetRef(),
+		Draft:     r.Draft,
+		UpdatedAt: r.GetUpdatedAt(),
+	}
+	for _, l := range r.Labels {
+		pr.Labels = append(pr.Labels, l.GetName())
+	}
+	for _, u := range r.RequestedReviewers {
+		pr.Reviewers = append(pr.Reviewers, u.GetLogin())
+	}
+	return
+}
+
+// fillPullRequest requests the checks and reviews of a pull request, it also returns the reviews as they are
+func (g *Client) fillPullRequest(ctx context.Context, pr *PullRequest, sha string) (reviews []*go_github.PullRequestReview, err error) {
+	// commit statuses
+	status, res, err := g.client.Repositories.GetCombinedStatus(ctx, g.repoOwner, g.repoName, sha, nil)
+	if err != nil {
+		return
+	}
+	g.setRate(res)
+	for _, s := range status.Statuses {
+		pr.Checks = append(pr.Checks, &Check{
+			Name:  s.GetContext(),
+			State: checkState(s.GetState(), ""),
+		})
+	}
+	// check runs, GitHub Enterprise without the Checks API returns 404
+	req, err := g.client.NewRequest("GET", "repos/"+g.repoOwner+"/"+g.repoName+"/commits/"+sha+"/check-runs", nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/vnd.github.antiope-preview+json")
+	runs := new(checkRuns)
+	res, err = g.client.Do(ctx, req, runs)
+	if res != nil && res.StatusCode == 404 {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+	g.setRate(res)
+	for _, r := range runs.CheckRuns {
+		pr.Checks = append(pr.Checks, &Check{
+			Name:  r.Name,
+			State: checkState(r.Status, r.Conclusion),
+		})
+	}
+	pr.CheckState = combineCheckStates(pr.Checks)
+
+	// reviews
+	reviews, res, err = g.client.PullRequests.ListReviews(ctx, g.repoOwner, g.repoName, pr.Number, nil)
+	if err != nil {
+		return
+	}
+	g.setRate(res)
+	latest := map[string]*Review{}
+	for _, r := range reviews {
+		user := r.User.GetLogin()
+		// a comment doesn't override an approval or a change request
+		if r.GetState() == "COMMENTED" && latest[user] != nil {
+			continue
+		}
+		if latest[user] == nil {
+			pr.Reviews = append(pr.Reviews, &Review{User: user})
+			latest[user] = pr.Reviews[len(pr.Reviews)-1]
+		}
+		latest[user].State = r.GetState()
+	}
+	pr.ReviewDecision = reviewDecision(pr.Reviews, pr.Reviewers)
+	pr.Detailed = true
+	return
+}
+
+// resolveMe replaces "@me" with the login of the authenticated user
+func (g *Client) resolveMe(ctx context.Context, login string) (string, error) {
+	if login != "@me" {
+		return login, nil
+	}
+	g.loginMutex.Lock()
+	defer g.loginMutex.Unlock()
+	if g.login == "" {
+		user, _, err := g.client.Users.Get(ctx, "")
+		if err != nil {
+			return "", err
+		}
+		g.login = user.GetLogin()
+	}
+	return g.login, nil
+}
+
+// checkState unifies the states of commit statuses and check runs
+func checkState(status string, conclusion string) string {
+	switch status {
+	case "success", "failure", "pending":
+		return status
+	case "error":
+		return "failure"
+	case "queued", "in_progress":
+		return "pending"
+	}
+	switch conclusion {
+	case "success":
+		return "success"
+	case "neutral", "skipped":
+		return "neutral"
+	}
+	return "failure"
+}
+
+func combineCheckStates(checks []*Check) (state string) {
+	for _, c := range checks {
+		switch c.State {
+		case "failure":
+			return "failure"
+		case "pending":
+			state = "pending"
+		case "success", "neutral":
+			if state == "" {
+				state = "success"
+			}
+		}
+	}
+	return
+}
+
+func reviewDecision(reviews []*Review, requested []string) string {
+	approved := false
+	for _, r := range reviews {
+		if r.State == "CHANGES_REQUESTED" {
+			return "changes_requested"
+		}
+		if r.State == "APPROVED" {
+			approved = true
+		}
+	}
+	if approved {
+		return "approved"
+	}
+	if len(requested) > 0 {
+		return "review_required"
+	}
+	return ""
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Client) setRate(res *go_github.Response) {
+	if res == nil || res.Rate.Limit == 0 {
+		return
+	}
+	g.rateMutex.Lock()
+	defer g.rateMutex.Unlock()
+	g.rate = res.Rate
+}
+
+// RateLimit returns the remaining requests and the reset time told by the last response,
+// the remaining is -1 if it's unknown
+func (g *Client) RateLimit() (remaining int, reset time.Time) {
+	g.rateMutex.Lock()
+	defer g.rateMutex.Unlock()
+	if g.rate.Limit == 0 {
+		return -1, time.Time{}
+	}
+	return g.rate.Remaining, g.rate.Reset.Time
+}
+
+// RetryAfter returns how long to wait before the next request if err is caused by the rate limit
+func RetryAfter(err error) (d time.Duration, limited bool) {
+	switch e := err.(type) {
+	case *go_github.RateLimitError:
+		return time.Until(e.Rate.Reset.Time), true
+	case *go_github.AbuseRateLimitError:
+		if e.RetryAfter != nil {
+			return *e.RetryAfter, true
+		}
+		return time.Minute, true
+	}
+	return 0, false
+}
@@ -0,0 +1,248 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestPullRequestServer returns a Client for a stand-in of the GitHub API,
+// the pulls endpoint responds the remaining rate limit and returns 403 if it's 0.
+// the pull requests are in 2 pages
+func newTestPullRequestServer(t *testing.T, remaining int) (g *Client, srv *httptest.Server) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"login":"alice"}`))
+	})
+	mux.HandleFunc("/repos/qmu/mcc/pulls", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		if remaining == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"API rate limit exceeded for alice."}`))
+			return
+		}
+		// only the pull request of qmu:feature is open for a branch
+		if head := r.URL.Query().Get("head"); head != "" {
+			if head != "qmu:feature" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"number":1,"title":"Add a widget","user":{"login":"alice"},"head":{"ref":"feature","sha":"sha1"},"base":{"ref":"master"},
+			 "labels":[{"name":"enhancement"}],"requested_reviewers":[{"login":"bob"}]}]`))
+			return
+		}
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`[{"number":3,"title":"Update docs","user":{"login":"dave"},"head":{"ref":"docs","sha":"sha3"},"base":{"ref":"master"}}]`))
+			return
+		}
+		w.Header().Set("Link", `<http://`+r.Host+`/repos/qmu/mcc/pulls?state=open&per_page=100&page=2>; rel="next"`)
+		w.Write([]byte(`[
+			{"number":1,"title":"Add a widget","user":{"login":"alice"},"head":{"ref":"feature","sha":"sha1"},"base":{"ref":"master"},
+			 "labels":[{"name":"enhancement"}],"requested_reviewers":[{"login":"bob"}]},
+			{"number":2,"title":"Fix a bug","user":{"login":"bob"},"head":{"ref":"fix","sha":"sha2"},"base":{"ref":"master"},
+			 "draft":true,"labels":[{"name":"bug"}],"requested_reviewers":[]}
+		]`))
+	})
+	mux.HandleFunc("/repos/qmu/mcc/commits/sha1/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"state":"success","statuses":[{"context":"ci/travis","state":"success"}]}`))
+	})
+	mux.HandleFunc("/repos/qmu/mcc/commits/sha1/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"check_runs":[{"name":"lint","status":"in_progress"}]}`))
+	})
+	mux.HandleFunc("/repos/qmu/mcc/commits/sha2/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"state":"failure","statuses":[{"context":"ci/travis","state":"error"}]}`))
+	})
+	// the Checks API is not available
+	mux.HandleFunc("/repos/qmu/mcc/commits/sha2/check-runs", http.NotFound)
+	mux.HandleFunc("/repos/qmu/mcc/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"user":{"login":"carol"},"state":"APPROVED","submitted_at":"2018-05-02T00:00:00Z"},
+			{"user":{"login":"carol"},"state":"COMMENTED","body":"LGTM","submitted_at":"2018-05-03T00:00:00Z"}]`))
+	})
+	mux.HandleFunc("/repos/qmu/mcc/pulls/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"user":{"login":"carol"},"path":"main.go","position":3,"body":"typo","created_at":"2018-05-01T00:00:00Z"}]`))
+	})
+	mux.HandleFunc("/repos/qmu/mcc/pulls/2/reviews", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"user":{"login":"alice"},"state":"APPROVED"},{"user":{"login":"carol"},"state":"CHANGES_REQUESTED"}]`))
+	})
+	mux.HandleFunc("/repos/qmu/mcc/commits/sha3/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"state":"pending","statuses":[]}`))
+	})
+	mux.HandleFunc("/repos/qmu/mcc/commits/sha3/check-runs", http.NotFound)
+	mux.HandleFunc("/repos/qmu/mcc/pulls/3/reviews", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	srv = httptest.NewServer(mux)
+
+	client, err := newAPIClient(http.DefaultClient, srv.URL+"/", srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = &Client{client: client, repoOwner: "qmu", repoName: "mcc"}
+	return
+}
+
+func TestListPullRequests(t *testing.T) {
+	g, srv := newTestPullRequestServer(t, 5000)
+	defer srv.Close()
+
+	prs, err := g.ListPullRequests(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 3 {
+		t.Fatalf("3 pull requests in the 2 pages should be listed but %d", len(prs))
+	}
+	if pr := prs[0]; pr.CheckState != "pending" || len(pr.Checks) != 2 || pr.ReviewDecision != "approved" || pr.Head != "feature" {
+		t.Fatalf("#1 should be pending and approved but %s, %d checks, %s", pr.CheckState, len(pr.Checks), pr.ReviewDecision)
+	}
+	if pr := prs[1]; pr.CheckState != "failure" || pr.ReviewDecision != "changes_requested" || !pr.Draft {
+		t.Fatalf("#2 should be a failed draft with changes requested but %s, %s, %v", pr.CheckState, pr.ReviewDecision, pr.Draft)
+	}
+
+	cases := []struct {
+		filter  *PullRequestFilter
+		numbers []int
+	}{
+		{&PullRequestFilter{Author: "@me"}, []int{1}},
+		{&PullRequestFilter{Author: "bob"}, []int{2}},
+		{&PullRequestFilter{Author: "dave"}, []int{3}},
+		{&PullRequestFilter{ReviewRequested: "bob"}, []int{1}},
+		{&PullRequestFilter{Label: "bug"}, []int{2}},
+		{&PullRequestFilter{Author: "alice", Label: "bug"}, []int{}},
+	}
+	for _, c := range cases {
+		prs, err = g.ListPullRequests(c.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		numbers := []int{}
+		for _, pr := range prs {
+			numbers = append(numbers, pr.Number)
+		}
+		if len(numbers) != len(c.numbers) || (len(numbers) > 0 && numbers[0] != c.numbers[0]) {
+			t.Fatalf("%+v should match %v but %v", c.filter, c.numbers, numbers)
+		}
+	}
+}
+
+func TestListPullRequestsConcurrently(t *testing.T) {
+	// the widgets of a repository share the client
+	g, srv := newTestPullRequestServer(t, 5000)
+	defer srv.Close()
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = g.ListPullRequests(&PullRequestFilter{Author: "@me"})
+		}(i)
+	}
+	wg.Wait()
+	if errs[0] != nil || errs[1] != nil || g.login != "alice" {
+		t.Fatalf("the login should be resolved but %s, %v", g.login, errs)
+	}
+}
+
+func TestListPullRequestsWorkers(t *testing.T) {
+	// many pull requests whose details take a while
+	var mutex sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/qmu/mcc/pulls" {
+			var prs []string
+			for i := 1; i <= 20; i++ {
+				n := strconv.Itoa(i)
+				prs = append(prs, `{"number":`+n+`,"head":{"sha":"sha`+n+`"}}`)
+			}
+			w.Write([]byte("[" + strings.Join(prs, ",") + "]"))
+			return
+		}
+		mutex.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/reviews") {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	client, err := newAPIClient(http.DefaultClient, srv.URL+"/", srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Client{client: client, repoOwner: "qmu", repoName: "mcc"}
+	prs, err := g.ListPullRequests(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 20 || !prs[19].Detailed || maxInFlight > pullRequestDetailWorkers {
+		t.Fatalf("the details of 20 pull requests should be requested by %d workers but %d at a time", pullRequestDetailWorkers, maxInFlight)
+	}
+}
+
+func TestListPullRequestsWithRateLimit(t *testing.T) {
+	// the checks and reviews are skipped while the remaining requests are not enough
+	g, srv := newTestPullRequestServer(t, 5)
+	defer srv.Close()
+	prs, err := g.ListPullRequests(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 3 || prs[0].Detailed || prs[0].CheckState != "" {
+		t.Fatalf("the details should be skipped but %+v", prs[0])
+	}
+	if remaining, reset := g.RateLimit(); remaining != 5 || reset.Before(time.Now()) {
+		t.Fatalf("the remaining should be 5 until an hour later but %d, %v", remaining, reset)
+	}
+
+	// the rate limit error tells when to retry
+	g, srv = newTestPullRequestServer(t, 0)
+	defer srv.Close()
+	_, err = g.ListPullRequests(nil)
+	d, limited := RetryAfter(err)
+	if !limited || d < 59*time.Minute {
+		t.Fatalf("the request should be retried an hour later but %v, %v (%v)", d, limited, err)
+	}
+	if _, limited = RetryAfter(nil); limited {
+		t.Fatal("nil should not be rate limited")
+	}
+}
+
+func TestGetBranchPullRequest(t *testing.T) {
+	g, srv := newTestPullRequestServer(t, 5000)
+	defer srv.Close()
+
+	g.branch = "feature"
+	pr, comments, err := g.GetBranchPullRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr == nil || pr.Number != 1 || pr.CheckState != "pending" || len(pr.Reviewers) != 1 {
+		t.Fatalf("#1 should be the pull request of feature but %+v", pr)
+	}
+	// the comment on the diff and the review with the body in the order of time
+	if len(comments) != 2 || comments[0].Path != "main.go" || comments[0].Position != 3 || comments[1].Body != "LGTM" || comments[1].State != "COMMENTED" {
+		t.Fatalf("2 comments should be returned but %+v", comments)
+	}
+
+	g.branch = "i12-no-pull-request"
+	pr, comments, err = g.GetBranchPullRequest()
+	if err != nil || pr != nil || comments != nil {
+		t.Fatalf("no pull request should be returned but %+v, %v, %v", pr, comments, err)
+	}
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var scpLikeURL = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// Remote is a repository on GitHub referred by a git remote
+type Remote struct {
+	Host  string // including the port if the URL has it
+	Owner string
+	Name  string
+}
+
+// ParseRemoteURL parses a URL of a git remote like
+// "git@github.com:owner/repo.git", "ssh://git@github.com:22/owner/repo.git"
+// and "https://github.com/owner/repo"
+func ParseRemoteURL(remoteURL string) (r *Remote, err error) {
+	remoteURL = strings.TrimSpace(remoteURL)
+	var host, path string
+	if strings.Contains(remoteURL, "://") {
+		u, perr := url.Parse(remoteURL)
+		if perr != nil {
+			return nil, perr
+		}
+		host = u.Host
+		path = u.Path
+	} else if m := scpLikeURL.FindStringSubmatch(remoteURL); m != nil {
+		// scp-like syntax of ssh, "[user@]host:path"
+		host = m[1]
+		path = m[2]
+	} else {
+		return nil, errors.New(remoteURL + " is not a URL of a remote repository")
+	}
+	// drop the default port of ssh
+	if strings.HasSuffix(host, ":22") && !strings.HasPrefix(remoteURL, "http") {
+		host = strings.TrimSuffix(host, ":22")
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	sp := strings.Split(path, "/")
+	if host == "" || len(sp) < 2 || sp[len(sp)-2] == "" || sp[len(sp)-1] == "" {
+		return nil, errors.New(remoteURL + " doesn't have an owner and a repository name")
+	}
+	// the owner and the name are the last two elements, GHE may serve repositories under a path prefix
+	return &Remote{
+		Host:  host,
+		Owner: sp[len(sp)-2],
+		Name:  sp[len(sp)-1],
+	}, nil
+}
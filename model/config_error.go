@@ -0,0 +1,42 @@
+package model
+
+import "strconv"
+
+// ConfigError is returned by NewLoader when the config doesn't pass ConfigValidator
+type ConfigError struct {
+	ConfigPath string             `json:"config"`
+	Errors     []*ConfigErrorItem `json:"errors"`
+}
+
+// ConfigErrorItem is a validation error with its position in the config file
+type ConfigErrorItem struct {
+	Message  string `json:"message"`
+	Position string `json:"position"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func newConfigError(configPath string, src []byte, vErrs []*validationError) (e *ConfigError) {
+	e = &ConfigError{ConfigPath: configPath}
+	locator := newConfigLocator(src)
+	for _, v := range vErrs {
+		line, column := locator.locate(v.position)
+		e.Errors = append(e.Errors, &ConfigErrorItem{
+			Message:  v.message,
+			Position: v.position,
+			Line:     line,
+			Column:   column,
+		})
+	}
+	return
+}
+
+// Error is the implementation of error.Error
+func (e *ConfigError) Error() string {
+	return e.ConfigPath + " has " + strconv.Itoa(len(e.Errors)) + " configuration error(s)"
+}
+
+// String formats an item as "path:line:column: message (position)"
+func (i *ConfigErrorItem) String(configPath string) string {
+	return configPath + ":" + strconv.Itoa(i.Line) + ":" + strconv.Itoa(i.Column) + ": " + i.Message + " (position : " + i.Position + ")"
+}
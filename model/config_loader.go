@@ -1,9 +1,9 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"os"
 	"strconv"
 
 	ui "github.com/gizak/termui"
@@ -15,12 +15,17 @@ import (
 
 // ConfRoot is the root schema of config file
 type ConfRoot struct {
-	SchemaVersion string `yaml:"schema_version"`
-	Timezone      string
-	GitHubHost    string `yaml:"github_url"`
-	Envs          []map[string]string
-	Widgets       []*widgetNode
-	Layout        []*tabNode
+	SchemaVersion      string `yaml:"schema_version"`
+	Timezone           string
+	GitHubHost         string `yaml:"github_url"`
+	GitHubTokenCommand string `yaml:"github_token_command"`
+	GitHubClientID     string `yaml:"github_client_id"`
+	GitHubCABundle     string `yaml:"github_ca_bundle"`
+	GitHubRemote       string `yaml:"github_remote"`
+	DockerHost         string `yaml:"docker_host"`
+	Envs               []map[string]string
+	Widgets            []*widgetNode
+	Layout             []*tabNode
 }
 
 // tabNode is the schema implements ConfRoot.OriginalWidgets.Section
@@ -59,6 +64,11 @@ type widgetNode struct {
 	IssueRegex string `yaml:"issue_regex"`
 	Content    interface{}
 	Path       string
+	Command    string
+	Interval   string
+	Timeout    string
+	Repo       string
+	DockerHost string `yaml:"docker_host"`
 }
 
 // ConfigLoader load and unmarshal config file
@@ -87,6 +97,9 @@ func NewLoader(opt *ConfigLoaderOption) (c *ConfigLoader, err error) {
 	if err != nil {
 		return
 	}
+	if c.config == nil {
+		return c, errors.New(opt.ConfigPath + " is empty")
+	}
 	err = c.checkConfigScheme()
 	if err != nil {
 		return
@@ -95,20 +108,14 @@ func NewLoader(opt *ConfigLoaderOption) (c *ConfigLoader, err error) {
 	if err != nil {
 		return
 	}
+	validator.execPath = opt.ExecPath
 
 	res, err := validator.validate(c.config)
 	if err != nil {
 		return
 	}
 	if res != nil {
-		fmt.Println("==================================================================")
-		fmt.Println("CONFIGURATION ERROR")
-		fmt.Println(".....................")
-		for i, r := range res {
-			fmt.Println("No." + strconv.Itoa(i+1) + " : " + r.message + " (position : " + r.position + ")")
-		}
-		fmt.Println("==================================================================")
-		os.Exit(0)
+		return c, newConfigError(opt.ConfigPath, file, res)
 	}
 
 	err = c.optimiseIncompleteParams()
@@ -120,14 +127,15 @@ func NewLoader(opt *ConfigLoaderOption) (c *ConfigLoader, err error) {
 
 func (c *ConfigLoader) checkConfigScheme() (err error) {
 	vApp, err := version.NewVersion(c.options.ConfigSchemaVersion)
-	vConfig, err := version.NewVersion(c.config.SchemaVersion)
 	if err != nil {
 		return
 	}
+	vConfig, err := version.NewVersion(c.config.SchemaVersion)
+	if err != nil {
+		return fmt.Errorf("schema_version in %s is invalid: %v", c.options.ConfigPath, err)
+	}
 	if vConfig.LessThan(vApp) {
-		fmt.Printf("mcc %s supports schema_version %s but ths schema_version in %s seems to be %s\n", c.options.AppVersion, vApp, c.options.ConfigPath, vConfig)
-		fmt.Printf("please upgrade mcc or %s first\n", c.options.ConfigPath)
-		os.Exit(1)
+		return fmt.Errorf("mcc %s supports schema_version %s but the schema_version in %s seems to be %s, please upgrade mcc or %s first", c.options.AppVersion, vApp, c.options.ConfigPath, vConfig, c.options.ConfigPath)
 	}
 	return
 }
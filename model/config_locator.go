@@ -0,0 +1,144 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// configLocator indexes the line and column of each node in a yaml config
+// by the same path notation as validationError.position (e.g. "layout[0].rows[1].height")
+type configLocator struct {
+	positions map[string]*configPosition
+}
+
+// configPosition is the 1-based line and column of a node
+type configPosition struct {
+	line   int
+	column int
+}
+
+// locatorFrame is a mapping key or a sequence item which may contain children
+type locatorFrame struct {
+	column int
+	path   string
+	isItem bool
+}
+
+// newConfigLocator scans the yaml source line by line.
+// block mappings and block sequences are indexed, flow styles are regarded as scalars
+func newConfigLocator(src []byte) (l *configLocator) {
+	l = new(configLocator)
+	l.positions = map[string]*configPosition{}
+
+	var stack []*locatorFrame
+	counters := map[string]int{}
+	blockIndent := -1
+	for i, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		column := len(line) - len(trimmed)
+		// skip the contents of block scalars ("|" or ">")
+		if blockIndent > -1 {
+			if trimmed == "" || column > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if trimmed == "" || trimmed[0] == '#' || trimmed == "---" {
+			continue
+		}
+
+		for {
+			if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+				// sequence item
+				for len(stack) > 0 && (stack[len(stack)-1].column > column || (stack[len(stack)-1].isItem && stack[len(stack)-1].column == column)) {
+					stack = stack[:len(stack)-1]
+				}
+				parent := ""
+				if len(stack) > 0 {
+					parent = stack[len(stack)-1].path
+				}
+				path := parent + "[" + strconv.Itoa(counters[parent]) + "]"
+				counters[parent]++
+				l.record(path, i+1, column+1)
+				stack = append(stack, &locatorFrame{column: column, path: path, isItem: true})
+
+				rest := strings.TrimLeft(strings.TrimPrefix(trimmed, "-"), " ")
+				column = column + len(trimmed) - len(rest)
+				trimmed = rest
+				if trimmed == "" {
+					break
+				}
+				if strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, ">") {
+					blockIndent = stack[len(stack)-1].column
+					break
+				}
+				continue
+			}
+
+			// mapping key
+			key, value, ok := l.splitKey(trimmed)
+			if !ok {
+				break
+			}
+			for len(stack) > 0 && stack[len(stack)-1].column >= column {
+				stack = stack[:len(stack)-1]
+			}
+			path := key
+			if len(stack) > 0 {
+				path = stack[len(stack)-1].path + "." + key
+			}
+			l.record(path, i+1, column+1)
+			stack = append(stack, &locatorFrame{column: column, path: path})
+			if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+				blockIndent = column
+			}
+			break
+		}
+	}
+	return
+}
+
+func (l *configLocator) splitKey(s string) (key string, value string, ok bool) {
+	idx := strings.Index(s, ": ")
+	if idx < 0 {
+		if !strings.HasSuffix(s, ":") {
+			return
+		}
+		idx = len(s) - 1
+	}
+	key = strings.Trim(s[:idx], "\"'")
+	if key == "" || strings.ContainsAny(key, "{[") {
+		return
+	}
+	value = strings.TrimSpace(s[idx+1:])
+	ok = true
+	return
+}
+
+func (l *configLocator) record(path string, line int, column int) {
+	if _, ok := l.positions[path]; ok {
+		return
+	}
+	l.positions[path] = &configPosition{line: line, column: column}
+}
+
+// locate returns the line and column of the position,
+// or of its nearest ancestor if the node itself is not written in the config
+func (l *configLocator) locate(position string) (line int, column int) {
+	path := strings.SplitN(position, " ", 2)[0]
+	if path == "root" {
+		return 1, 1
+	}
+	for path != "" {
+		if p, ok := l.positions[path]; ok {
+			return p.line, p.column
+		}
+		idx := strings.LastIndexAny(path, ".[")
+		if idx < 0 {
+			break
+		}
+		path = path[:idx]
+	}
+	return 0, 0
+}
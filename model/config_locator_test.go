@@ -0,0 +1,60 @@
+package model
+
+import "testing"
+
+func TestConfigLocator(t *testing.T) {
+	src := []byte(`---
+schema_version: v1.1.0
+widgets:
+  - id: note
+    type: note
+    content: |
+      - id: not a widget
+        type: note
+  - id: docker_status
+    content:
+      - metrics: cpu
+        name: Web Server
+widgets_no_indent:
+- id: hoge
+  type: note
+layout:
+  - name: TAB1
+    rows:
+      - height: 50%
+        cols:
+          - width: 4
+            stacks:
+              - id: note
+              - id: docker_status
+                height: 20%
+`)
+	l := newConfigLocator(src)
+	positions := []struct {
+		position string
+		line     int
+		column   int
+	}{
+		{"root", 1, 1},
+		{"schema_version", 2, 1},
+		{"widgets[0]", 4, 3},
+		{"widgets[0].type", 5, 5},
+		{"widgets[1]", 9, 3},
+		{"widgets[1].content[0].name", 12, 9},
+		{"widgets_no_indent[0].type", 15, 3},
+		{"layout[0].rows[0].height", 19, 9},
+		{"layout[0].rows[0].cols[0].width", 21, 13},
+		{"layout[0].rows[0].cols[0].stacks[1].id = docker_status", 24, 17},
+		{"layout[0].rows[0].cols[0].stacks[1].height", 25, 17},
+		// falls back to the nearest ancestor
+		{"layout[0].rows[0].cols[0].stacks[0].height", 23, 15},
+		{"layout[0].rows[0].cols[]", 20, 9},
+		{"nothing", 0, 0},
+	}
+	for _, p := range positions {
+		line, column := l.locate(p.position)
+		if line != p.line || column != p.column {
+			t.Fatalf("%s should be at %d:%d but %d:%d", p.position, p.line, p.column, line, column)
+		}
+	}
+}
@@ -1,9 +1,16 @@
 package model
 
 import (
+	"os"
+	"regexp"
 	"strconv"
+	"time"
 
+	humanize "github.com/dustin/go-humanize"
 	m2s "github.com/mitchellh/mapstructure"
+	"github.com/qmu/mcc/docker"
+	"github.com/qmu/mcc/git"
+	"github.com/qmu/mcc/github"
 	"github.com/qmu/mcc/utils"
 	"github.com/qmu/mcc/widget"
 )
@@ -13,13 +20,35 @@ const (
 	vErrLackOfWidgetID                   = "'widgets[].id' should have value"
 	vErrLackOfWidgetType                 = "'widgets[].type' should have value"
 	vErrLackOfWidgetTitle                = "'widgets[].title' should have value"
+	vErrIntervalInvalid                  = "'widgets[].interval' should be a duration like '10s', '1m'"
+	vErrRepoUnsupported                  = "'widgets[].repo' is available only for git_status, git_log, git_branches, github_issue and github_pull_requests"
+	vErrRepoNotFound                     = "'widgets[].repo' should be a directory of a git repository"
 	vErrLackOfNoteContent                = "'widgets[].type=note' should have content"
 	vErrLackOfTextFilePath               = "'widgets[].type=text_file' should have path"
 	vErrLackOfDockerStatusContent        = "'widgets[].type=docker_status' should have content"
 	vErrLackOfDockerStatusName           = "'widgets[].type=docker_status' should have value of content[].name"
-	vErrLackOfDockerStatusContainer      = "'widgets[].type=docker_status' should have value of content[].container"
+	vErrLackOfDockerStatusContainer      = "'widgets[].type=docker_status' should have value of content[].container or content[].selector"
 	vErrLackOfDockerStatusMetrics        = "'widgets[].type=docker_status' should have value of content[].metrics"
-	vErrLackOfDockerStatusInvalidMetrics = "'widgets[].type=docker_status' metrics should be 'cpu' or 'memory'"
+	vErrLackOfDockerStatusInvalidMetrics = "'widgets[].type=docker_status' metrics should be 'cpu', 'memory', 'net_rx', 'net_tx', 'block_read', 'block_write', 'pids' or 'status'"
+	vErrDockerStatusModeInvalid          = "'widgets[].type=docker_status' mode should be 'rate' or 'total' for net_rx, net_tx, block_read and block_write"
+	vErrDockerStatusMaxInvalid           = "'widgets[].type=docker_status' max should be a number or a size like '10MB'"
+	vErrDockerStatusSelectorConflict     = "'widgets[].type=docker_status' content[].container and content[].selector can't be used together"
+	vErrDockerStatusSelectorEmpty        = "'widgets[].type=docker_status' content[].selector should have project, label or name"
+	vErrDockerStatusSelectorNameInvalid  = "'widgets[].type=docker_status' content[].selector.name should be a valid regular expression"
+	vErrDockerStatusSparklineUnsupported = "'widgets[].type=docker_status' sparkline is not available for status"
+	vErrDockerStatusWindowInvalid        = "'widgets[].type=docker_status' window should be a duration like '5m'"
+	vErrDockerHostUnsupported            = "'widgets[].docker_host' is available only for docker_status and docker_logs"
+	vErrLackOfDockerLogsContainer        = "'widgets[].type=docker_logs' content should have either container or service"
+	vErrDockerLogsContentInvalid         = "'widgets[].type=docker_logs' content should have only container, service, project, tail and timestamps"
+	vErrLackOfSystemStatsContent         = "'widgets[].type=system_stats' should have content"
+	vErrSystemStatsInvalidMetrics        = "'widgets[].type=system_stats' metrics should be 'cpu', 'memory', 'load', 'disk', 'net_rx' or 'net_tx'"
+	vErrSystemStatsModeInvalid           = "'widgets[].type=system_stats' mode should be 'rate' or 'total' for net_rx and net_tx"
+	vErrSystemStatsMaxInvalid            = "'widgets[].type=system_stats' max should be a number or a size like '10MB'"
+	vErrSystemStatsWindowInvalid         = "'widgets[].type=system_stats' window should be a duration like '5m'"
+	vErrProcessListContentInvalid        = "'widgets[].type=process_list' content should have only match and sort"
+	vErrProcessListMatchInvalid          = "'widgets[].type=process_list' match should be a valid regular expression"
+	vErrProcessListSortInvalid           = "'widgets[].type=process_list' sort should be 'pid', 'user', 'cpu', 'rss' or 'command'"
+	vErrDockerHostInvalid                = "'docker_host' should be like 'unix:///var/run/docker.sock' or 'tcp://127.0.0.1:2376'"
 	vErrLackOfMenuContent                = "'widgets[].type=menu' should have content"
 	vErrLackOfMenuName                   = "'widgets[].type=menu' should have value of content[].name"
 	vErrLackOfMenuCategory               = "'widgets[].type=menu' should have value of content[].category"
@@ -27,6 +56,10 @@ const (
 	vErrLackOfMenuCommand                = "'widgets[].type=menu' should have value of content[].command"
 	vErrLackOfGithubIssueRegex           = "'widgets[].type=github_issue' should have issue_regex"
 	vErrLackOfTailFilePath               = "'widgets[].type=tail_file' should have path"
+	vErrLackOfCommandCommand             = "'widgets[].type=command' should have command"
+	vErrCommandTimeoutInvalid            = "'widgets[].type=command' timeout should be a duration like '10s', '1m'"
+	vErrPullRequestsFilterInvalid        = "'widgets[].type=github_pull_requests' content should have only author, review_requested and label"
+	vErrGitLogContentInvalid             = "'widgets[].type=git_log' content should have only ref, path and max_count"
 	// layout section
 	vErrLackOfTabs              = "'layout should have array of tab"
 	vErrLackOfTabName           = "'layout[].name' should have value"
@@ -45,6 +78,16 @@ const (
 
 // ConfigValidator is
 type ConfigValidator struct {
+	execPath string // the directory of the config, relative paths are resolved from it
+}
+
+// repoWidgetTypes are the types of the widgets which accept "repo"
+var repoWidgetTypes = map[string]bool{
+	"git_status":           true,
+	"git_log":              true,
+	"git_branches":         true,
+	"github_issue":         true,
+	"github_pull_requests": true,
 }
 
 // validationError is
@@ -75,6 +118,13 @@ func (c *ConfigValidator) validate(config *ConfRoot) (vErr []*validationError, e
 
 func (c *ConfigValidator) validateWidgets(config *ConfRoot) (vErr []*validationError, err error) {
 	vErr = nil
+	// "docker_host" should be an endpoint of the Docker API
+	if config.DockerHost != "" && docker.ValidateHost(config.DockerHost) != nil {
+		vErr = append(vErr, &validationError{
+			message:  vErrDockerHostInvalid,
+			position: "docker_host",
+		})
+	}
 	for i1, w := range config.Widgets {
 		// all widgetNode should have id, type, title
 		if w.ID == "" {
@@ -95,6 +145,41 @@ func (c *ConfigValidator) validateWidgets(config *ConfRoot) (vErr []*validationE
 				position: "widgets[" + strconv.Itoa(i1) + "]",
 			})
 		}
+		// "repo" should be a git repository
+		if w.Repo != "" {
+			if !repoWidgetTypes[w.Type] {
+				vErr = append(vErr, &validationError{
+					message:  vErrRepoUnsupported,
+					position: "widgets[" + strconv.Itoa(i1) + "].repo",
+				})
+			} else if !isGitRepository(utils.ResolvePath(c.execPath, w.Repo)) {
+				vErr = append(vErr, &validationError{
+					message:  vErrRepoNotFound,
+					position: "widgets[" + strconv.Itoa(i1) + "].repo",
+				})
+			}
+		}
+		// "docker_host" should be an endpoint of the Docker API
+		if w.DockerHost != "" {
+			if w.Type != "docker_status" && w.Type != "docker_logs" {
+				vErr = append(vErr, &validationError{
+					message:  vErrDockerHostUnsupported,
+					position: "widgets[" + strconv.Itoa(i1) + "].docker_host",
+				})
+			} else if docker.ValidateHost(w.DockerHost) != nil {
+				vErr = append(vErr, &validationError{
+					message:  vErrDockerHostInvalid,
+					position: "widgets[" + strconv.Itoa(i1) + "].docker_host",
+				})
+			}
+		}
+		// "interval" should be a positive duration
+		if d, perr := time.ParseDuration(w.Interval); w.Interval != "" && (perr != nil || d <= 0) {
+			vErr = append(vErr, &validationError{
+				message:  vErrIntervalInvalid,
+				position: "widgets[" + strconv.Itoa(i1) + "].interval",
+			})
+		}
 		// type=note widget, should have "content"
 		if w.Type == "note" && w.Content == nil {
 			vErr = append(vErr, &validationError{
@@ -129,25 +214,72 @@ func (c *ConfigValidator) validateWidgets(config *ConfRoot) (vErr []*validationE
 							position: "widgets[" + strconv.Itoa(i1) + "]",
 						})
 					}
-					if ct.Container == "" {
+					if ct.Container == "" && ct.Selector == nil {
 						vErr = append(vErr, &validationError{
 							message:  vErrLackOfDockerStatusContainer,
 							position: "widgets[" + strconv.Itoa(i1) + "]",
 						})
 					}
+					// type=docker_status widget, "selector" finds the containers instead of "container"
+					if s := ct.Selector; s != nil {
+						if ct.Container != "" {
+							vErr = append(vErr, &validationError{
+								message:  vErrDockerStatusSelectorConflict,
+								position: "widgets[" + strconv.Itoa(i1) + "]",
+							})
+						}
+						if s.Project == "" && s.Label == "" && s.Name == "" {
+							vErr = append(vErr, &validationError{
+								message:  vErrDockerStatusSelectorEmpty,
+								position: "widgets[" + strconv.Itoa(i1) + "]",
+							})
+						}
+						if _, rerr := regexp.Compile(s.Name); rerr != nil {
+							vErr = append(vErr, &validationError{
+								message:  vErrDockerStatusSelectorNameInvalid,
+								position: "widgets[" + strconv.Itoa(i1) + "]",
+							})
+						}
+					}
 					if ct.Metrics == "" {
 						vErr = append(vErr, &validationError{
 							message:  vErrLackOfDockerStatusContainer,
 							position: "widgets[" + strconv.Itoa(i1) + "]",
 						})
 					}
-					// type=docker_status widget, "metrics" should be "cpu" or "memory"
-					if ct.Metrics != "cpu" && ct.Metrics != "memory" {
+					// type=docker_status widget, "metrics" should be one of the available metrics
+					if !widget.IsDockerStatusMetrics(ct.Metrics) {
 						vErr = append(vErr, &validationError{
 							message:  vErrLackOfDockerStatusInvalidMetrics,
 							position: "widgets[" + strconv.Itoa(i1) + "]",
 						})
 					}
+					// type=docker_status widget, "mode" is only for the counters
+					if ct.Mode != "" && (!widget.IsDockerStatusCounter(ct.Metrics) || ct.Mode != "rate" && ct.Mode != "total") {
+						vErr = append(vErr, &validationError{
+							message:  vErrDockerStatusModeInvalid,
+							position: "widgets[" + strconv.Itoa(i1) + "]",
+						})
+					}
+					if _, perr := humanize.ParseBytes(ct.Max); ct.Max != "" && perr != nil {
+						vErr = append(vErr, &validationError{
+							message:  vErrDockerStatusMaxInvalid,
+							position: "widgets[" + strconv.Itoa(i1) + "]",
+						})
+					}
+					// type=docker_status widget, "sparkline" is only for the numbers
+					if ct.Sparkline && widget.IsDockerStatusMetrics(ct.Metrics) && !widget.HasDockerStatusHistory(ct.Metrics) {
+						vErr = append(vErr, &validationError{
+							message:  vErrDockerStatusSparklineUnsupported,
+							position: "widgets[" + strconv.Itoa(i1) + "]",
+						})
+					}
+					if d, derr := time.ParseDuration(ct.Window); ct.Window != "" && (derr != nil || d <= 0) {
+						vErr = append(vErr, &validationError{
+							message:  vErrDockerStatusWindowInvalid,
+							position: "widgets[" + strconv.Itoa(i1) + "]",
+						})
+					}
 				}
 			}
 		}
@@ -210,10 +342,161 @@ func (c *ConfigValidator) validateWidgets(config *ConfRoot) (vErr []*validationE
 				})
 			}
 		}
+		if w.Type == "github_pull_requests" && w.Content != nil {
+			// type=github_pull_requests widget, "content" is the filter
+			dec, derr := m2s.NewDecoder(&m2s.DecoderConfig{
+				ErrorUnused: true,
+				Result:      new(github.PullRequestFilter),
+			})
+			if derr != nil {
+				return nil, derr
+			}
+			if dec.Decode(w.Content) != nil {
+				vErr = append(vErr, &validationError{
+					message:  vErrPullRequestsFilterInvalid,
+					position: "widgets[" + strconv.Itoa(i1) + "].content",
+				})
+			}
+		}
+		if w.Type == "git_log" && w.Content != nil {
+			// type=git_log widget, "content" is the option of the log
+			dec, derr := m2s.NewDecoder(&m2s.DecoderConfig{
+				ErrorUnused: true,
+				Result:      new(git.LogOption),
+			})
+			if derr != nil {
+				return nil, derr
+			}
+			if dec.Decode(w.Content) != nil {
+				vErr = append(vErr, &validationError{
+					message:  vErrGitLogContentInvalid,
+					position: "widgets[" + strconv.Itoa(i1) + "].content",
+				})
+			}
+		}
+		if w.Type == "docker_logs" {
+			// type=docker_logs widget, "content" selects the container
+			logs := new(widget.DockerLogs)
+			dec, derr := m2s.NewDecoder(&m2s.DecoderConfig{
+				ErrorUnused: true,
+				Result:      logs,
+			})
+			if derr != nil {
+				return nil, derr
+			}
+			if dec.Decode(w.Content) != nil {
+				vErr = append(vErr, &validationError{
+					message:  vErrDockerLogsContentInvalid,
+					position: "widgets[" + strconv.Itoa(i1) + "].content",
+				})
+			} else if (logs.Container == "") == (logs.Service == "") {
+				vErr = append(vErr, &validationError{
+					message:  vErrLackOfDockerLogsContainer,
+					position: "widgets[" + strconv.Itoa(i1) + "].content",
+				})
+			}
+		}
+		if w.Type == "system_stats" {
+			// type=system_stats widget, should have "content"
+			if w.Content == nil {
+				vErr = append(vErr, &validationError{
+					message:  vErrLackOfSystemStatsContent,
+					position: "widgets[" + strconv.Itoa(i1) + "]",
+				})
+			} else {
+				stats := &[]widget.SystemStat{}
+				if err = m2s.Decode(w.Content, stats); err != nil {
+					return
+				}
+				for _, st := range *stats {
+					// type=system_stats widget, "metrics" should be one of the available metrics
+					if !widget.IsSystemStatsMetrics(st.Metrics) {
+						vErr = append(vErr, &validationError{
+							message:  vErrSystemStatsInvalidMetrics,
+							position: "widgets[" + strconv.Itoa(i1) + "]",
+						})
+					}
+					// type=system_stats widget, "mode" is only for the counters
+					if st.Mode != "" && (!widget.IsSystemStatsCounter(st.Metrics) || st.Mode != "rate" && st.Mode != "total") {
+						vErr = append(vErr, &validationError{
+							message:  vErrSystemStatsModeInvalid,
+							position: "widgets[" + strconv.Itoa(i1) + "]",
+						})
+					}
+					if _, perr := humanize.ParseBytes(st.Max); st.Max != "" && perr != nil {
+						vErr = append(vErr, &validationError{
+							message:  vErrSystemStatsMaxInvalid,
+							position: "widgets[" + strconv.Itoa(i1) + "]",
+						})
+					}
+					if d, derr := time.ParseDuration(st.Window); st.Window != "" && (derr != nil || d <= 0) {
+						vErr = append(vErr, &validationError{
+							message:  vErrSystemStatsWindowInvalid,
+							position: "widgets[" + strconv.Itoa(i1) + "]",
+						})
+					}
+				}
+			}
+		}
+		if w.Type == "process_list" {
+			// type=process_list widget, "content" restricts and sorts the processes
+			list := new(widget.ProcessList)
+			dec, derr := m2s.NewDecoder(&m2s.DecoderConfig{
+				ErrorUnused: true,
+				Result:      list,
+			})
+			if derr != nil {
+				return nil, derr
+			}
+			if dec.Decode(w.Content) != nil {
+				vErr = append(vErr, &validationError{
+					message:  vErrProcessListContentInvalid,
+					position: "widgets[" + strconv.Itoa(i1) + "].content",
+				})
+			} else {
+				if _, rerr := widget.CompileProcessMatch(list.Match, c.execPath); rerr != nil {
+					vErr = append(vErr, &validationError{
+						message:  vErrProcessListMatchInvalid,
+						position: "widgets[" + strconv.Itoa(i1) + "].content.match",
+					})
+				}
+				if list.Sort != "" && !widget.IsProcessListColumn(list.Sort) {
+					vErr = append(vErr, &validationError{
+						message:  vErrProcessListSortInvalid,
+						position: "widgets[" + strconv.Itoa(i1) + "].content.sort",
+					})
+				}
+			}
+		}
+		if w.Type == "command" {
+			// type=command widget, should have "command"
+			if w.Command == "" {
+				vErr = append(vErr, &validationError{
+					message:  vErrLackOfCommandCommand,
+					position: "widgets[" + strconv.Itoa(i1) + "]",
+				})
+			}
+			// type=command widget, "timeout" should be a positive duration
+			if d, perr := time.ParseDuration(w.Timeout); w.Timeout != "" && (perr != nil || d <= 0) {
+				vErr = append(vErr, &validationError{
+					message:  vErrCommandTimeoutInvalid,
+					position: "widgets[" + strconv.Itoa(i1) + "].timeout",
+				})
+			}
+		}
 	}
 	return
 }
 
+// isGitRepository tells if the directory is in a git repository
+func isGitRepository(path string) bool {
+	if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+		return false
+	}
+	root, err := utils.GetDotGitPath(path)
+	return err == nil && root != ""
+}
+
 func (c *ConfigValidator) validateLayout(config *ConfRoot) (vErr []*validationError, err error) {
 	vErr = nil
 	// ConfRoot.Layout should be set
@@ -293,7 +576,7 @@ func (c *ConfigValidator) validateLayout(config *ConfRoot) (vErr []*validationEr
 					if !defined {
 						vErr = append(vErr, &validationError{
 							message:  vErrWidgetDoesNotExit,
-							position: "widgets[" + strconv.Itoa(i4) + "].id = " + s.ID + "",
+							position: "layout[" + strconv.Itoa(i1) + "].rows[" + strconv.Itoa(i2) + "].cols[" + strconv.Itoa(i3) + "].stacks[" + strconv.Itoa(i4) + "].id = " + s.ID,
 						})
 					}
 					// stackNode.Height should be "[1-100]%" format
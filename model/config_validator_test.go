@@ -1,6 +1,11 @@
 package model
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func BenchmarkValidateLayout(b *testing.B) {
 	v, err := NewConfigValidator()
@@ -292,6 +297,172 @@ func TestValidateWidgets(t *testing.T) {
 		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
 	}
 
+	// vErrDockerStatusModeInvalid
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"name": "name1", "container": "hoge_container", "metrics": "cpu", "mode": "total"},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrDockerStatusModeInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"name": "name1", "container": "hoge_container", "metrics": "net_rx", "mode": "peak"},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrDockerStatusModeInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrDockerStatusSelectorConflict
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"name": "name1", "container": "hoge_container", "metrics": "cpu", "selector": map[interface{}]interface{}{"project": "app"}},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrDockerStatusSelectorConflict {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrDockerStatusSelectorEmpty
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"name": "name1", "metrics": "cpu", "selector": map[interface{}]interface{}{}},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrDockerStatusSelectorEmpty {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrDockerStatusSelectorNameInvalid
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"name": "name1", "metrics": "cpu", "selector": map[interface{}]interface{}{"name": "web[", "label": "tier=web"}},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrDockerStatusSelectorNameInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"name": "name1", "metrics": "cpu", "selector": map[interface{}]interface{}{"project": "app", "name": "^web"}},
+	}
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) > 0 || err != nil {
+		t.Fatalf("the selector should be valid but %v | error:%v", vErrs, err)
+	}
+
+	// vErrDockerStatusSparklineUnsupported
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"name": "name1", "container": "hoge_container", "metrics": "status", "sparkline": true},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrDockerStatusSparklineUnsupported {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrDockerStatusWindowInvalid
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"name": "name1", "container": "hoge_container", "metrics": "memory", "sparkline": true, "window": "5"},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrDockerStatusWindowInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrDockerStatusMaxInvalid
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"name": "name1", "container": "hoge_container", "metrics": "block_write", "max": "fast"},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrDockerStatusMaxInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"name": "name1", "container": "hoge_container", "metrics": "net_tx", "mode": "total", "max": "1GB"},
+		map[interface{}]interface{}{"name": "name1", "container": "hoge_container", "metrics": "pids", "max": "100"},
+		map[interface{}]interface{}{"name": "name1", "container": "hoge_container", "metrics": "status"},
+	}
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 0 {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrLackOfSystemStatsContent
+	conf = ConfRoot{
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:    "widget1",
+				Title: "widget1",
+				Type:  "system_stats",
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrLackOfSystemStatsContent {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrSystemStatsInvalidMetrics
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"metrics": "pids"},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrSystemStatsInvalidMetrics {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrSystemStatsModeInvalid
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"metrics": "disk", "mode": "total"},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrSystemStatsModeInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrSystemStatsMaxInvalid
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"metrics": "net_rx", "max": "fast"},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrSystemStatsMaxInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrSystemStatsWindowInvalid
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"metrics": "cpu", "sparkline": true, "window": "-1m"},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrSystemStatsWindowInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	conf.Widgets[0].Content = []interface{}{
+		map[interface{}]interface{}{"metrics": "cpu", "sparkline": true, "window": "10m"},
+		map[interface{}]interface{}{"metrics": "load", "max": "4"},
+		map[interface{}]interface{}{"metrics": "disk", "path": "/home"},
+		map[interface{}]interface{}{"metrics": "net_tx", "interface": "eth0", "mode": "total"},
+	}
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 0 {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrProcessListContentInvalid
+	conf = ConfRoot{
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:      "widget1",
+				Title:   "widget1",
+				Type:    "process_list",
+				Content: map[interface{}]interface{}{"filter": "node"},
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrProcessListContentInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrProcessListMatchInvalid
+	conf.Widgets[0].Content = map[interface{}]interface{}{"match": "{exec_path}/(node"}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrProcessListMatchInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrProcessListSortInvalid
+	conf.Widgets[0].Content = map[interface{}]interface{}{"sort": "mem"}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrProcessListSortInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	conf.Widgets[0].Content = map[interface{}]interface{}{"match": "^{exec_path}", "sort": "rss"}
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 0 {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	conf.Widgets[0].Content = nil
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 0 {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
 	// vErrLackOfMenuContent
 	conf = ConfRoot{
 		Widgets: []*widgetNode{
@@ -417,6 +588,216 @@ func TestValidateWidgets(t *testing.T) {
 	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrLackOfTailFilePath {
 		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
 	}
+
+	// vErrLackOfCommandCommand
+	conf = ConfRoot{
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:    "widget1",
+				Title: "widget1",
+				Type:  "command",
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrLackOfCommandCommand {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrIntervalInvalid
+	conf = ConfRoot{
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:       "widget1",
+				Title:    "widget1",
+				Type:     "command",
+				Command:  "date",
+				Interval: "10",
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrIntervalInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrCommandTimeoutInvalid
+	conf = ConfRoot{
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:       "widget1",
+				Title:    "widget1",
+				Type:     "command",
+				Command:  "date",
+				Interval: "10s",
+				Timeout:  "-1s",
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrCommandTimeoutInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrPullRequestsFilterInvalid
+	conf = ConfRoot{
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:    "widget1",
+				Title: "widget1",
+				Type:  "github_pull_requests",
+				Content: map[interface{}]interface{}{
+					"author":   "@me",
+					"reviewer": "@me",
+				},
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrPullRequestsFilterInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrGitLogContentInvalid
+	conf = ConfRoot{
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:    "widget1",
+				Title: "widget1",
+				Type:  "git_log",
+				Content: map[interface{}]interface{}{
+					"ref":   "master",
+					"limit": 10,
+				},
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrGitLogContentInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	conf.Widgets[0].Content = map[interface{}]interface{}{
+		"ref":       "master",
+		"path":      "widget/",
+		"max_count": 10,
+	}
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 0 {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrDockerLogsContentInvalid
+	conf = ConfRoot{
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:    "widget1",
+				Title: "widget1",
+				Type:  "docker_logs",
+				Content: map[interface{}]interface{}{
+					"container": "web",
+					"follow":    true,
+				},
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrDockerLogsContentInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrLackOfDockerLogsContainer
+	conf.Widgets[0].Content = nil
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrLackOfDockerLogsContainer {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	conf.Widgets[0].Content = map[interface{}]interface{}{"container": "web", "service": "web"}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrLackOfDockerLogsContainer {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	conf.Widgets[0].Content = map[interface{}]interface{}{"service": "web", "project": "app", "tail": 100, "timestamps": true}
+	conf.Widgets[0].DockerHost = "tcp://127.0.0.1:2376"
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 0 {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrRepoUnsupported
+	conf = ConfRoot{
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:      "widget1",
+				Title:   "widget1",
+				Type:    "note",
+				Content: "note",
+				Repo:    "..",
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrRepoUnsupported {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrRepoNotFound
+	dir, err := ioutil.TempDir("", "mcc-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	v.execPath = dir
+	conf.Widgets[0].Type = "git_status"
+	conf.Widgets[0].Repo = "no-such-dir"
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrRepoNotFound {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	// a linked worktree has a .git file
+	os.MkdirAll(filepath.Join(dir, "api", ".git"), 0755)
+	os.MkdirAll(filepath.Join(dir, "linked"), 0755)
+	conf.Widgets[0].Repo = "api"
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrRepoNotFound {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "api", ".git", "HEAD"), []byte("ref: refs/heads/master\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "linked", ".git"), []byte("gitdir: ../api/.git\n"), 0644)
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 0 {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+	conf.Widgets[0].Repo = "linked"
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 0 {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrDockerHostUnsupported
+	conf = ConfRoot{
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:         "widget1",
+				Title:      "widget1",
+				Type:       "note",
+				Content:    "hoge",
+				DockerHost: "tcp://127.0.0.1:2376",
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); vErrs[0].message != vErrDockerHostUnsupported {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
+
+	// vErrDockerHostInvalid
+	conf = ConfRoot{
+		DockerHost: "ssh://user@host",
+		Widgets: []*widgetNode{
+			&widgetNode{
+				ID:      "widget1",
+				Title:   "widget1",
+				Type:    "docker_status",
+				Content: []map[interface{}]interface{}{{"name": "web", "container": "web", "metrics": "cpu"}},
+			},
+		},
+	}
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 1 || vErrs[0].message != vErrDockerHostInvalid {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs, err)
+	}
+	conf.DockerHost = "unix:///run/user/1000/podman/podman.sock"
+	conf.Widgets[0].DockerHost = "tcp://"
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 1 || vErrs[0].position != "widgets[0].docker_host" {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs, err)
+	}
+	conf.Widgets[0].DockerHost = "tcp://192.168.99.100:2376"
+	if vErrs, err := v.validateWidgets(&conf); len(vErrs) != 0 {
+		t.Fatalf("Get validation error: %v | error:%v", vErrs[0].message, err)
+	}
 }
 
 func TestValidateLayout(t *testing.T) {
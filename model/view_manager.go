@@ -9,6 +9,7 @@ import (
 	"github.com/qmu/mcc/model/vector"
 	"github.com/qmu/mcc/utils"
 	"github.com/qmu/mcc/widget"
+	"github.com/qmu/mcc/widget/listable"
 )
 
 // ViewManager load and unmarshal config file
@@ -125,6 +126,11 @@ func (c *ViewManager) buildCollection() (err error) {
 						IssueRegex: wi.IssueRegex,
 						Type:       wi.Type,
 						Path:       wi.Path,
+						Command:    wi.Command,
+						Interval:   wi.Interval,
+						Timeout:    wi.Timeout,
+						Repo:       utils.ResolvePath(c.execPath, wi.Repo),
+						DockerHost: c.getDockerHost(wi),
 					}
 					if err != nil {
 						return err
@@ -185,7 +191,7 @@ func (c *ViewManager) renderTabPane(tab *tabNode) (err error) {
 	if tab.initialized {
 		ui.Body.AddRows(tab.renderedCells...)
 		ui.Body.Align()
-		ui.Render(ui.Body)
+		listable.RenderBody()
 		return
 	}
 	tab.initialized = true
@@ -231,7 +237,7 @@ func (c *ViewManager) renderTabPane(tab *tabNode) (err error) {
 
 	ui.Body.AddRows(screen...)
 	ui.Body.Align()
-	ui.Render(ui.Body)
+	listable.RenderBody()
 
 	return nil
 }
@@ -302,6 +308,33 @@ func (c *ViewManager) moveActually(from *widget.WrapperWidget, to *widget.Wrappe
 	}
 }
 
+// ActivateWidget moves the focus to the widget of idx if it's on the active tab
+func (c *ViewManager) ActivateWidget(idx int) bool {
+	to := c.getWidgetByIndex(idx)
+	if to == nil || to.Tab != c.activeTabIndex || to.IsDisabled() || !to.IsReady() {
+		return false
+	}
+	from := c.getWidgetByIndex(c.activeWidgetIndex)
+	if from != nil && from != to {
+		from.Deactivate()
+	}
+	to.Activate()
+	c.activeWidgetIndex = idx
+	c.lastWidget = nil
+	c.lastDirection = ""
+	return true
+}
+
+// GetActiveTabIndex returns the index of the current tab
+func (c *ViewManager) GetActiveTabIndex() int {
+	return c.activeTabIndex
+}
+
+// GetActiveWidgetIndex returns the index of the focused widget
+func (c *ViewManager) GetActiveWidgetIndex() int {
+	return c.activeWidgetIndex
+}
+
 // HasWidget returns whether config contains 'widgetType' stack or not
 func (c *ViewManager) HasWidget(widgetType string) bool {
 	result := false
@@ -346,3 +379,31 @@ func (c *ViewManager) MapWidgets(fn func(*widget.WrapperWidget) error) (err erro
 func (c *ViewManager) GetGithubHost() string {
 	return c.config.GitHubHost
 }
+
+// GetGithubTokenCommand returns the command which prints a GitHub token
+func (c *ViewManager) GetGithubTokenCommand() string {
+	return c.config.GitHubTokenCommand
+}
+
+// GetGithubCABundle returns the path of the PEM file trusted for GitHub Enterprise
+func (c *ViewManager) GetGithubCABundle() string {
+	return utils.ResolvePath(c.execPath, c.config.GitHubCABundle)
+}
+
+// GetGithubRemote returns the name of the git remote which refers the GitHub repository
+func (c *ViewManager) GetGithubRemote() string {
+	return c.config.GitHubRemote
+}
+
+// getDockerHost returns docker_host of the widget, the global one if the widget doesn't have it
+func (c *ViewManager) getDockerHost(wi *widgetNode) string {
+	if wi.DockerHost != "" {
+		return wi.DockerHost
+	}
+	return c.config.DockerHost
+}
+
+// GetGithubClientID returns the client id of the OAuth App for the device flow
+func (c *ViewManager) GetGithubClientID() string {
+	return c.config.GitHubClientID
+}
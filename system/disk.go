@@ -0,0 +1,16 @@
+package system
+
+// DiskUsage is the usage of the filesystem of a mount point in bytes like df
+type DiskUsage struct {
+	Total     uint64
+	Used      uint64
+	Available uint64 // available for the unprivileged users
+}
+
+// Percent returns the percentage of the used bytes to the ones the users can use like df
+func (d *DiskUsage) Percent() float64 {
+	if d.Used+d.Available == 0 {
+		return 0
+	}
+	return float64(d.Used) / float64(d.Used+d.Available) * 100
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package system
+
+import "syscall"
+
+// Disk reads the usage of the filesystem which the path is on by statfs
+func Disk(path string) (d *DiskUsage, err error) {
+	var st syscall.Statfs_t
+	if err = syscall.Statfs(path, &st); err != nil {
+		return
+	}
+	size := uint64(st.Bsize)
+	return &DiskUsage{
+		Total:     st.Blocks * size,
+		Used:      (st.Blocks - st.Bfree) * size,
+		Available: st.Bavail * size,
+	}, nil
+}
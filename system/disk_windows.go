@@ -0,0 +1,8 @@
+package system
+
+import "errors"
+
+// Disk is not supported on Windows
+func Disk(path string) (*DiskUsage, error) {
+	return nil, errors.New("disk usage is not supported on windows")
+}
@@ -0,0 +1,141 @@
+package system
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Process is a process read from /proc/[pid]
+type Process struct {
+	PID     int
+	UID     int    // the real user ID
+	Name    string // the name of the executable like "bash"
+	Cmdline string // the arguments joined with spaces, "[Name]" for a kernel thread
+	Cwd     string // the working directory, empty if it can't be read
+	State   string // "R", "S", "Z" ...
+	Ticks   uint64 // the CPU time in user and kernel mode in USER_HZ
+	RSS     uint64 // the resident set size in bytes
+	Start   uint64 // the time the process started after the boot in USER_HZ, it tells a reused pid
+}
+
+// Processes reads all the processes, the ones which exit while reading are skipped
+func (fs *FS) Processes() (ps []*Process, err error) {
+	entries, err := ioutil.ReadDir(fs.Root)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		pid, perr := strconv.Atoi(e.Name())
+		if perr != nil || !e.IsDir() {
+			continue
+		}
+		p, perr := fs.Process(pid)
+		if perr != nil {
+			continue
+		}
+		ps = append(ps, p)
+	}
+	return
+}
+
+// Process reads the process of the pid
+func (fs *FS) Process(pid int) (p *Process, err error) {
+	dir := strconv.Itoa(pid)
+	p = &Process{PID: pid}
+	stat, err := ioutil.ReadFile(filepath.Join(fs.Root, dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	if err = p.parseStat(string(stat)); err != nil {
+		return nil, err
+	}
+	status, err := fs.readLines(filepath.Join(dir, "status"))
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range status {
+		// "Uid:	1000	1000	1000	1000"
+		if fields := strings.Fields(l); len(fields) > 1 && fields[0] == "Uid:" {
+			if p.UID, err = strconv.Atoi(fields[1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	cmdline, err := ioutil.ReadFile(filepath.Join(fs.Root, dir, "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+	// the arguments are terminated by NUL
+	p.Cmdline = strings.TrimSpace(strings.Replace(strings.TrimRight(string(cmdline), "\x00"), "\x00", " ", -1))
+	if p.Cmdline == "" {
+		p.Cmdline = "[" + p.Name + "]"
+	}
+	// only the owner can read it
+	p.Cwd, _ = os.Readlink(filepath.Join(fs.Root, dir, "cwd"))
+	return
+}
+
+// parseStat reads the name, the state, the CPU time, the start time and the RSS from /proc/[pid]/stat
+func (p *Process) parseStat(stat string) (err error) {
+	// "1234 (name with spaces) S 1 ..." the name can have any character
+	open, close := strings.Index(stat, "("), strings.LastIndex(stat, ")")
+	if open < 0 || close < open {
+		return errors.New("invalid stat of the process " + strconv.Itoa(p.PID))
+	}
+	p.Name = stat[open+1 : close]
+	// the fields from the 3rd one, the state
+	fields := strings.Fields(stat[close+1:])
+	if len(fields) < 22 {
+		return errors.New("invalid stat of the process " + strconv.Itoa(p.PID))
+	}
+	p.State = fields[0]
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return
+	}
+	p.Ticks = utime + stime
+	if p.Start, err = strconv.ParseUint(fields[19], 10, 64); err != nil {
+		return
+	}
+	rss, err := strconv.ParseInt(fields[21], 10, 64)
+	if err != nil {
+		return
+	}
+	if rss > 0 {
+		p.RSS = uint64(rss) * uint64(os.Getpagesize())
+	}
+	return
+}
+
+// Signal is a signal which can be sent to a process
+type Signal struct {
+	Name   string
+	Signal os.Signal
+}
+
+// Signal sends the signal to the process read before, the pid is read again just before
+// and nothing is sent if it's another process which reused the pid
+func (fs *FS) Signal(p *Process, sig os.Signal) (err error) {
+	current, err := fs.Process(p.PID)
+	if err != nil || current.Start != p.Start || current.Cmdline != p.Cmdline {
+		return errors.New("the process " + strconv.Itoa(p.PID) + " has exited")
+	}
+	return Kill(p.PID, sig)
+}
+
+// Kill sends the signal to the process
+func Kill(pid int, sig os.Signal) (err error) {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return
+	}
+	return p.Signal(sig)
+}
@@ -0,0 +1,227 @@
+package system
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultRoot is where procfs is mounted
+const DefaultRoot = "/proc"
+
+// FS reads the stats of the host from procfs and sysfs,
+// Root is replaced with a directory of fixture files in the tests
+type FS struct {
+	Root    string
+	SysRoot string // where sysfs is mounted, "sys" next to Root
+}
+
+// NewFS constructs a FS of procfs at root, DefaultRoot is used if it's empty
+func NewFS(root string) *FS {
+	if root == "" {
+		root = DefaultRoot
+	}
+	return &FS{Root: root, SysRoot: filepath.Join(filepath.Dir(root), "sys")}
+}
+
+// CPUTimes is the time which the CPUs spent since the boot in USER_HZ
+type CPUTimes struct {
+	Total uint64
+	Idle  uint64 // idle and iowait
+	Count int    // the number of the CPUs
+}
+
+// Usage returns the percentage of the busy time from the previous times
+func (c *CPUTimes) Usage(prev *CPUTimes) float64 {
+	total := float64(c.Total) - float64(prev.Total)
+	idle := float64(c.Idle) - float64(prev.Idle)
+	if total <= 0 || idle > total {
+		return 0
+	}
+	return (total - idle) / total * 100
+}
+
+// Memory is the usage of the memory in bytes
+type Memory struct {
+	Total     uint64
+	Available uint64
+}
+
+// Used returns the bytes which can't be reclaimed
+func (m *Memory) Used() uint64 {
+	if m.Available > m.Total {
+		return 0
+	}
+	return m.Total - m.Available
+}
+
+// LoadAvg is the load averages of 1, 5 and 15 minutes
+type LoadAvg struct {
+	Load1  float64
+	Load5  float64
+	Load15 float64
+}
+
+// NetDev is the bytes which an interface received and sent
+type NetDev struct {
+	RxBytes uint64
+	TxBytes uint64
+	Virtual bool // the loopback, a bridge, a veth or a tunnel which has no device
+}
+
+// CPU reads the total of the CPU times from /proc/stat
+func (fs *FS) CPU() (c *CPUTimes, err error) {
+	lines, err := fs.readLines("stat")
+	if err != nil {
+		return
+	}
+	count := 0
+	for _, l := range lines {
+		fields := strings.Fields(l)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		if fields[0] != "cpu" {
+			count++
+			continue
+		}
+		// cpu user nice system idle iowait irq softirq steal guest guest_nice
+		if len(fields) < 5 {
+			return nil, errors.New("invalid cpu line of /proc/stat: " + l)
+		}
+		c = new(CPUTimes)
+		for i, f := range fields[1:] {
+			// guest and guest_nice are included in user and nice
+			if i >= 8 {
+				break
+			}
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			c.Total += v
+			if i == 3 || i == 4 {
+				c.Idle += v
+			}
+		}
+	}
+	if c == nil {
+		return nil, errors.New("no cpu line in /proc/stat")
+	}
+	c.Count = count
+	return
+}
+
+// Memory reads the total and the available memory from /proc/meminfo
+func (fs *FS) Memory() (m *Memory, err error) {
+	lines, err := fs.readLines("meminfo")
+	if err != nil {
+		return
+	}
+	info := map[string]uint64{}
+	for _, l := range lines {
+		// "MemTotal:       16314520 kB"
+		fields := strings.Fields(strings.Replace(l, ":", " ", 1))
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 2 && fields[2] == "kB" {
+			v *= 1024
+		}
+		info[fields[0]] = v
+	}
+	total, ok := info["MemTotal"]
+	if !ok {
+		return nil, errors.New("no MemTotal in /proc/meminfo")
+	}
+	m = &Memory{Total: total}
+	if available, ok := info["MemAvailable"]; ok {
+		m.Available = available
+	} else {
+		// the kernels before 3.14
+		m.Available = info["MemFree"] + info["Buffers"] + info["Cached"]
+	}
+	return
+}
+
+// LoadAvg reads the load averages from /proc/loadavg
+func (fs *FS) LoadAvg() (l *LoadAvg, err error) {
+	lines, err := fs.readLines("loadavg")
+	if err != nil {
+		return
+	}
+	var fields []string
+	if len(lines) > 0 {
+		fields = strings.Fields(lines[0])
+	}
+	if len(fields) < 3 {
+		return nil, errors.New("invalid /proc/loadavg")
+	}
+	var loads [3]float64
+	for i := range loads {
+		if loads[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return nil, err
+		}
+	}
+	return &LoadAvg{Load1: loads[0], Load5: loads[1], Load15: loads[2]}, nil
+}
+
+// NetDev reads the bytes of the network interfaces from /proc/net/dev,
+// an interface is virtual if /sys/class/net/[name]/device doesn't exist, or it's lo without sysfs
+func (fs *FS) NetDev() (devs map[string]NetDev, err error) {
+	lines, err := fs.readLines(filepath.Join("net", "dev"))
+	if err != nil {
+		return
+	}
+	classNet := filepath.Join(fs.SysRoot, "class", "net")
+	_, serr := os.Stat(classNet)
+	hasSys := serr == nil
+	devs = map[string]NetDev{}
+	for _, l := range lines {
+		// "  eth0: 1234 ..." after the 2 lines of the header
+		i := strings.Index(l, ":")
+		if i < 0 {
+			continue
+		}
+		fields := strings.Fields(l[i+1:])
+		if len(fields) < 9 {
+			continue
+		}
+		var d NetDev
+		if d.RxBytes, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+			return nil, err
+		}
+		if d.TxBytes, err = strconv.ParseUint(fields[8], 10, 64); err != nil {
+			return nil, err
+		}
+		name := strings.TrimSpace(l[:i])
+		if hasSys {
+			_, serr = os.Lstat(filepath.Join(classNet, name, "device"))
+			d.Virtual = serr != nil
+		} else {
+			d.Virtual = name == "lo"
+		}
+		devs[name] = d
+	}
+	return
+}
+
+func (fs *FS) readLines(name string) (lines []string, err error) {
+	f, err := os.Open(filepath.Join(fs.Root, name))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	return lines, s.Err()
+}
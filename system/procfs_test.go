@@ -0,0 +1,127 @@
+package system
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestCPU(t *testing.T) {
+	fs := NewFS("testdata/proc")
+	c, err := fs.CPU()
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	// the guest times are not added
+	if c.Total != 10000 || c.Idle != 8500 || c.Count != 2 {
+		t.Fatalf("the times should be 10000 with 8500 idle of 2 CPUs but %+v", c)
+	}
+	prev := &CPUTimes{Total: 9000, Idle: 8000}
+	if u := c.Usage(prev); u != 50 {
+		t.Fatalf("the usage should be 50%% but %v", u)
+	}
+	if u := c.Usage(c); u != 0 {
+		t.Fatalf("the usage without the elapsed time should be 0 but %v", u)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	m, err := NewFS("testdata/proc").Memory()
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if m.Total != 8000000*1024 || m.Used() != 6000000*1024 {
+		t.Fatalf("6GB of 8GB should be used but %+v", m)
+	}
+	// MemAvailable is estimated on the old kernels
+	if m, err = NewFS("testdata/proc2").Memory(); err != nil || m.Available != 2000000*1024 {
+		t.Fatalf("the available memory should be the free, buffers and cache but %+v, error: %v", m, err)
+	}
+}
+
+func TestLoadAvg(t *testing.T) {
+	l, err := NewFS("testdata/proc").LoadAvg()
+	if err != nil || l.Load1 != 0.52 || l.Load5 != 0.78 || l.Load15 != 1.05 {
+		t.Fatalf("the load averages should be 0.52 0.78 1.05 but %+v, error: %v", l, err)
+	}
+	if _, err = NewFS("testdata/proc2").LoadAvg(); err == nil {
+		t.Fatal("the missing file should be an error")
+	}
+}
+
+func TestNetDev(t *testing.T) {
+	devs, err := NewFS("testdata/proc").NetDev()
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if len(devs) != 5 || devs["eth0"].RxBytes != 5000000 || devs["eth0"].TxBytes != 2000000 || devs["wlan0"].RxBytes != 1000000 {
+		t.Fatalf("the bytes of all the interfaces should be read but %+v", devs)
+	}
+	// eth0 and wlan0 have the devices in sysfs
+	for name, virtual := range map[string]bool{"lo": true, "eth0": false, "wlan0": false, "docker0": true, "veth1a2b3c": true} {
+		if devs[name].Virtual != virtual {
+			t.Fatalf("%s should be virtual: %v but %+v", name, virtual, devs[name])
+		}
+	}
+	// only lo is virtual without sysfs
+	devs, err = (&FS{Root: "testdata/proc", SysRoot: "testdata/not_found"}).NetDev()
+	if err != nil || !devs["lo"].Virtual || devs["docker0"].Virtual {
+		t.Fatalf("only lo should be virtual without sysfs but %+v, %v", devs, err)
+	}
+}
+
+func TestDisk(t *testing.T) {
+	d, err := Disk(".")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if d.Total == 0 || d.Used > d.Total || d.Percent() < 0 || d.Percent() > 100 {
+		t.Fatalf("the usage of the filesystem should be read but %+v", d)
+	}
+}
+
+func TestProcesses(t *testing.T) {
+	ps, err := NewFS("testdata/proc").Processes()
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if len(ps) != 3 {
+		t.Fatalf("3 processes should be read but %d", len(ps))
+	}
+	byPID := map[int]*Process{}
+	for _, p := range ps {
+		byPID[p.PID] = p
+	}
+	if p := byPID[1]; p == nil || p.UID != 0 || p.Cmdline != "/sbin/init splash" || p.Ticks != 200 || p.State != "S" {
+		t.Fatalf("init should be read but %+v", p)
+	}
+	// the name can have spaces and parentheses
+	p := byPID[4242]
+	if p == nil || p.Name != "node (dev) x" || p.UID != 1000 || p.Cmdline != "node server.js --port 3000" || p.Cwd != "/home/dev/app" {
+		t.Fatalf("the dev server should be read but %+v", p)
+	}
+	if p.Ticks != 1000 || p.Start != 500 || p.RSS != 25600*uint64(os.Getpagesize()) {
+		t.Fatalf("the CPU time, the start time and the RSS should be read but %+v", p)
+	}
+	if p := byPID[77]; p == nil || p.Cmdline != "[kworker/0:1]" || p.RSS != 0 || p.Cwd != "" {
+		t.Fatalf("a kernel thread should be named by the brackets but %+v", p)
+	}
+	if _, err = NewFS("testdata/proc").Process(99); err == nil {
+		t.Fatal("a missing process should be an error")
+	}
+}
+
+func TestSignal(t *testing.T) {
+	fs := NewFS("testdata/proc")
+	// the pid was reused by another process, or the process has exited
+	for _, p := range []*Process{
+		{PID: 4242, Start: 400, Cmdline: "node server.js --port 3000"},
+		{PID: 4242, Start: 500, Cmdline: "node worker.js"},
+		{PID: 99, Start: 500, Cmdline: "node server.js --port 3000"},
+	} {
+		if err := fs.Signal(p, syscall.Signal(0)); err == nil || !strings.Contains(err.Error(), "has exited") {
+			t.Fatalf("the signal should not be sent to %+v but %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package system
+
+import "syscall"
+
+// Signals are the signals which can be sent to a process, the first one is the default
+var Signals = []Signal{
+	{Name: "TERM", Signal: syscall.SIGTERM},
+	{Name: "KILL", Signal: syscall.SIGKILL},
+	{Name: "INT", Signal: syscall.SIGINT},
+	{Name: "HUP", Signal: syscall.SIGHUP},
+	{Name: "STOP", Signal: syscall.SIGSTOP},
+	{Name: "CONT", Signal: syscall.SIGCONT},
+	{Name: "USR1", Signal: syscall.SIGUSR1},
+	{Name: "USR2", Signal: syscall.SIGUSR2},
+}
@@ -0,0 +1,8 @@
+package system
+
+import "os"
+
+// Signals are the signals which can be sent to a process, only killing is supported on Windows
+var Signals = []Signal{
+	{Name: "KILL", Signal: os.Kill},
+}
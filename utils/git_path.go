@@ -2,27 +2,140 @@ package utils
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 )
 
-// GetDotGitPath returns the .git path from the arg path
+// GitPaths is the locations of a repository found by FindGitRepository
+type GitPaths struct {
+	WorkTree  string // the root of the worktree
+	GitDir    string // the .git directory, or the one which a .git file points to
+	CommonDir string // the directory shared by the linked worktrees, the same as GitDir if the worktree is not linked
+}
+
+// NotGitRepositoryError is returned when no repository includes the path
+type NotGitRepositoryError struct {
+	Path string
+}
+
+func (e *NotGitRepositoryError) Error() string {
+	return "not a git repository (or any of the parent directories): " + e.Path
+}
+
+// IsNotGitRepository tells if the error is a NotGitRepositoryError
+func IsNotGitRepository(err error) bool {
+	_, ok := err.(*NotGitRepositoryError)
+	return ok
+}
+
+// GetDotGitPath returns the root of the worktree which includes the arg path
 func GetDotGitPath(path string) (result string, err error) {
+	p, err := FindGitRepository(path)
+	if err != nil {
+		return
+	}
+	return p.WorkTree, nil
+}
+
+// FindGitRepository finds the repository which includes the path like git does.
+// .git may be a directory or a file pointing to the git dir like in a linked worktree or a submodule,
+// and GIT_DIR and GIT_WORK_TREE override the discovery, a relative one is from the current directory
+func FindGitRepository(path string) (p *GitPaths, err error) {
 	dir, err := filepath.Abs(path)
-	files, err := ioutil.ReadDir(dir)
 	if err != nil {
-		dir = filepath.Dir(path)
-		return GetDotGitPath(dir)
+		return
+	}
+	if fi, err := os.Stat(dir); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(dir)
 	}
 
-	for _, file := range files {
-		if file.IsDir() && file.Name() == ".git" {
-			return dir, err
+	if env := os.Getenv("GIT_DIR"); env != "" {
+		p = &GitPaths{WorkTree: dir}
+		if p.GitDir, err = filepath.Abs(env); err != nil {
+			return nil, err
+		}
+		if !isGitDir(p.GitDir) {
+			return nil, &NotGitRepositoryError{Path: env}
 		}
+		if env := os.Getenv("GIT_WORK_TREE"); env != "" {
+			if p.WorkTree, err = filepath.Abs(env); err != nil {
+				return nil, err
+			}
+		}
+		p.CommonDir, err = readCommonDir(p.GitDir)
+		return
 	}
 
-	if dir != "/" {
-		dir = filepath.Dir(dir)
-		return GetDotGitPath(dir)
+	for d := dir; ; d = filepath.Dir(d) {
+		gitDir, err := resolveDotGit(filepath.Join(d, ".git"))
+		if err != nil {
+			return nil, err
+		}
+		if gitDir != "" {
+			p = &GitPaths{WorkTree: d, GitDir: gitDir}
+			p.CommonDir, err = readCommonDir(gitDir)
+			return p, err
+		}
+		if d == filepath.Dir(d) {
+			return nil, &NotGitRepositoryError{Path: path}
+		}
 	}
-	return result, err
+}
+
+// resolveDotGit returns the git dir of .git, empty if it isn't a repository
+func resolveDotGit(dotGit string) (gitDir string, err error) {
+	fi, err := os.Stat(dotGit)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return
+	}
+	if fi.IsDir() {
+		if isGitDir(dotGit) {
+			gitDir = dotGit
+		}
+		return
+	}
+	// a .git file is like "gitdir: ../.git/worktrees/name"
+	b, err := ioutil.ReadFile(dotGit)
+	if err != nil {
+		return
+	}
+	line := strings.TrimSpace(strings.SplitN(string(b), "\n", 2)[0])
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", &NotGitRepositoryError{Path: dotGit}
+	}
+	gitDir = strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(dotGit), gitDir)
+	}
+	if !isGitDir(gitDir) {
+		return "", &NotGitRepositoryError{Path: gitDir}
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// readCommonDir returns the directory in the commondir file of the git dir of a linked worktree,
+// the git dir itself if it doesn't exist
+func readCommonDir(gitDir string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir"))
+	if os.IsNotExist(err) {
+		return gitDir, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	dir := strings.TrimSpace(string(b))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
+// isGitDir tells if the directory has HEAD like a git dir
+func isGitDir(dir string) bool {
+	fi, err := os.Stat(filepath.Join(dir, "HEAD"))
+	return err == nil && !fi.IsDir()
 }
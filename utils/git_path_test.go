@@ -1,13 +1,122 @@
 package utils
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
+// newTestGitDir makes a directory which looks like a git dir
+func newTestGitDir(t *testing.T, dir string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "HEAD"), []byte("ref: refs/heads/master\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestGetDotGitPath(t *testing.T) {
-	result, err := GetDotGitPath("/Users/tamurayoshiya/Sites/go/src/github.com/qmu/mcc/utils")
+	root, err := ioutil.TempDir("", "mcc-utils")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+	newTestGitDir(t, filepath.Join(root, ".git"))
+	os.MkdirAll(filepath.Join(root, "a", "b"), 0755)
+	ioutil.WriteFile(filepath.Join(root, "a", "file"), nil, 0644)
+
+	for _, path := range []string{root, filepath.Join(root, "a", "b"), filepath.Join(root, "a", "file")} {
+		result, err := GetDotGitPath(path)
+		if err != nil {
+			t.Fatalf("Get error: %v", err)
+		}
+		if result != root {
+			t.Fatalf("%s should be found from %s but %s", root, path, result)
+		}
+	}
+}
+
+func TestFindGitRepository(t *testing.T) {
+	root, err := ioutil.TempDir("", "mcc-utils")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+	main := filepath.Join(root, "main")
+	newTestGitDir(t, filepath.Join(main, ".git"))
+
+	// a linked worktree made by "git worktree add ../linked"
+	linked := filepath.Join(root, "linked")
+	newTestGitDir(t, filepath.Join(main, ".git", "worktrees", "linked"))
+	ioutil.WriteFile(filepath.Join(main, ".git", "worktrees", "linked", "commondir"), []byte("../..\n"), 0644)
+	os.MkdirAll(filepath.Join(linked, "src"), 0755)
+	ioutil.WriteFile(filepath.Join(linked, ".git"), []byte("gitdir: "+filepath.Join(main, ".git", "worktrees", "linked")+"\n"), 0644)
+
+	// a submodule with a relative gitdir
+	sub := filepath.Join(main, "lib")
+	newTestGitDir(t, filepath.Join(main, ".git", "modules", "lib"))
+	os.MkdirAll(sub, 0755)
+	ioutil.WriteFile(filepath.Join(sub, ".git"), []byte("gitdir: ../.git/modules/lib\n"), 0644)
+
+	tests := []struct {
+		path     string
+		expected GitPaths
+	}{
+		{main, GitPaths{WorkTree: main, GitDir: filepath.Join(main, ".git"), CommonDir: filepath.Join(main, ".git")}},
+		{filepath.Join(linked, "src"), GitPaths{WorkTree: linked, GitDir: filepath.Join(main, ".git", "worktrees", "linked"), CommonDir: filepath.Join(main, ".git")}},
+		{sub, GitPaths{WorkTree: sub, GitDir: filepath.Join(main, ".git", "modules", "lib"), CommonDir: filepath.Join(main, ".git", "modules", "lib")}},
+	}
+	for _, test := range tests {
+		p, err := FindGitRepository(test.path)
+		if err != nil {
+			t.Fatalf("Get error from %s: %v", test.path, err)
+		}
+		if !reflect.DeepEqual(*p, test.expected) {
+			t.Fatalf("%+v should be found from %s but %+v", test.expected, test.path, *p)
+		}
+	}
+
+	// a broken .git file
+	broken := filepath.Join(root, "broken")
+	os.MkdirAll(broken, 0755)
+	ioutil.WriteFile(filepath.Join(broken, ".git"), []byte("gitdir: ../nowhere\n"), 0644)
+	if _, err = FindGitRepository(broken); !IsNotGitRepository(err) {
+		t.Fatalf("not a git repository error should be returned from %s but %v", broken, err)
+	}
+}
+
+func TestFindGitRepositoryWithEnv(t *testing.T) {
+	root, err := ioutil.TempDir("", "mcc-utils")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+	gitDir := filepath.Join(root, "repo.git")
+	newTestGitDir(t, gitDir)
+	workTree := filepath.Join(root, "work")
+	os.MkdirAll(workTree, 0755)
+
+	defer os.Unsetenv("GIT_DIR")
+	defer os.Unsetenv("GIT_WORK_TREE")
+	os.Setenv("GIT_DIR", gitDir)
+	os.Setenv("GIT_WORK_TREE", workTree)
+	p, err := FindGitRepository(root)
 	if err != nil {
 		t.Fatalf("Get error: %v", err)
 	}
-	t.Log(result)
+	expected := GitPaths{WorkTree: workTree, GitDir: gitDir, CommonDir: gitDir}
+	if !reflect.DeepEqual(*p, expected) {
+		t.Fatalf("%+v should be found but %+v", expected, *p)
+	}
+
+	os.Setenv("GIT_DIR", filepath.Join(root, "nowhere"))
+	if _, err = FindGitRepository(root); !IsNotGitRepository(err) {
+		t.Fatalf("not a git repository error should be returned but %v", err)
+	}
 }
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"path/filepath"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// ResolvePath expands "~" of the path and joins it to base if it's relative
+func ResolvePath(base string, path string) string {
+	expanded, err := homedir.Expand(path)
+	if err != nil || expanded == "" || filepath.IsAbs(expanded) {
+		return expanded
+	}
+	return filepath.Join(base, expanded)
+}
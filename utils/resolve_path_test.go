@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+func TestResolvePath(t *testing.T) {
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Skip(err)
+	}
+	cases := []struct {
+		path     string
+		expected string
+	}{
+		{"", ""},
+		{"/srv/api", "/srv/api"},
+		{"../api", "/work/api"},
+		{"~/api", filepath.Join(home, "api")},
+	}
+	for _, c := range cases {
+		if p := ResolvePath("/work/mcc", c.path); p != c.expected {
+			t.Fatalf("%q should be resolved to %q but %q", c.path, c.expected, p)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// NewShellCommand builds a command which runs a (multi-line) command by "sh -c"
+// with the envs of the config
+func NewShellCommand(command string, envs []map[string]string) (cmd *exec.Cmd) {
+	cmd = exec.Command("sh", "-c", StraightenCommand(command))
+	cmd.Env = os.Environ()
+	for _, env := range envs {
+		cmd.Env = append(cmd.Env, env["name"]+"="+env["value"])
+	}
+	return
+}
+
+// StartKillable starts a command in its own process group and kills the whole group if ctx is done
+// before the returned wait returns. don't use it for commands which read the terminal
+func StartKillable(ctx context.Context, cmd *exec.Cmd) (wait func() error, err error) {
+	setProcessGroup(cmd)
+	if err = cmd.Start(); err != nil {
+		return
+	}
+	p := cmd.Process
+	var mutex sync.Mutex
+	waited := false
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			mutex.Lock()
+			// the pid can be reused once the process is reaped
+			if !waited {
+				killProcessGroup(p)
+			}
+			mutex.Unlock()
+		case <-done:
+		}
+	}()
+	wait = func() error {
+		// the exited process isn't reaped until waited is set under the lock,
+		// so its pid can't be reused while the group may be killed
+		waitExited(p)
+		mutex.Lock()
+		waited = true
+		mutex.Unlock()
+		close(done)
+		return cmd.Wait()
+	}
+	return
+}
+
+// RunKillable runs a command like exec.Cmd.Run, the whole process group is killed if ctx is done
+func RunKillable(ctx context.Context, cmd *exec.Cmd) error {
+	wait, err := StartKillable(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	return wait()
+}
+
+// StraightenCommand joins the lines of a multi-line command by "; "
+func StraightenCommand(command string) (result string) {
+	for _, c := range strings.Split(command, "\n") {
+		if c != "" {
+			result = result + c + "; "
+		}
+	}
+	return
+}
+
+// ExitCode returns the exit status of a finished command
+func ExitCode(cmd *exec.Cmd, err error) int {
+	if cmd.ProcessState != nil {
+		if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok {
+			return ws.ExitStatus()
+		}
+	}
+	if err != nil {
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,30 @@
+//go:build linux
+// +build linux
+
+package utils
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	waitidPID     = 1
+	waitidExited  = 0x4
+	waitidNoWait  = 0x1000000
+	siginfoLength = 128
+)
+
+// waitExited blocks until the process exits but leaves it unreaped,
+// the zombie keeps its pid until exec.Cmd.Wait reaps it
+func waitExited(p *os.Process) {
+	var info [siginfoLength]byte
+	for {
+		_, _, errno := syscall.Syscall6(syscall.SYS_WAITID, waitidPID, uintptr(p.Pid),
+			uintptr(unsafe.Pointer(&info[0])), waitidExited|waitidNoWait, 0, 0)
+		if errno != syscall.EINTR {
+			return
+		}
+	}
+}
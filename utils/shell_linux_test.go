@@ -0,0 +1,30 @@
+//go:build linux
+// +build linux
+
+package utils
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWaitExited(t *testing.T) {
+	cmd := NewShellCommand("exit 3", nil)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitExited(cmd.Process)
+	// the process is a zombie which still holds the pid
+	stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/stat")
+	if err != nil {
+		t.Fatalf("the process should not be reaped but %v", err)
+	}
+	if fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:])); fields[0] != "Z" {
+		t.Fatalf("the process should be a zombie but %s", fields[0])
+	}
+	if err = cmd.Wait(); ExitCode(cmd, err) != 3 {
+		t.Fatalf("the exit code should be reaped by Wait but %v", err)
+	}
+}
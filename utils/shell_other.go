@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package utils
+
+import "os"
+
+// waitExited returns at once where the process can't be waited without being reaped,
+// exec.Cmd.Wait blocks instead
+func waitExited(p *os.Process) {
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunKillable(t *testing.T) {
+	if err := RunKillable(context.Background(), NewShellCommand("true", nil)); err != nil {
+		t.Fatalf("the command should succeed but %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	// the child of the shell is killed together
+	err := RunKillable(ctx, NewShellCommand("sleep 10 & sleep 10", nil))
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Fatalf("the command should be killed after the timeout but %v in %v", err, time.Since(start))
+	}
+	wait, err := StartKillable(context.Background(), NewShellCommand("exit 3", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = wait(); err == nil {
+		t.Fatal("the exit code should be an error")
+	}
+}
@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+func killProcessGroup(p *os.Process) {
+	p.Kill()
+}
@@ -0,0 +1,120 @@
+package widget
+
+import (
+	"strings"
+
+	"github.com/qmu/mcc/widget/listable"
+)
+
+const diffScrollStep = 8
+
+// diffPane shows a unified diff with colors in a popup,
+// n/N jump to the next/previous hunk and h/l scroll it horizontally
+type diffPane struct {
+	popup  *listable.Popup
+	lines  []string
+	offset int
+}
+
+// newDiffPane constructs a diffPane of the text of "git diff"
+func newDiffPane(title string, text string) (d *diffPane) {
+	d = new(diffPane)
+	text = strings.Replace(strings.TrimSuffix(text, "\n"), "\t", "    ", -1)
+	if text == "" {
+		text = "no difference"
+	}
+	d.lines = strings.Split(text, "\n")
+	d.popup = listable.NewPopup(&listable.PopupOption{
+		Title:         title + " (n/N: next/previous hunk, h/l: scroll)",
+		LineHighLight: true,
+	})
+	d.popup.Handle("n", func() {
+		d.jump(1)
+	})
+	d.popup.Handle("N", func() {
+		d.jump(-1)
+	})
+	for _, key := range []string{"l", "<right>"} {
+		d.popup.Handle(key, func() {
+			d.scroll(diffScrollStep)
+		})
+	}
+	for _, key := range []string{"h", "<left>"} {
+		d.popup.Handle(key, func() {
+			d.scroll(-diffScrollStep)
+		})
+	}
+	return
+}
+
+// Open renders the pane, onClose is called after it's closed
+func (d *diffPane) Open(onClose func()) {
+	d.render()
+	d.popup.Open(onClose)
+}
+
+func (d *diffPane) render() {
+	body := make([]string, len(d.lines))
+	header := true
+	for i, l := range d.lines {
+		if strings.HasPrefix(l, "@@") {
+			header = false
+		}
+		body[i] = " " + colorDiffLine(l, d.offset, header)
+	}
+	d.popup.SetBody(body)
+}
+
+func (d *diffPane) jump(direction int) {
+	if i := nextHunk(d.lines, d.popup.GetCursor(), direction); i >= 0 {
+		d.popup.SetCursor(i)
+	}
+}
+
+func (d *diffPane) scroll(n int) {
+	longest := 0
+	for _, l := range d.lines {
+		if w := len([]rune(l)); w > longest {
+			longest = w
+		}
+	}
+	d.offset += n
+	if d.offset > longest-diffScrollStep {
+		d.offset = longest - diffScrollStep
+	}
+	if d.offset < 0 {
+		d.offset = 0
+	}
+	d.render()
+}
+
+// nextHunk returns the index of the next "@@" line in the direction from the cursor, -1 if there is no more
+func nextHunk(lines []string, cursor int, direction int) int {
+	for i := cursor + direction; i >= 0 && i < len(lines); i += direction {
+		if strings.HasPrefix(lines[i], "@@") {
+			return i
+		}
+	}
+	return -1
+}
+
+// colorDiffLine colors a line of a diff scrolled by offset runes,
+// header is true for the lines before the first hunk
+func colorDiffLine(line string, offset int, header bool) string {
+	runes := []rune(line)
+	if offset >= len(runes) {
+		return ""
+	}
+	text := escapeMarkup(string(runes[offset:]))
+	switch {
+	case header:
+		return "[" + text + "](fg-bold)"
+	case strings.HasPrefix(line, "@@"):
+		return "[" + text + "](fg-cyan)"
+	case strings.HasPrefix(line, "+"):
+		return "[" + text + "](fg-green)"
+	case strings.HasPrefix(line, "-"):
+		return "[" + text + "](fg-red)"
+	}
+	return text
+}
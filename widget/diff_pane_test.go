@@ -0,0 +1,45 @@
+package widget
+
+import "testing"
+
+func TestNextHunk(t *testing.T) {
+	lines := []string{"diff --git a/a b/a", "--- a/a", "+++ b/a", "@@ -1 +1 @@", "-a", "+b", "@@ -9 +9 @@", "-c"}
+	cases := []struct {
+		cursor    int
+		direction int
+		expected  int
+	}{
+		{0, 1, 3},
+		{3, 1, 6},
+		{6, 1, -1},
+		{7, -1, 6},
+		{6, -1, 3},
+		{3, -1, -1},
+	}
+	for _, c := range cases {
+		if i := nextHunk(lines, c.cursor, c.direction); i != c.expected {
+			t.Fatalf("the hunk next to %d in %d should be %d but %d", c.cursor, c.direction, c.expected, i)
+		}
+	}
+}
+
+func TestColorDiffLine(t *testing.T) {
+	cases := []struct {
+		line     string
+		offset   int
+		header   bool
+		expected string
+	}{
+		{"--- a/main.go", 0, true, "[--- a/main.go](fg-bold)"},
+		{"@@ -1,2 +1,3 @@", 0, false, "[@@ -1,2 +1,3 @@](fg-cyan)"},
+		{"+\tx := []int{1}", 0, false, "[+\tx := ()int{1}](fg-green)"},
+		{"-removed", 3, false, "[moved](fg-red)"},
+		{" context", 0, false, " context"},
+		{"-short", 10, false, ""},
+	}
+	for _, c := range cases {
+		if s := colorDiffLine(c.line, c.offset, c.header); s != c.expected {
+			t.Fatalf("%q should be colored as %q but %q", c.line, c.expected, s)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package widget
+
+import (
+	"strconv"
+	"sync"
+
+	ui "github.com/gizak/termui"
+)
+
+// minGaugeHeight is the height of a gauge with the borders and a line of the bar
+const minGaugeHeight = 3
+
+// gaugeStack stacks the gauges in a cell of the grid,
+// unlike the cells the gauges can be added and removed after the grid is laid out.
+// the rows which don't fit are scrolled to keep the one under the cursor shown
+type gaugeStack struct {
+	x, y   int
+	width  int
+	height int
+	rows   []stackRow
+	cursor int
+	offset int // the index of the first row shown
+	mutex  sync.Mutex
+}
+
+// stackRow is a gauge with the optional sparkline on the right half
+type stackRow struct {
+	gauge *ui.Gauge
+	spark *sparkline
+}
+
+func newGaugeStack(width int, height int) *gaugeStack {
+	return &gaugeStack{width: width, height: height}
+}
+
+// SetRows replaces the rows shown from the next render
+func (s *gaugeStack) SetRows(rows []stackRow) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rows = rows
+}
+
+// SetCursor scrolls the rows to show the row of the index from the next render
+func (s *gaugeStack) SetCursor(i int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cursor = i
+}
+
+// layout divides the height by the rows which fit in it and returns them,
+// above and below are the numbers of the rows hidden before and after them
+func (s *gaugeStack) layout() (shown []stackRow, above int, below int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	max := s.height / minGaugeHeight
+	if max < 1 {
+		max = 1
+	}
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if s.cursor >= s.offset+max {
+		s.offset = s.cursor - max + 1
+	}
+	if s.offset > len(s.rows)-max {
+		s.offset = len(s.rows) - max
+	}
+	if s.offset < 0 {
+		s.offset = 0
+	}
+	shown = s.rows[s.offset:]
+	if len(shown) > max {
+		shown = shown[:max]
+	}
+	above = s.offset
+	below = len(s.rows) - s.offset - len(shown)
+	l := len(shown)
+	y := s.y
+	for i, r := range shown {
+		g := r.gauge
+		g.X = s.x
+		g.Y = y
+		g.Width = s.width
+		if i == l-1 {
+			// the last one fills the rest
+			g.Height = s.height - (s.height/l)*(l-1)
+		} else {
+			g.Height = s.height / l
+		}
+		if r.spark != nil {
+			g.Width = s.width / 2
+			r.spark.x = s.x + g.Width
+			r.spark.y = y
+			r.spark.width = s.width - g.Width
+			r.spark.height = g.Height
+		}
+		y += g.Height
+	}
+	return
+}
+
+// Buffer implements ui.Bufferer
+func (s *gaugeStack) Buffer() ui.Buffer {
+	buf := ui.NewBuffer()
+	shown, above, below := s.layout()
+	for _, r := range shown {
+		buf.Merge(r.gauge.Buffer())
+		if r.spark != nil {
+			buf.Merge(r.spark.Buffer())
+		}
+	}
+	if len(shown) == 0 {
+		return buf
+	}
+	// the hidden rows are told on the top border of the first gauge and the bottom border of the last one
+	if above > 0 {
+		s.mark(buf, shown[0].gauge.Y, above)
+	}
+	if below > 0 {
+		last := shown[len(shown)-1].gauge
+		s.mark(buf, last.Y+last.Height-1, below)
+	}
+	return buf
+}
+
+// mark writes "+N more" on the right of the line
+func (s *gaugeStack) mark(buf ui.Buffer, y int, hidden int) {
+	text := []rune(" +" + strconv.Itoa(hidden) + " more ")
+	x := s.x + s.width - len(text) - 1
+	for i, ch := range text {
+		if x+i > s.x {
+			buf.Set(x+i, y, ui.Cell{Ch: ch, Fg: ui.ColorYellow, Bg: ui.ColorDefault})
+		}
+	}
+}
+
+// GetHeight implements ui.GridBufferer
+func (s *gaugeStack) GetHeight() int {
+	return s.height
+}
+
+// SetWidth implements ui.GridBufferer
+func (s *gaugeStack) SetWidth(w int) {
+	s.width = w
+}
+
+// SetX implements ui.GridBufferer
+func (s *gaugeStack) SetX(x int) {
+	s.x = x
+}
+
+// SetY implements ui.GridBufferer
+func (s *gaugeStack) SetY(y int) {
+	s.y = y
+}
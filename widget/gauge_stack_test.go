@@ -0,0 +1,64 @@
+package widget
+
+import (
+	"testing"
+
+	ui "github.com/gizak/termui"
+)
+
+func TestGaugeStackLayout(t *testing.T) {
+	s := newGaugeStack(40, 10)
+	s.SetX(2)
+	s.SetY(5)
+	spark := &sparkline{}
+	s.SetRows([]stackRow{{gauge: ui.NewGauge()}, {gauge: ui.NewGauge(), spark: spark}, {gauge: ui.NewGauge()}})
+	shown, _, _ := s.layout()
+	if len(shown) != 3 {
+		t.Fatalf("3 gauges should be shown but %d", len(shown))
+	}
+	// 3, 3 and the rest 4
+	for i, e := range []struct{ y, h, w int }{{5, 3, 40}, {8, 3, 20}, {11, 4, 40}} {
+		if g := shown[i].gauge; g.X != 2 || g.Y != e.y || g.Height != e.h || g.Width != e.w {
+			t.Fatalf("gauge %d should be at y=%d with %dx%d but at (%d, %d) with %dx%d", i, e.y, e.w, e.h, g.X, g.Y, g.Width, g.Height)
+		}
+	}
+
+	// the sparkline is on the right half of the gauge
+	if spark.x != 22 || spark.y != 8 || spark.width != 20 || spark.height != 3 {
+		t.Fatalf("the sparkline should be beside the gauge but at (%d, %d) with %dx%d", spark.x, spark.y, spark.width, spark.height)
+	}
+
+	// the gauges which don't fit are hidden
+	rows := []stackRow{{gauge: ui.NewGauge()}, {gauge: ui.NewGauge()}, {gauge: ui.NewGauge()}, {gauge: ui.NewGauge()}, {gauge: ui.NewGauge()}}
+	s.SetRows(rows)
+	shown, above, below := s.layout()
+	if len(shown) != 3 || shown[0].gauge != rows[0].gauge || above != 0 || below != 2 {
+		t.Fatalf("only 3 gauges should fit in the height 10 but %d, %d above and %d below", len(shown), above, below)
+	}
+	buf := s.Buffer()
+	if c := buf.At(33, 14); c.Ch != '+' || buf.At(36, 14).Ch != 'm' {
+		t.Fatalf("the hidden gauges should be marked on the bottom border but %q", c.Ch)
+	}
+
+	// the gauges are scrolled to show the one under the cursor
+	s.SetCursor(3)
+	if shown, above, below = s.layout(); shown[2].gauge != rows[3].gauge || above != 1 || below != 1 {
+		t.Fatalf("the 4th gauge should be the last one shown but %d above and %d below", above, below)
+	}
+	s.SetCursor(2)
+	if shown, above, _ = s.layout(); shown[0].gauge != rows[1].gauge || above != 1 {
+		t.Fatal("the gauges should not be scrolled while the cursor is shown")
+	}
+	s.SetCursor(0)
+	if shown, above, _ = s.layout(); shown[0].gauge != rows[0].gauge || above != 0 {
+		t.Fatal("the gauges should be scrolled back to the cursor")
+	}
+	// the rows removed don't leave a blank
+	s.SetCursor(4)
+	s.layout()
+	s.SetRows(rows[:4])
+	s.SetCursor(3)
+	if shown, above, below = s.layout(); len(shown) != 3 || above != 1 || below != 0 {
+		t.Fatalf("the last 3 gauges should be shown but %d, %d above and %d below", len(shown), above, below)
+	}
+}
@@ -0,0 +1,68 @@
+package widget
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// lifecycle is embedded in the widgets which run goroutines,
+// Stop cancels the context the goroutines select on and closes the watchers they opened.
+// the zero value is ready to use
+type lifecycle struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closers []io.Closer
+	stopped bool
+	mutex   sync.Mutex
+}
+
+// closerFunc makes a function an io.Closer
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// context returns the context which is canceled by Stop
+func (l *lifecycle) context() context.Context {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.init()
+	return l.ctx
+}
+
+func (l *lifecycle) init() {
+	if l.ctx == nil {
+		l.ctx, l.cancel = context.WithCancel(context.Background())
+	}
+}
+
+// keep registers c to be closed by Stop, it's closed at once and false is returned
+// if the widget has been stopped while c was being opened
+func (l *lifecycle) keep(c io.Closer) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.stopped {
+		c.Close()
+		return false
+	}
+	l.closers = append(l.closers, c)
+	return true
+}
+
+// Stop is the implementation of Widget.Stop
+func (l *lifecycle) Stop() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.stopped {
+		return
+	}
+	l.stopped = true
+	l.init()
+	l.cancel()
+	for _, c := range l.closers {
+		c.Close()
+	}
+	l.closers = nil
+}
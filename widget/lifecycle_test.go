@@ -0,0 +1,28 @@
+package widget
+
+import "testing"
+
+func TestLifecycleStop(t *testing.T) {
+	l := new(lifecycle)
+	ctx := l.context()
+	closed := 0
+	if !l.keep(closerFunc(func() error { closed++; return nil })) {
+		t.Fatal("the watcher should be kept before the widget is stopped")
+	}
+	l.Stop()
+	l.Stop()
+	if ctx.Err() == nil {
+		t.Fatal("the context should be canceled by Stop")
+	}
+	if closed != 1 {
+		t.Fatalf("the watcher should be closed once but %d", closed)
+	}
+	if l.keep(closerFunc(func() error { closed++; return nil })) || closed != 2 {
+		t.Fatal("the watcher opened after Stop should be closed at once")
+	}
+	l = new(lifecycle)
+	l.Stop()
+	if l.context().Err() == nil {
+		t.Fatal("the context should be canceled even if the widget is stopped before it's used")
+	}
+}
@@ -0,0 +1,85 @@
+package listable
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ansiSequence = regexp.MustCompile("\x1b\\[([0-9;?]*)([A-Za-z])")
+	ansiColors   = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+)
+
+// ConvertANSI converts ANSI SGR colour sequences of a line into termui's markup
+// like "[text](fg-red,fg-bold)", the other escape sequences are removed
+func ConvertANSI(line string) (result string) {
+	if !strings.Contains(line, "\x1b") {
+		return line
+	}
+	fg, bg, bold := "", "", false
+	pos := 0
+	for _, m := range ansiSequence.FindAllStringSubmatchIndex(line, -1) {
+		result += ansiSegment(line[pos:m[0]], fg, bg, bold)
+		pos = m[1]
+		if line[m[4]:m[5]] != "m" {
+			continue
+		}
+		params := strings.Split(line[m[2]:m[3]], ";")
+		for i := 0; i < len(params); i++ {
+			n, _ := strconv.Atoi(params[i])
+			switch {
+			case n == 0:
+				fg, bg, bold = "", "", false
+			case n == 1:
+				bold = true
+			case n == 22:
+				bold = false
+			case n >= 30 && n <= 37:
+				fg = ansiColors[n-30]
+			case n >= 90 && n <= 97:
+				fg = ansiColors[n-90]
+			case n == 39:
+				fg = ""
+			case n >= 40 && n <= 47:
+				bg = ansiColors[n-40]
+			case n >= 100 && n <= 107:
+				bg = ansiColors[n-100]
+			case n == 49:
+				bg = ""
+			case n == 38 || n == 48:
+				// 256 colours and true colours are not supported by termui, skip their arguments
+				if i+1 < len(params) && params[i+1] == "5" {
+					i += 2
+				} else if i+1 < len(params) && params[i+1] == "2" {
+					i += 4
+				}
+			}
+		}
+	}
+	result += ansiSegment(line[pos:], fg, bg, bold)
+	// drop the lone escape characters which are not a sequence
+	return strings.Replace(result, "\x1b", "", -1)
+}
+
+func ansiSegment(text string, fg string, bg string, bold bool) string {
+	if text == "" {
+		return ""
+	}
+	var attrs []string
+	if fg != "" {
+		attrs = append(attrs, "fg-"+fg)
+	}
+	if bold {
+		attrs = append(attrs, "fg-bold")
+	}
+	if bg != "" {
+		attrs = append(attrs, "bg-"+bg)
+	}
+	if len(attrs) == 0 {
+		return text
+	}
+	// brackets in the text would break the markup
+	text = strings.NewReplacer("[", "(", "]", ")").Replace(text)
+	return "[" + text + "](" + strings.Join(attrs, ",") + ")"
+}
@@ -0,0 +1,24 @@
+package listable
+
+import "testing"
+
+func TestConvertANSI(t *testing.T) {
+	cases := []struct {
+		in  string
+		out string
+	}{
+		{"plain text", "plain text"},
+		{"\x1b[31mred\x1b[0m text", "[red](fg-red) text"},
+		{"\x1b[1;32mbold green\x1b[m", "[bold green](fg-green,fg-bold)"},
+		{"\x1b[94mbright\x1b[39m default", "[bright](fg-blue) default"},
+		{"\x1b[30;47minverse\x1b[0m", "[inverse](fg-black,bg-white)"},
+		{"\x1b[38;5;208morange\x1b[0m", "orange"},
+		{"\x1b[2Kcleared", "cleared"},
+		{"\x1b[33m[WARN]\x1b[0m message", "[(WARN)](fg-yellow) message"},
+	}
+	for _, c := range cases {
+		if r := ConvertANSI(c.in); r != c.out {
+			t.Fatalf("%q should be converted to %q but %q", c.in, c.out, r)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package listable
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const editorCursor = "[ ](bg-white)"
+
+// Editor is a Popup to write a multi-line text like a commit message,
+// the text is edited at its end
+type Editor struct {
+	popup    *Popup
+	text     string
+	message  string
+	onSubmit func(text string) error
+}
+
+// EditorOption is the option argument for NewEditor
+type EditorOption struct {
+	Title  string
+	Text   string
+	Width  string
+	Height string
+}
+
+// NewEditor constructs an Editor
+func NewEditor(opt *EditorOption) (e *Editor) {
+	e = new(Editor)
+	e.text = opt.Text
+	e.popup = NewPopup(&PopupOption{
+		Title:  opt.Title + " (C-s: submit, Esc: cancel)",
+		Width:  opt.Width,
+		Height: opt.Height,
+	})
+	e.popup.HandleInput(e.input)
+	e.popup.Handle("C-s", e.submit)
+	return
+}
+
+// Open renders the editor, onSubmit is called with the text by C-s.
+// the editor is closed if onSubmit returns nil, otherwise the error is shown
+func (e *Editor) Open(onSubmit func(text string) error, onClose func()) {
+	e.onSubmit = onSubmit
+	e.render()
+	e.popup.Open(onClose)
+}
+
+func (e *Editor) submit() {
+	if err := e.onSubmit(e.text); err != nil {
+		e.message = err.Error()
+		e.render()
+		return
+	}
+	e.popup.Close()
+}
+
+func (e *Editor) input(key string) {
+	e.text = editText(e.text, key)
+	e.message = ""
+	e.render()
+}
+
+func (e *Editor) render() {
+	lines := strings.Split(e.text, "\n")
+	for i, l := range lines {
+		lines[i] = " " + strings.NewReplacer("[", "(", "]", ")").Replace(l)
+	}
+	lines[len(lines)-1] += editorCursor
+	if e.message != "" {
+		lines = append(lines, "", " ["+e.message+"](fg-red)")
+	}
+	e.popup.SetBody(lines)
+}
+
+// editText applies a key of termui to the end of the text
+func editText(text string, key string) string {
+	switch key {
+	case "<enter>":
+		return text + "\n"
+	case "<space>":
+		return text + " "
+	case "<tab>":
+		return text + "\t"
+	case "<backspace>", "C-8", "C-h":
+		if text == "" {
+			return text
+		}
+		_, size := utf8.DecodeLastRuneInString(text)
+		return text[:len(text)-size]
+	}
+	// a printable character, the other keys like "C-a" or "<up>" are ignored
+	if utf8.RuneCountInString(key) == 1 {
+		return text + key
+	}
+	return text
+}
@@ -0,0 +1,27 @@
+package listable
+
+import "testing"
+
+func TestEditText(t *testing.T) {
+	text := ""
+	for _, key := range []string{"F", "i", "x", "<space>", "[", "q", "]", "<enter>", "あ", "<up>", "C-a", "<backspace>", "<enter>", "b"} {
+		text = editText(text, key)
+	}
+	if text != "Fix [q]\n\nb" {
+		t.Fatalf("the text should be edited but %q", text)
+	}
+	text = editText(editText(editText("", "C-8"), "é"), "C-8")
+	if text != "" {
+		t.Fatalf("a multi-byte character should be deleted but %q", text)
+	}
+}
+
+func TestEditLine(t *testing.T) {
+	text := ""
+	for _, key := range []string{"n", "o", "<enter>", "<tab>", "d", "<backspace>", "<space>", "d", "e", "v"} {
+		text = editLine(text, key)
+	}
+	if text != "no dev" {
+		t.Fatalf("the line should be edited without a line break but %q", text)
+	}
+}
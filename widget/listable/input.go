@@ -0,0 +1,78 @@
+package listable
+
+import "strings"
+
+// Input is a Popup to write a line like a filter,
+// the text is passed to onChange on every key
+type Input struct {
+	popup    *Popup
+	text     string
+	original string
+	onChange func(text string)
+}
+
+// InputOption is the option argument for NewInput
+type InputOption struct {
+	Title  string
+	Text   string
+	Width  string
+	Height string
+}
+
+// NewInput constructs an Input
+func NewInput(opt *InputOption) (i *Input) {
+	i = new(Input)
+	i.text = opt.Text
+	i.original = opt.Text
+	if opt.Height == "" {
+		opt.Height = "10%"
+	}
+	i.popup = NewPopup(&PopupOption{
+		Title:  opt.Title + " (Enter: done, Esc: cancel)",
+		Width:  opt.Width,
+		Height: opt.Height,
+	})
+	i.popup.HandleInput(i.input)
+	i.popup.Handle("<enter>", i.popup.Close)
+	i.popup.Handle("<escape>", i.cancel)
+	return
+}
+
+// Open renders the input, onChange is called with the text whenever it's edited.
+// Esc restores the text it was opened with
+func (i *Input) Open(onChange func(text string), onClose func()) {
+	i.onChange = onChange
+	i.render()
+	i.popup.Open(onClose)
+}
+
+func (i *Input) cancel() {
+	if i.text != i.original {
+		i.text = i.original
+		i.onChange(i.text)
+	}
+	i.popup.Close()
+}
+
+func (i *Input) input(key string) {
+	text := editLine(i.text, key)
+	if text == i.text {
+		return
+	}
+	i.text = text
+	i.render()
+	i.onChange(i.text)
+}
+
+func (i *Input) render() {
+	i.popup.SetBody([]string{" " + strings.NewReplacer("[", "(", "]", ")").Replace(i.text) + editorCursor})
+}
+
+// editLine applies a key of termui to the end of the line, unlike editText a line break is not added
+func editLine(text string, key string) string {
+	switch key {
+	case "<enter>", "<tab>":
+		return text
+	}
+	return editText(text, key)
+}
@@ -1,6 +1,12 @@
 package listable
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+)
+
+// markupAttrs matches the attributes of termui's markup like "](fg-red,bg-white)"
+var markupAttrs = regexp.MustCompile(`\]\([a-z,-]+\)`)
 
 // ListRenderer make a List widget which includes
 // multi-line texts look like scrolled
@@ -94,12 +100,9 @@ func (l *ListRenderer) RenderActually() []string {
 }
 
 func (l *ListRenderer) unHighlighten(v string) string {
+	v = markupAttrs.ReplaceAllString(v, "]")
 	v = strings.Replace(v, "[", "", -1)
 	v = strings.Replace(v, "]", "", -1)
-	v = strings.Replace(v, "(fg-blue)", "", -1)
-	v = strings.Replace(v, "(fg-green)", "", -1)
-	v = strings.Replace(v, "(bg-green)", "", -1)
-	v = strings.Replace(v, "(fg-red)", "", -1)
 	return v
 }
 
@@ -199,6 +202,39 @@ func (l *ListRenderer) GetCursor() int {
 // SetBody replace strings on ListWrapper.body
 func (l *ListRenderer) SetBody(items []string) {
 	l.body = items
+	// go back to the top if the cursor is out of the new body
+	if l.cursor >= len(items) {
+		l.cursor = 0
+		l.top = 0
+		l.bottom = l.maxH - len(l.header) - 3
+	}
+}
+
+// SetHeader replaces the header, the scroll is kept as long as the cursor is shown
+func (l *ListRenderer) SetHeader(header []string) {
+	l.header = header
+	l.bottom = l.top + l.maxH - len(header) - 3
+	l.SetCursor(l.cursor)
+}
+
+// SetCursor moves the cursor to the line and scrolls to show it
+func (l *ListRenderer) SetCursor(i int) {
+	if i >= len(l.body) {
+		i = len(l.body) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	l.cursor = i
+	h := l.bottom - l.top
+	if l.cursor < l.top {
+		l.top = l.cursor
+		l.bottom = l.top + h
+	}
+	if l.cursor > l.bottom {
+		l.bottom = l.cursor
+		l.top = l.bottom - h
+	}
 }
 
 // AddBody add an another line of text to ListWrapper.body
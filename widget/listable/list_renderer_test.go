@@ -61,6 +61,37 @@ func TestRender(t *testing.T) {
 	}
 }
 
+func TestSetCursor(t *testing.T) {
+	opt := &ListRendererOption{
+		Header:        buildHeader(),
+		Body:          buildBody(),
+		MaxH:          8,
+		LineHighLight: true,
+	}
+	listRenderer := NewListRenderer(opt)
+	listRenderer.SetCursor(6)
+	items := listRenderer.RenderActually()
+	if listRenderer.GetCursor() != 6 || items[len(items)-1] != "[row7](fg-black,bg-green)" {
+		t.Fatalf("the list should be scrolled to row7 but %v", stringify(items))
+	}
+	listRenderer.SetCursor(1)
+	items = listRenderer.RenderActually()
+	if items[2] != "[row2](fg-black,bg-green)" {
+		t.Fatalf("the list should be scrolled to row2 but %v", stringify(items))
+	}
+	listRenderer.SetCursor(100)
+	if listRenderer.GetCursor() != 8 {
+		t.Fatalf("the cursor should stop at the last row but %d", listRenderer.GetCursor())
+	}
+
+	// a longer header shows less rows
+	listRenderer.SetHeader(append(buildHeader(), "header2"))
+	items = listRenderer.RenderActually()
+	if len(items) != 6 || items[len(items)-1] != "[row9](fg-black,bg-green)" {
+		t.Fatalf("the cursor should be shown under the new header but %v", stringify(items))
+	}
+}
+
 func stringify(list []string) (result string) {
 	result += "\n"
 	for _, l := range list {
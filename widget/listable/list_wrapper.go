@@ -9,6 +9,7 @@ type ListWrapper struct {
 	gPressed     bool
 	stepsByJump  int // how many steps to jump when C-d, C-u
 	listRenderer *ListRenderer
+	keyHandlers  map[string]func() // the keys which can't be a path of ui.Handle like "/"
 }
 
 // ListWrapperOption is the option argument for NewListWrapper
@@ -80,6 +81,11 @@ func (l *ListWrapper) setKeyBindings() error {
 		if l.gPressed && e.Path != "/sys/kbd/g" {
 			l.gPressed = false
 		}
+		if k, ok := e.Data.(ui.EvtKbd); ok {
+			if fn, ok := l.keyHandlers[k.KeyStr]; ok {
+				fn()
+			}
+		}
 	})
 	// moving top by gg
 	ui.Handle("/sys/kbd/g", func(ui.Event) {
@@ -99,6 +105,15 @@ func (l *ListWrapper) setKeyBindings() error {
 	return nil
 }
 
+// HandleKey binds a key which ui.Handle can't, e.g. "/" is cleaned into "/sys/kbd",
+// it's bound from the next Activate and stays after Deactivate like the other keys
+func (l *ListWrapper) HandleKey(key string, fn func()) {
+	if l.keyHandlers == nil {
+		l.keyHandlers = map[string]func(){}
+	}
+	l.keyHandlers[key] = fn
+}
+
 // Activate display current *ui.List.Items
 func (l *ListWrapper) Activate() {
 	l.setKeyBindings()
@@ -112,13 +127,13 @@ func (l *ListWrapper) Deactivate() {
 	l.widget.BorderLabelFg = ui.ColorWhite
 	l.widget.BorderFg = ui.ColorBlue
 	l.widget.Items = l.listRenderer.Deactivate()
-	ui.Render(ui.Body)
+	RenderBody()
 }
 
 // Render renders widgets
 func (l *ListWrapper) Render() {
 	l.widget.Items = l.listRenderer.RenderActually()
-	ui.Render(ui.Body)
+	RenderBody()
 }
 
 // ResetRender returns a initial multi-line texts
@@ -131,7 +146,12 @@ func (l *ListWrapper) ResetRender() {
 	} else {
 		l.widget.Items = []string{"loading..."}
 	}
-	ui.Render(ui.Body)
+	RenderBody()
+}
+
+// SetTitle replaces the border label of ui.List
+func (l *ListWrapper) SetTitle(title string) {
+	l.widget.BorderLabel = title
 }
 
 // GetWidget returns the instance of ui.List
@@ -150,25 +170,35 @@ func (l *ListWrapper) SetBody(items []string) {
 	l.widget.Items = items
 }
 
+// SetHeader replaces strings on ListWrapper.header
+func (l *ListWrapper) SetHeader(header []string) {
+	l.listRenderer.SetHeader(header)
+}
+
+// SetCursor moves the cursor to the line
+func (l *ListWrapper) SetCursor(i int) {
+	l.listRenderer.SetCursor(i)
+}
+
 // AddBody add an another line of textto ListWrapper.body
 func (l *ListWrapper) AddBody(line string) {
 	l.listRenderer.AddBody(line)
 	l.widget.Items = append(l.widget.Items, line)
-	ui.Render(ui.Body)
+	RenderBody()
 }
 
 // MoveCursor moves cursor
 func (l *ListWrapper) MoveCursor(direction string) {
 	l.gPressed = false // cancel gg to top
 	l.widget.Items = l.listRenderer.MoveCursor(direction)
-	ui.Render(ui.Body)
+	RenderBody()
 }
 
 // MmoveCursorWithFocus moves cursor and update ui
 func (l *ListWrapper) MmoveCursorWithFocus(direction string) {
 	l.gPressed = false // cancel gg to top
 	l.widget.Items = l.listRenderer.MoveCursorWithFocus(direction)
-	ui.Render(ui.Body)
+	RenderBody()
 }
 
 // GetWidth is the implementation of widget.Activate
@@ -0,0 +1,233 @@
+package listable
+
+import (
+	"strings"
+	"sync"
+
+	ui "github.com/gizak/termui"
+	"github.com/qmu/mcc/utils"
+)
+
+var (
+	popups          []*Popup
+	popupMutex      sync.Mutex
+	baseKeyBindings func()
+)
+
+// Popup is a ListWrapper rendered over ui.Body,
+// it takes over the key bindings while it is opened
+type Popup struct {
+	list     *ListWrapper
+	handlers map[string]func()
+	keys     []string
+	onInput  func(key string)
+	onClose  func()
+}
+
+// PopupOption is the option argument for NewPopup
+type PopupOption struct {
+	Title         string
+	Header        []string
+	Body          []string
+	LineHighLight bool
+	Width         string // percent of the terminal, "80%" by default
+	Height        string // percent of the terminal, "80%" by default
+}
+
+// SetBaseKeyBindings registers the function which binds the application-wide keys,
+// it's called when the last popup is closed
+func SetBaseKeyBindings(fn func()) {
+	baseKeyBindings = fn
+}
+
+// ResetKeyHandlers removes the handlers of the keys and the window to bind them again,
+// the handlers of the custom events under /usr/ are kept
+func ResetKeyHandlers() {
+	for path := range ui.DefaultEvtStream.Handlers {
+		if !strings.HasPrefix(path, "/usr/") {
+			delete(ui.DefaultEvtStream.Handlers, path)
+		}
+	}
+}
+
+// HasPopup returns whether any popup is opened
+func HasPopup() bool {
+	popupMutex.Lock()
+	defer popupMutex.Unlock()
+	return len(popups) > 0
+}
+
+// RenderBody renders ui.Body and the opened popups over it
+func RenderBody() {
+	popupMutex.Lock()
+	bs := []ui.Bufferer{ui.Body}
+	for _, p := range popups {
+		bs = append(bs, p.list.widget)
+	}
+	popupMutex.Unlock()
+	ui.Render(bs...)
+}
+
+// NewPopup constructs a Popup
+func NewPopup(opt *PopupOption) (p *Popup) {
+	p = new(Popup)
+	p.handlers = map[string]func(){}
+	if opt.Width == "" {
+		opt.Width = "80%"
+	}
+	if opt.Height == "" {
+		opt.Height = "80%"
+	}
+	termW := ui.TermWidth()
+	termH := ui.TermHeight()
+	w := utils.Percentalize(termW, opt.Width)
+	h := utils.Percentalize(termH, opt.Height)
+	if h < 5 {
+		h = 5
+	}
+
+	p.list = NewListWrapper(&ListWrapperOption{
+		Title:         opt.Title,
+		RealHeight:    h,
+		Header:        opt.Header,
+		Body:          opt.Body,
+		LineHighLight: opt.LineHighLight,
+	})
+	p.list.widget.Width = w
+	p.list.widget.X = (termW - w) / 2
+	p.list.widget.Y = (termH - h) / 2
+	return
+}
+
+// Handle binds a key (e.g. "y", "<enter>") to fn while the popup is opened,
+// "<escape>", "q" and "C-c" close the popup unless they are bound
+func (p *Popup) Handle(key string, fn func()) {
+	if _, ok := p.handlers[key]; !ok {
+		p.keys = append(p.keys, key)
+	}
+	p.handlers[key] = fn
+}
+
+// HandleInput makes the popup a text input, the keys which are not bound by Handle are passed to fn.
+// the cursor keys of the list are not bound and only "<escape>" and "C-c" close the popup
+func (p *Popup) HandleInput(fn func(key string)) {
+	p.onInput = fn
+}
+
+// Open renders the popup over ui.Body and takes over the key bindings,
+// onClose is called after the popup is closed
+func (p *Popup) Open(onClose func()) {
+	p.onClose = onClose
+	popupMutex.Lock()
+	popups = append(popups, p)
+	popupMutex.Unlock()
+	p.bindKeys()
+	p.list.widget.BorderLabelFg = ui.ColorGreen
+	p.list.widget.BorderFg = ui.ColorGreen
+	p.list.Render()
+}
+
+// Close closes the popup and gives the key bindings back
+func (p *Popup) Close() {
+	popupMutex.Lock()
+	opened := false
+	for i, o := range popups {
+		if o == p {
+			popups = append(popups[:i], popups[i+1:]...)
+			opened = true
+			break
+		}
+	}
+	var top *Popup
+	if len(popups) > 0 {
+		top = popups[len(popups)-1]
+	}
+	popupMutex.Unlock()
+	if !opened {
+		return
+	}
+
+	if top != nil {
+		top.bindKeys()
+	} else {
+		ResetKeyHandlers()
+		if baseKeyBindings != nil {
+			baseKeyBindings()
+		}
+	}
+	ui.Clear()
+	RenderBody()
+	if p.onClose != nil {
+		p.onClose()
+	}
+}
+
+func (p *Popup) bindKeys() {
+	ResetKeyHandlers()
+	closeKeys := []string{"<escape>", "q", "C-c"}
+	if p.onInput != nil {
+		closeKeys = []string{"<escape>", "C-c"}
+		ui.Handle("/sys/kbd", func(e ui.Event) {
+			if k, ok := e.Data.(ui.EvtKbd); ok {
+				p.onInput(k.KeyStr)
+			}
+		})
+	} else {
+		p.list.setKeyBindings()
+	}
+	for _, key := range closeKeys {
+		ui.Handle("/sys/kbd/"+key, func(ui.Event) {
+			p.Close()
+		})
+	}
+	ui.Handle("/sys/wnd/resize", func(ui.Event) {
+		ui.Body.Width = ui.TermWidth()
+		ui.Body.Align()
+		ui.Clear()
+		RenderBody()
+	})
+	for _, key := range p.keys {
+		fn := p.handlers[key]
+		ui.Handle("/sys/kbd/"+key, func(ui.Event) {
+			fn()
+		})
+	}
+}
+
+// SetTitle replaces the border label
+func (p *Popup) SetTitle(title string) {
+	p.list.SetTitle(title)
+	RenderBody()
+}
+
+// SetBody replaces the lines and renders them
+func (p *Popup) SetBody(items []string) {
+	p.list.SetBody(items)
+	p.list.Render()
+}
+
+// AddBody appends a line
+func (p *Popup) AddBody(line string) {
+	p.list.AddBody(line)
+}
+
+// MoveCursor moves the cursor to "direction" with a highlightened focus
+func (p *Popup) MoveCursor(direction string) {
+	p.list.MmoveCursorWithFocus(direction)
+}
+
+// SetCursor moves the cursor to the line and renders it
+func (p *Popup) SetCursor(i int) {
+	p.list.SetCursor(i)
+	p.list.Render()
+}
+
+// GetCursor returns the cursor position of the body
+func (p *Popup) GetCursor() int {
+	return p.list.GetCursor()
+}
+
+// GetWidth returns the width of the popup including its border
+func (p *Popup) GetWidth() int {
+	return p.list.GetWidth()
+}
@@ -1,6 +1,8 @@
 package widget
 
 import (
+	"path/filepath"
+
 	ui "github.com/gizak/termui"
 	"github.com/qmu/mcc/github"
 )
@@ -17,6 +19,11 @@ type Option struct {
 	Title      string
 	Type       string
 	Path       string
+	Command    string
+	Interval   string
+	Timeout    string
+	Repo       string // the git repository of the widget, ExecPath is used if it's empty
+	DockerHost string // the endpoint of the Docker API, DOCKER_HOST or a found socket is used if it's empty
 }
 
 // GetHeight is
@@ -29,17 +36,29 @@ func (w *Option) GetWidth() int {
 	return w.Width
 }
 
-// GetTitle is
+// GetTitle returns the title with the name of the repository if the widget has "repo"
 func (w *Option) GetTitle() string {
+	if w.Repo != "" {
+		return w.Title + " (" + filepath.Base(w.Repo) + ")"
+	}
 	return w.Title
 }
 
+// GetRepoPath returns the path of the git repository of the widget
+func (w *Option) GetRepoPath() string {
+	if w.Repo != "" {
+		return w.Repo
+	}
+	return w.ExecPath
+}
+
 // Menu is the schema implements Config.Widgets.Menu
 type Menu struct {
 	Category    string
 	Name        string
 	Description string
 	Command     string
+	Interactive bool // hand the terminal over to the command instead of running it in a pane
 }
 
 // Container is the schema implements Config.Widgets.Conttainer
@@ -47,11 +66,51 @@ type Container struct {
 	Metrics   string
 	Name      string
 	Container string
+	Selector  *ContainerSelector // selects the containers instead of Container, a gauge is shown for each of them
+	Mode      string             // "rate" (default) or "total" for the counters like net_rx
+	Max       string             // the value of the full bar like "10MB", the peak so far by default
+	Sparkline bool               // show the recent values beside the gauge
+	Window    string             // the period of the sparkline like "10m", 5m by default
+}
+
+// ContainerSelector selects the running containers of docker_status, they should match all of the set fields
+type ContainerSelector struct {
+	Project string // the name of the compose project
+	Label   string // "key" or "key=value" of a label
+	Name    string // the regular expression of the container name
+}
+
+// DockerLogs is the schema implements Config.Widgets.Content of docker_logs
+type DockerLogs struct {
+	Container  string // the name of the container
+	Service    string // the compose service, the container of it is followed instead of Container
+	Project    string // the compose project of Service, any project by default
+	Tail       int    // the number of the lines from the end to start with, 500 by default
+	Timestamps bool   // show the time of each line in the timezone of the config
+}
+
+// SystemStat is the schema implements Config.Widgets.Content of system_stats
+type SystemStat struct {
+	Metrics   string
+	Name      string // the label of the gauge, the one of the metrics by default
+	Path      string // a path on the filesystem of disk, "/" by default
+	Interface string // the network interface of net_rx and net_tx, all except lo by default
+	Mode      string // "rate" (default) or "total" for net_rx and net_tx
+	Max       string // the value of the full bar like "10MB", the peak so far by default
+	Sparkline bool   // show the recent values beside the gauge
+	Window    string // the period of the sparkline like "10m", 5m by default
+}
+
+// ProcessList is the schema implements Config.Widgets.Content of process_list
+type ProcessList struct {
+	Match string // the regular expression of the command line or the working directory, {exec_path} is the directory of the config
+	Sort  string // the column sorted by, "cpu" by default
 }
 
 // AdditionalWidgetOption is
 type AdditionalWidgetOption struct {
 	GithubClient *github.Client
+	GithubError  error // the reason why GithubClient is not available
 }
 
 // Widgetter define common interface for each widgets
@@ -65,4 +124,5 @@ type Widgetter interface {
 	GetGridBufferers() []ui.GridBufferer
 	GetHighlightenPos() int
 	Init() error
+	Stop()
 }
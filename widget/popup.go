@@ -0,0 +1,30 @@
+package widget
+
+import "github.com/qmu/mcc/widget/listable"
+
+// openConfirm asks the question by y/n, onYes is called after the popup is closed by y
+func openConfirm(title string, question string, onYes func(), onClose func()) {
+	p := listable.NewPopup(&listable.PopupOption{
+		Title:  title,
+		Body:   []string{" " + question, "", " [y](fg-green): yes, [n](fg-red): no"},
+		Width:  "60%",
+		Height: "20%",
+	})
+	p.Handle("y", func() {
+		p.Close()
+		onYes()
+	})
+	p.Handle("n", p.Close)
+	p.Open(onClose)
+}
+
+// openError shows the error in a popup
+func openError(err error, onClose func()) {
+	p := listable.NewPopup(&listable.PopupOption{
+		Title:  "ERROR",
+		Body:   []string{" [" + escapeMarkup(err.Error()) + "](fg-red)"},
+		Width:  "60%",
+		Height: "20%",
+	})
+	p.Open(onClose)
+}
@@ -0,0 +1,107 @@
+package widget
+
+import (
+	"sync"
+
+	ui "github.com/gizak/termui"
+)
+
+// history keeps the recent samples in a ring buffer
+type history struct {
+	samples []float64
+	next    int
+	full    bool
+	mutex   sync.Mutex
+}
+
+func newHistory(size int) *history {
+	if size < 1 {
+		size = 1
+	}
+	return &history{samples: make([]float64, size)}
+}
+
+// push adds the sample, the oldest one is dropped if the buffer is full
+func (h *history) push(v float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.samples[h.next] = v
+	h.next++
+	if h.next == len(h.samples) {
+		h.next = 0
+		h.full = true
+	}
+}
+
+// values returns the samples from the oldest one
+func (h *history) values() (vs []float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.full {
+		vs = append(vs, h.samples[h.next:]...)
+	}
+	return append(vs, h.samples[:h.next]...)
+}
+
+// resample shrinks the values into n points with the max of each span, so a spike isn't lost
+func resample(vs []float64, n int) []float64 {
+	if n <= 0 || len(vs) <= n {
+		return vs
+	}
+	points := make([]float64, n)
+	for i := range points {
+		from, to := i*len(vs)/n, (i+1)*len(vs)/n
+		for _, v := range vs[from:to] {
+			if v > points[i] {
+				points[i] = v
+			}
+		}
+	}
+	return points
+}
+
+// sparkline renders the history beside a gauge, the position is set by gaugeStack
+type sparkline struct {
+	title   string
+	color   ui.Attribute
+	history *history
+	x, y    int
+	width   int
+	height  int
+}
+
+// data scales the values into the integers of ui.Sparkline
+func (s *sparkline) data() []int {
+	vs := resample(s.history.values(), s.width)
+	max := 0.0
+	for _, v := range vs {
+		if v > max {
+			max = v
+		}
+	}
+	data := make([]int, len(vs))
+	if max > 0 {
+		for i, v := range vs {
+			data[i] = int(v / max * 1000)
+		}
+	}
+	return data
+}
+
+// Buffer implements ui.Bufferer
+func (s *sparkline) Buffer() ui.Buffer {
+	line := ui.NewSparkline()
+	line.Title = s.title
+	line.TitleColor = ui.ColorWhite
+	line.LineColor = s.color
+	// a line for the title
+	line.Height = s.height - 1
+	line.Data = s.data()
+	lines := ui.NewSparklines(line)
+	lines.Border = false
+	lines.X = s.x
+	lines.Y = s.y
+	lines.Width = s.width
+	lines.Height = s.height
+	return lines.Buffer()
+}
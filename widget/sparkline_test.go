@@ -0,0 +1,40 @@
+package widget
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	h := newHistory(3)
+	h.push(1)
+	h.push(2)
+	if vs := h.values(); !reflect.DeepEqual(vs, []float64{1, 2}) {
+		t.Fatalf("the samples should be [1 2] but %v", vs)
+	}
+	h.push(3)
+	h.push(4)
+	if vs := h.values(); !reflect.DeepEqual(vs, []float64{2, 3, 4}) {
+		t.Fatalf("the oldest sample should be dropped but %v", vs)
+	}
+}
+
+func TestResample(t *testing.T) {
+	vs := []float64{1, 9, 2, 3, 4, 1}
+	if points := resample(vs, 3); !reflect.DeepEqual(points, []float64{9, 3, 4}) {
+		t.Fatalf("the max of each span should be kept but %v", points)
+	}
+	if points := resample(vs, 10); !reflect.DeepEqual(points, vs) {
+		t.Fatalf("the values which fit should be kept but %v", points)
+	}
+}
+
+func TestSparklineData(t *testing.T) {
+	s := &sparkline{history: newHistory(4), width: 2}
+	for _, v := range []float64{0.5, 1, 2, 0} {
+		s.history.push(v)
+	}
+	if data := s.data(); !reflect.DeepEqual(data, []int{500, 1000}) {
+		t.Fatalf("the values should be scaled by the max but %v", data)
+	}
+}
@@ -0,0 +1,182 @@
+package widget
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ui "github.com/gizak/termui"
+	"github.com/qmu/mcc/utils"
+	"github.com/qmu/mcc/widget/listable"
+)
+
+const (
+	defaultCommandInterval = 10 * time.Second
+)
+
+// CommandWidget runs a shell command periodically and renders its output
+type CommandWidget struct {
+	lifecycle
+	options  *Option
+	renderer *listable.ListWrapper
+	isReady  bool
+	disabled bool
+	active   bool
+	interval time.Duration
+	timeout  time.Duration
+	body     []string // the last successful output
+	mutex    sync.Mutex
+}
+
+// NewCommandWidget constructs a New CommandWidget
+func NewCommandWidget(opt *Option) (c *CommandWidget, err error) {
+	c = new(CommandWidget)
+	c.options = opt
+	c.interval = defaultCommandInterval
+	if opt.Interval != "" {
+		if c.interval, err = time.ParseDuration(opt.Interval); err != nil {
+			return
+		}
+	}
+	c.timeout = c.interval
+	if opt.Timeout != "" {
+		if c.timeout, err = time.ParseDuration(opt.Timeout); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Init is the implementation of stack.Init
+func (c *CommandWidget) Init() (err error) {
+	c.renderer = listable.NewListWrapper(&listable.ListWrapperOption{
+		Title:      c.options.GetTitle(),
+		RealHeight: c.options.GetHeight(),
+	})
+	c.isReady = true
+	go c.watch()
+	return
+}
+
+// watch runs the command every interval until the widget is stopped
+func (c *CommandWidget) watch() {
+	ctx := c.context()
+	c.update()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.update()
+		}
+	}
+}
+
+// update runs the command once and renders the result,
+// the last successful output stays with an error marker when the command fails
+func (c *CommandWidget) update() {
+	lines, err := c.execute()
+	if c.context().Err() != nil {
+		// the command was killed by Stop
+		return
+	}
+	now := time.Now().Format("15:04:05")
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err != nil {
+		c.renderer.SetTitle(c.options.GetTitle() + " (STALE since " + now + ")")
+		lines = append(append([]string{}, c.body...), " ["+strings.NewReplacer("[", "(", "]", ")").Replace(err.Error())+"](fg-red)")
+	} else {
+		c.renderer.SetTitle(c.options.GetTitle() + " (" + now + ")")
+		c.body = lines
+	}
+	c.renderer.SetBody(lines)
+	if c.active {
+		c.renderer.Render()
+	} else {
+		c.renderer.Deactivate()
+	}
+}
+
+// execute runs the command with the timeout, the output is converted into termui's markup.
+// the command is killed when the widget is stopped
+func (c *CommandWidget) execute() (lines []string, err error) {
+	ctx, cancel := context.WithTimeout(c.context(), c.timeout)
+	defer cancel()
+
+	cmd := utils.NewShellCommand(c.options.Command, c.options.Envs)
+	cmd.Dir = c.options.ExecPath
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = utils.RunKillable(ctx, cmd)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, errors.New("timed out after " + c.timeout.String())
+	}
+	if err != nil {
+		msg := "exit code " + strconv.Itoa(utils.ExitCode(cmd, err))
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			msg += ": " + strings.Split(s, "\n")[0]
+		}
+		return nil, errors.New(msg)
+	}
+	out := strings.TrimRight(stdout.String(), "\n")
+	if out == "" {
+		return []string{}, nil
+	}
+	for _, l := range strings.Split(out, "\n") {
+		lines = append(lines, " "+listable.ConvertANSI(l))
+	}
+	return
+}
+
+// Activate is the implementation of Widget.Activate
+func (c *CommandWidget) Activate() {
+	c.mutex.Lock()
+	c.active = true
+	c.mutex.Unlock()
+	c.renderer.Activate()
+}
+
+// Deactivate is the implementation of Widget.Activate
+func (c *CommandWidget) Deactivate() {
+	c.mutex.Lock()
+	c.active = false
+	c.mutex.Unlock()
+	c.renderer.Deactivate()
+}
+
+// IsDisabled is the implementation of Widget.IsDisabled
+func (c *CommandWidget) IsDisabled() bool {
+	return c.disabled
+}
+
+// IsReady is the implementation of Widget.IsReady
+func (c *CommandWidget) IsReady() bool {
+	return c.isReady
+}
+
+// GetHighlightenPos is the implementation of Widget.GetHighlightenPos
+func (c *CommandWidget) GetHighlightenPos() int {
+	return c.renderer.GetCursor()
+}
+
+// GetGridBufferers is the implementation of stack.Activate
+func (c *CommandWidget) GetGridBufferers() []ui.GridBufferer {
+	return []ui.GridBufferer{c.renderer.GetWidget()}
+}
+
+// Disable is
+func (c *CommandWidget) Disable() {
+}
+
+// SetOption is
+func (c *CommandWidget) SetOption(opt *AdditionalWidgetOption) {
+}
@@ -0,0 +1,74 @@
+package widget
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandWidgetExecute(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mcc-command")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	script := "#!/bin/sh\nprintf 'hello %s\\n\\033[32mgreen\\033[0m\\n' \"$MCC_NAME\"\n"
+	if err = ioutil.WriteFile(filepath.Join(dir, "script.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// runs a script relative to the config with the envs
+	c, err := NewCommandWidget(&Option{
+		ExecPath: dir,
+		Command:  "./script.sh",
+		Envs:     []map[string]string{{"name": "MCC_NAME", "value": "mcc"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := c.execute()
+	if err != nil {
+		t.Fatalf("command should succeed but %v", err)
+	}
+	if len(lines) != 2 || lines[0] != " hello mcc" || lines[1] != " [green](fg-green)" {
+		t.Fatalf("unexpected output: %q", lines)
+	}
+
+	// a failed command returns its exit code and stderr
+	c, _ = NewCommandWidget(&Option{
+		ExecPath: dir,
+		Command:  "echo broken >&2; exit 3",
+	})
+	if _, err = c.execute(); err == nil || err.Error() != "exit code 3: broken" {
+		t.Fatalf("command should fail with exit code 3 but %v", err)
+	}
+
+	// a command taking longer than the timeout is killed
+	c, _ = NewCommandWidget(&Option{
+		ExecPath: dir,
+		Command:  "sleep 5",
+		Timeout:  "100ms",
+	})
+	if _, err = c.execute(); err == nil || !strings.HasPrefix(err.Error(), "timed out") {
+		t.Fatalf("command should time out but %v", err)
+	}
+
+	// a running command is killed when the widget is stopped
+	c, _ = NewCommandWidget(&Option{
+		ExecPath: dir,
+		Command:  "sleep 5",
+	})
+	time.AfterFunc(100*time.Millisecond, c.Stop)
+	start := time.Now()
+	if _, err = c.execute(); err == nil || time.Since(start) > 3*time.Second {
+		t.Fatalf("command should be killed by Stop but %v", err)
+	}
+
+	// invalid durations are rejected
+	if _, err = NewCommandWidget(&Option{Command: "date", Interval: "10"}); err == nil {
+		t.Fatal("interval without a unit should be an error")
+	}
+}
@@ -0,0 +1,284 @@
+package widget
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	ui "github.com/gizak/termui"
+	m2s "github.com/mitchellh/mapstructure"
+	mccdocker "github.com/qmu/mcc/docker"
+	"github.com/qmu/mcc/widget/listable"
+)
+
+const (
+	defaultDockerLogsTail = 500
+	dockerLogsRetry       = 3 * time.Second
+	composeServiceLabel   = "com.docker.compose.service"
+)
+
+var (
+	errorLevelRegexp   = regexp.MustCompile(`(?i)\b(error|fatal|panic|crit|critical)\b`)
+	warningLevelRegexp = regexp.MustCompile(`(?i)\b(warn|warning)\b`)
+)
+
+// DockerLogsWidget follows the logs of a container like tail_file,
+// it reconnects to the container after it's restarted or recreated
+type DockerLogsWidget struct {
+	lifecycle
+	options  *Option
+	renderer *listable.ListWrapper
+	source   *DockerLogs
+	selector *containerSelector // finds the container of the compose service
+	location *time.Location
+	client   *docker.Client
+	last     time.Time // the time of the latest line, the logs continue from it after a reconnection
+	resumed  time.Time // the time of the latest line before the reconnection, the lines until it are replayed
+	notice   string    // the last message about the connection not to repeat it
+	isReady  bool
+	disabled bool
+	mutex    sync.Mutex
+}
+
+// NewDockerLogsWidget constructs a New DockerLogsWidget
+func NewDockerLogsWidget(opt *Option) (n *DockerLogsWidget, err error) {
+	n = new(DockerLogsWidget)
+	n.options = opt
+	n.source = &DockerLogs{Tail: defaultDockerLogsTail}
+	if err = m2s.Decode(opt.Content, n.source); err != nil {
+		return
+	}
+	if n.source.Service != "" {
+		n.selector = &containerSelector{
+			project: n.source.Project,
+			label:   composeServiceLabel,
+			value:   n.source.Service,
+			byValue: true,
+		}
+	}
+	if n.location, err = time.LoadLocation(opt.Timezone); err != nil {
+		n.location, err = time.Local, nil
+	}
+	return
+}
+
+// Init is the implementation of widget.Init
+func (n *DockerLogsWidget) Init() (err error) {
+	n.renderer = listable.NewListWrapper(&listable.ListWrapperOption{
+		Title:      n.options.GetTitle(),
+		RealHeight: n.options.GetHeight(),
+	})
+	n.isReady = true
+	go n.follow()
+	return
+}
+
+// follow streams the logs while the container is running, and waits for it to start again
+// until the widget is stopped
+func (n *DockerLogsWidget) follow() {
+	ctx := n.context()
+	for {
+		id, err := n.findContainer()
+		switch {
+		case err != nil:
+			n.notify(" ["+escapeMarkup(err.Error())+"](fg-red)", true)
+		case id == "":
+			n.notify(" [waiting for "+escapeMarkup(n.describe())+" to start](fg-blue)", false)
+		default:
+			err = mccdocker.FollowLogs(ctx, n.client, n.resume(id), func(l *mccdocker.LogLine) {
+				if line, ok := n.receive(l); ok {
+					n.addLine(line)
+				}
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				n.notify(" ["+escapeMarkup(err.Error())+"](fg-red)", true)
+			} else {
+				n.notify(" ["+strings.Repeat("-", 10)+" "+escapeMarkup(n.describe())+" stopped "+strings.Repeat("-", 10)+"](fg-yellow)", true)
+			}
+		}
+		retry := time.NewTimer(dockerLogsRetry)
+		select {
+		case <-ctx.Done():
+			retry.Stop()
+			return
+		case <-retry.C:
+		}
+	}
+}
+
+// findContainer returns the ID of the running container, empty if it's not running
+func (n *DockerLogsWidget) findContainer() (id string, err error) {
+	if n.client == nil {
+		if n.client, err = mccdocker.NewClient(&mccdocker.ClientOption{Host: n.options.DockerHost}); err != nil {
+			return
+		}
+	}
+	containers, err := n.client.ListContainers(docker.ListContainersOptions{
+		Filters: map[string][]string{
+			"status": {"running"},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot connect to %s: %v", n.client.Endpoint(), err)
+	}
+	if n.selector == nil {
+		return findContainerID(containers, n.source.Container), nil
+	}
+	// the first one of the replicas of the service
+	sort.Slice(containers, func(i, j int) bool {
+		return containerName(containers[i]) < containerName(containers[j])
+	})
+	for _, c := range containers {
+		if n.selector.match(c) {
+			return c.ID, nil
+		}
+	}
+	return
+}
+
+// describe returns the container or the compose service which is followed
+func (n *DockerLogsWidget) describe() string {
+	if n.source.Service == "" {
+		return n.source.Container
+	}
+	if n.source.Project == "" {
+		return "service " + n.source.Service
+	}
+	return "service " + n.source.Project + "/" + n.source.Service
+}
+
+// tail returns the number of the lines to start with,
+// all the lines after the last one are read after a reconnection
+func (n *DockerLogsWidget) tail() string {
+	if !n.last.IsZero() || n.source.Tail < 0 {
+		return "all"
+	}
+	return strconv.Itoa(n.source.Tail)
+}
+
+// resume returns the option to connect to the container,
+// the logs after a reconnection start from the second of the latest line
+func (n *DockerLogsWidget) resume(id string) *mccdocker.LogsOption {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.resumed = n.last
+	return &mccdocker.LogsOption{
+		ID:         id,
+		Tail:       n.tail(),
+		Since:      n.last,
+		Timestamps: true,
+	}
+}
+
+// receive formats the line, the lines replayed after a reconnection are skipped.
+// stdout and stderr are read separately, so the lines of a connection are not compared with each other
+func (n *DockerLogsWidget) receive(l *mccdocker.LogLine) (line string, ok bool) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if !l.Time.IsZero() {
+		if !n.resumed.IsZero() && !l.Time.After(n.resumed) {
+			return
+		}
+		if l.Time.After(n.last) {
+			n.last = l.Time
+		}
+	}
+	n.notice = ""
+	line = formatLogLine(l)
+	if n.source.Timestamps && !l.Time.IsZero() {
+		line = " [" + l.Time.In(n.location).Format("2006-01-02 15:04:05") + "](fg-blue)" + line
+	}
+	return line, true
+}
+
+// formatLogLine colors a line of stderr red, and a line of stdout by the level in it like "ERROR" and "WARN"
+// unless the line has its own colors
+func formatLogLine(l *mccdocker.LogLine) string {
+	color := ""
+	switch {
+	case l.Stderr:
+		color = "fg-red"
+	case strings.Contains(l.Text, "\x1b["):
+	case errorLevelRegexp.MatchString(l.Text):
+		color = "fg-red"
+	case warningLevelRegexp.MatchString(l.Text):
+		color = "fg-yellow"
+	}
+	if color == "" {
+		return " " + listable.ConvertANSI(l.Text)
+	}
+	return " [" + escapeMarkup(l.Text) + "](" + color + ")"
+}
+
+// notify shows the message about the connection, the same one as the last is skipped unless always is set
+func (n *DockerLogsWidget) notify(message string, always bool) {
+	n.mutex.Lock()
+	if !always && message == n.notice {
+		n.mutex.Unlock()
+		return
+	}
+	n.notice = message
+	n.mutex.Unlock()
+	n.addLine(message)
+}
+
+func (n *DockerLogsWidget) addLine(line string) {
+	n.renderer.AddBody(line)
+	n.renderer.MoveCursor("bottom")
+}
+
+// Activate is the implementation of Widget.Activate
+func (n *DockerLogsWidget) Activate() {
+	n.renderer.Activate()
+}
+
+// Deactivate is the implementation of Widget.Activate
+func (n *DockerLogsWidget) Deactivate() {
+	n.renderer.Deactivate()
+}
+
+// IsDisabled is the implementation of Widget.IsDisabled
+func (n *DockerLogsWidget) IsDisabled() bool {
+	return n.disabled
+}
+
+// IsReady is the implementation of Widget.IsReady
+func (n *DockerLogsWidget) IsReady() bool {
+	return n.isReady
+}
+
+// GetHighlightenPos is the implementation of Widget.GetHighlightenPos
+func (n *DockerLogsWidget) GetHighlightenPos() int {
+	return n.renderer.GetCursor()
+}
+
+// GetGridBufferers is the implementation of Widget.GetGridBufferers
+func (n *DockerLogsWidget) GetGridBufferers() []ui.GridBufferer {
+	return []ui.GridBufferer{n.renderer.GetWidget()}
+}
+
+// GetWidth is the implementation of widget.Init
+func (n *DockerLogsWidget) GetWidth() int {
+	return n.renderer.GetWidth()
+}
+
+// GetHeight is the implementation of widget.Init
+func (n *DockerLogsWidget) GetHeight() int {
+	return n.renderer.GetHeight()
+}
+
+// Disable is
+func (n *DockerLogsWidget) Disable() {
+}
+
+// SetOption is
+func (n *DockerLogsWidget) SetOption(opt *AdditionalWidgetOption) {
+}
@@ -0,0 +1,91 @@
+package widget
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mccdocker "github.com/qmu/mcc/docker"
+	"github.com/qmu/mcc/widget/listable"
+)
+
+func TestFormatLogLine(t *testing.T) {
+	cases := map[*mccdocker.LogLine]string{
+		{Text: "error [fatal]", Stderr: true}:      " [error (fatal)](fg-red)",
+		{Text: "level=error msg=\"db is down\""}:   " [level=error msg=\"db is down\"](fg-red)",
+		{Text: "WARN slow query"}:                  " [WARN slow query](fg-yellow)",
+		{Text: "0 errors"}:                         " 0 errors",
+		{Text: "\x1b[31mERROR\x1b[0m colored"}:     listable.ConvertANSI(" \x1b[31mERROR\x1b[0m colored"),
+		{Text: "GET / 200", Time: time.Unix(0, 0)}: " GET / 200",
+	}
+	for l, expected := range cases {
+		if line := formatLogLine(l); line != expected {
+			t.Fatalf("%q should be %q but %q", l.Text, expected, line)
+		}
+	}
+}
+
+func TestDockerLogsWidgetReceive(t *testing.T) {
+	n, err := NewDockerLogsWidget(&Option{
+		Timezone: "Asia/Tokyo",
+		Content:  map[string]interface{}{"container": "web", "timestamps": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt := n.resume("c1"); opt.Tail != "500" || !opt.Since.IsZero() {
+		t.Fatalf("the last 500 lines should be read first but %+v", opt)
+	}
+	at := time.Date(2018, 5, 1, 3, 0, 0, 500, time.UTC)
+	if line, ok := n.receive(&mccdocker.LogLine{Text: "started", Time: at}); !ok || line != " [2018-05-01 12:00:00](fg-blue) started" {
+		t.Fatalf("the time should be shown in the timezone but %q", line)
+	}
+	// stdout and stderr are read separately, an earlier line of the other stream is not dropped
+	if _, ok := n.receive(&mccdocker.LogLine{Text: "warning", Stderr: true, Time: at.Add(-time.Millisecond)}); !ok {
+		t.Fatal("the line of stderr before the line of stdout should be shown")
+	}
+	// after a reconnection the logs start from the second of the last line
+	if opt := n.resume("c1"); opt.Tail != "all" || !opt.Since.Equal(at) {
+		t.Fatalf("the logs should continue from the last line but %+v", opt)
+	}
+	if _, ok := n.receive(&mccdocker.LogLine{Text: "warning", Stderr: true, Time: at.Add(-time.Millisecond)}); ok {
+		t.Fatal("the line already shown should be skipped")
+	}
+	if _, ok := n.receive(&mccdocker.LogLine{Text: "listening", Time: at.Add(time.Millisecond)}); !ok {
+		t.Fatal("the line after the last one should be shown")
+	}
+	if _, ok := n.receive(&mccdocker.LogLine{Text: "failed", Stderr: true, Time: at.Add(time.Microsecond)}); !ok {
+		t.Fatal("the line after the reconnection should be shown even if a later one has been shown")
+	}
+}
+
+func TestDockerLogsWidgetFindContainer(t *testing.T) {
+	api := &dockerAPI{
+		ids:    map[string]string{"app_web_2": "c2", "app_web_1": "c1", "other_web_1": "c3", "web": "c4"},
+		labels: map[string]string{"app_web_1": "app", "app_web_2": "app", "other_web_1": "other"},
+		svcs:   map[string]string{"app_web_1": "web", "app_web_2": "web", "other_web_1": "web"},
+	}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	host := "tcp://" + srv.Listener.Addr().String()
+
+	cases := []struct {
+		content map[string]interface{}
+		id      string
+	}{
+		{map[string]interface{}{"container": "web"}, "c4"},
+		{map[string]interface{}{"container": "app_web"}, ""},
+		{map[string]interface{}{"service": "web", "project": "app"}, "c1"},
+		{map[string]interface{}{"service": "web", "project": "other"}, "c3"},
+		{map[string]interface{}{"service": "db"}, ""},
+	}
+	for _, c := range cases {
+		n, err := NewDockerLogsWidget(&Option{DockerHost: host, Content: c.content})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, err := n.findContainer(); err != nil || id != c.id {
+			t.Fatalf("%v should find %q but %q, error: %v", c.content, c.id, id, err)
+		}
+	}
+}
@@ -1,135 +1,647 @@
 package widget
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	docker "github.com/fsouza/go-dockerclient"
 	ui "github.com/gizak/termui"
 	m2s "github.com/mitchellh/mapstructure"
+	mccdocker "github.com/qmu/mcc/docker"
+	"github.com/qmu/mcc/widget/listable"
 )
 
 // "github.com/k0kubun/pp"
 
+const (
+	defaultDockerInterval = time.Second
+	dockerStatsTimeout    = 10 * time.Second
+	composeProjectLabel   = "com.docker.compose.project"
+	defaultDockerWindow   = "5m"
+	dockerStopTimeout     = 10 // seconds to wait before the container is killed
+	dockerLogsTail        = "500"
+)
+
+// dockerStatusKeys are bound while the widget is active
+var dockerStatusKeys = []string{"j", "k", "<down>", "<up>", "r", "s", "l"}
+
 // DockerStatusWidget is a command launcher
 type DockerStatusWidget struct {
+	lifecycle
 	options    *Option
-	gauges     []*gaugeModel
+	stack      *gaugeStack
+	gauges     []*gaugeModel // the gauges shown now
+	contents   []*dockerContent
 	isReady    bool
 	disabled   bool
+	interval   time.Duration
 	containers []Container
 	client     *docker.Client
+	active     bool
+	cursor     int             // the index of the gauge whose container is operated
+	refresh    chan struct{}   // polls the stats without waiting for the interval
+	stopped    map[string]bool // the names of the containers of the selectors stopped by the widget
+	confirm    func(title string, question string, onYes func(), onClose func())
+	alert      func(err error, onClose func())
+	mutex      sync.Mutex
+}
+
+// dockerTarget is the container under the cursor, it's copied not to be changed by the poll
+type dockerTarget struct {
+	container string
+	id        string
+	running   bool
+	matched   bool
 }
 
 type gaugeModel struct {
 	gauge     *ui.Gauge
 	metrics   string
+	mode      string  // "rate" or "total" of a counter
+	max       float64 // the value of the full bar, the peak so far if it's 0
+	peak      float64
 	id        string
 	name      string
 	container string
+	missing   string // the label when the container is not found
+	matched   bool   // the container is matched by a selector
 	active    bool
+	last      uint64 // the last value of the counter to compute the rate
+	lastAt    time.Time
+	hasLast   bool
+	spark     *sparkline // nil without the sparkline
+}
+
+// dockerContent is a row of the content,
+// it expands to the gauges of the containers which the selector matches
+type dockerContent struct {
+	Container
+	metric   gaugeMetric
+	max      float64
+	window   time.Duration
+	selector *containerSelector
+	gauge    *gaugeModel            // the gauge of the container, or the one shown when no container matches
+	selected map[string]*gaugeModel // the gauges of the matched containers by the names
+}
+
+// containerSelector is the compiled ContainerSelector
+type containerSelector struct {
+	project string
+	label   string
+	value   string
+	byValue bool
+	name    *regexp.Regexp
+}
+
+func newContainerSelector(opt *ContainerSelector) (s *containerSelector, err error) {
+	s = &containerSelector{project: opt.Project, label: opt.Label}
+	if i := strings.Index(opt.Label, "="); i > -1 {
+		s.label, s.value, s.byValue = opt.Label[:i], opt.Label[i+1:], true
+	}
+	if opt.Name != "" {
+		if s.name, err = regexp.Compile(opt.Name); err != nil {
+			return nil, fmt.Errorf("selector name %s of docker_status is invalid: %v", opt.Name, err)
+		}
+	}
+	return
+}
+
+// match tells if the container has the compose project, the label and the name
+func (s *containerSelector) match(c docker.APIContainers) bool {
+	if s.project != "" && c.Labels[composeProjectLabel] != s.project {
+		return false
+	}
+	if s.label != "" {
+		v, ok := c.Labels[s.label]
+		if !ok || s.byValue && v != s.value {
+			return false
+		}
+	}
+	return s.name == nil || s.name.MatchString(containerName(c))
+}
+
+// String describes the selector like "project=app, name=^web"
+func (s *containerSelector) String() string {
+	var conds []string
+	if s.project != "" {
+		conds = append(conds, "project="+s.project)
+	}
+	if s.label != "" {
+		l := s.label
+		if s.byValue {
+			l += "=" + s.value
+		}
+		conds = append(conds, "label="+l)
+	}
+	if s.name != nil {
+		conds = append(conds, "name="+s.name.String())
+	}
+	return strings.Join(conds, ", ")
+}
+
+// gaugeMetric is a kind of the gauges of docker_status and system_stats
+type gaugeMetric struct {
+	label     string
+	color     ui.Attribute
+	counter   bool // a cumulative value shown as the rate or the total
+	noHistory bool // not a number which can be drawn as a sparkline
+}
+
+var dockerMetrics = map[string]gaugeMetric{
+	"cpu":         {label: "CPU Usage", color: ui.ColorGreen},
+	"memory":      {label: "Memory Usage", color: ui.ColorRed},
+	"net_rx":      {label: "Network RX", color: ui.ColorCyan, counter: true},
+	"net_tx":      {label: "Network TX", color: ui.ColorCyan, counter: true},
+	"block_read":  {label: "Block Read", color: ui.ColorYellow, counter: true},
+	"block_write": {label: "Block Write", color: ui.ColorYellow, counter: true},
+	"pids":        {label: "PIDs", color: ui.ColorMagenta},
+	"status":      {label: "Status", color: ui.ColorBlue, noHistory: true},
+}
+
+// IsDockerStatusMetrics tells if the metrics is available for docker_status
+func IsDockerStatusMetrics(metrics string) bool {
+	_, ok := dockerMetrics[metrics]
+	return ok
+}
+
+// HasDockerStatusHistory tells if the metrics of docker_status can be shown as a sparkline
+func HasDockerStatusHistory(metrics string) bool {
+	m, ok := dockerMetrics[metrics]
+	return ok && !m.noHistory
+}
+
+// IsDockerStatusCounter tells if the metrics of docker_status accepts the mode "rate" or "total"
+func IsDockerStatusCounter(metrics string) bool {
+	return dockerMetrics[metrics].counter
+}
+
+// dockerSample is what is read from a container in a poll
+type dockerSample struct {
+	stats     *docker.Stats
+	container *docker.Container
+	err       error
 }
 
 // NewDockerStatusWidget constructs a New DockerStatusWidget
 func NewDockerStatusWidget(opt *Option) (n *DockerStatusWidget, err error) {
 	n = new(DockerStatusWidget)
 	n.options = opt
+	n.stack = newGaugeStack(opt.GetWidth(), opt.GetHeight())
+	n.refresh = make(chan struct{}, 1)
+	n.stopped = map[string]bool{}
+	n.confirm = openConfirm
+	n.alert = openError
+	n.interval = defaultDockerInterval
+	if opt.Interval != "" {
+		if n.interval, err = time.ParseDuration(opt.Interval); err != nil {
+			return
+		}
+	}
+	err = m2s.Decode(opt.Content, &n.containers)
 	return
 }
 
 // Init is the implementation of widget.Init
 func (n *DockerStatusWidget) Init() (err error) {
-	if err = m2s.Decode(n.options.Content, &n.containers); err != nil {
-		return err
-	}
 	if err = n.buildGauges(); err != nil {
 		return
 	}
-	go func() {
-		endpoint := "unix:///var/run/docker.sock"
-		n.client, err = docker.NewClient(endpoint)
+	n.isReady = true
+	go n.watch()
+	return
+}
+
+// watch polls the stats every interval until the widget is stopped,
+// a poll starts after the previous one finishes
+func (n *DockerStatusWidget) watch() {
+	ctx := n.context()
+	n.poll()
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-n.refresh:
+		}
+		n.poll()
+	}
+}
+
+func (n *DockerStatusWidget) poll() {
+	n.update()
+	listable.RenderBody()
+}
+
+// update finds the running containers and reads the stats of them into the gauges,
+// the IDs are looked up every time so a restarted container is followed
+func (n *DockerStatusWidget) update() {
+	if n.client == nil {
+		client, err := mccdocker.NewClient(&mccdocker.ClientOption{Host: n.options.DockerHost})
 		if err != nil {
+			n.showError(err)
 			return
 		}
-		for _, g := range n.gauges {
-			var id string
-			id, err = n.getContainerIDByName(g.container)
-			if err != nil {
-				return
+		n.client = client
+	}
+	containers, err := n.client.ListContainers(docker.ListContainersOptions{
+		Filters: map[string][]string{
+			"status": {"running"},
+		},
+	})
+	if err != nil {
+		n.showError(fmt.Errorf("cannot connect to %s: %v", n.client.Endpoint(), err))
+		return
+	}
+	n.expand(containers)
+
+	// a container is read once for all of its gauges, the stats and the inspection only if they are needed
+	needStats, needInspect := map[string]bool{}, map[string]bool{}
+	n.mutex.Lock()
+	for _, g := range n.gauges {
+		id := findContainerID(containers, g.container)
+		if id != g.id {
+			// the counters restart with the container
+			g.hasLast = false
+		}
+		g.id = id
+		g.active = g.id != ""
+		if !g.active {
+			continue
+		}
+		if g.metrics == "status" {
+			needInspect[g.id] = true
+		} else {
+			needStats[g.id] = true
+		}
+	}
+	n.mutex.Unlock()
+	// the IDs are collected before reading, each read has its own slot
+	var ids []string
+	seen := map[string]bool{}
+	for _, need := range []map[string]bool{needStats, needInspect} {
+		for id := range need {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
 			}
-			active := true
-			if id == "" {
-				active = false
-				g.gauge.Label = "'" + g.container + "' is not running "
+		}
+	}
+	results := make([]*dockerSample, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			results[i] = n.read(id, needStats[id], needInspect[id])
+		}(i, id)
+	}
+	wg.Wait()
+	samples := map[string]*dockerSample{}
+	for i, id := range ids {
+		samples[id] = results[i]
+	}
+
+	for _, g := range n.gauges {
+		switch {
+		case !g.active:
+			g.gauge.Percent = 0
+			g.gauge.Label = g.missing + " "
+			g.record(0)
+		case samples[g.id].err != nil:
+			g.gauge.Percent = 0
+			g.gauge.Label = samples[g.id].err.Error() + " "
+			g.record(0)
+		default:
+			g.render(samples[g.id])
+		}
+	}
+}
+
+// expand lays out a gauge for each container which the selectors match,
+// the gauge of a container is kept while it is running, or after it's stopped by the widget to be started again
+func (n *DockerStatusWidget) expand(containers []docker.APIContainers) {
+	n.mutex.Lock()
+	stopped := map[string]bool{}
+	for name := range n.stopped {
+		stopped[name] = true
+	}
+	n.mutex.Unlock()
+
+	var gauges []*gaugeModel
+	for _, c := range n.contents {
+		if c.selector == nil {
+			gauges = append(gauges, c.gauge)
+			continue
+		}
+		var names []string
+		running := map[string]bool{}
+		for _, ct := range containers {
+			if c.selector.match(ct) {
+				names = append(names, containerName(ct))
+				running[containerName(ct)] = true
 			}
-			g.id = id
-			g.active = active
 		}
-	}()
+		for name := range c.selected {
+			if stopped[name] && !running[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		selected := map[string]*gaugeModel{}
+		for _, name := range names {
+			g, ok := c.selected[name]
+			if !ok {
+				g = n.newGauge(c, name, name)
+				g.missing = "'" + name + "' is not running"
+				g.matched = true
+			}
+			selected[name] = g
+			gauges = append(gauges, g)
+		}
+		c.selected = selected
+		if len(names) == 0 {
+			gauges = append(gauges, c.gauge)
+		}
+	}
+	n.mutex.Lock()
+	for _, ct := range containers {
+		// started again
+		delete(n.stopped, containerName(ct))
+	}
+	n.gauges = gauges
+	if n.cursor >= len(gauges) {
+		n.cursor = len(gauges) - 1
+	}
+	if n.cursor < 0 {
+		n.cursor = 0
+	}
+	n.highlight()
+	n.mutex.Unlock()
+	n.showGauges()
+}
+
+// showGauges passes the current gauges to the stack
+func (n *DockerStatusWidget) showGauges() {
+	rows := make([]stackRow, len(n.gauges))
+	for i, g := range n.gauges {
+		rows[i] = stackRow{gauge: g.gauge, spark: g.spark}
+	}
+	n.stack.SetRows(rows)
+}
+
+// read reads the stats and the inspection of the container
+func (n *DockerStatusWidget) read(id string, stats bool, inspect bool) (s *dockerSample) {
+	s = new(dockerSample)
+	if stats {
+		if s.stats, s.err = n.getStats(id); s.err != nil {
+			return
+		}
+	}
+	if inspect {
+		s.container, s.err = n.client.InspectContainer(id)
+	}
 	return
 }
 
+// render shows the metrics of the gauge in the sample
+func (g *gaugeModel) render(s *dockerSample) {
+	switch g.metrics {
+	case "cpu":
+		r := cpuPercent(s.stats)
+		g.record(r)
+		g.gauge.Percent = int(r)
+		g.gauge.Label = strconv.FormatFloat(r, 'f', 2, 64) + "% "
+	case "memory":
+		m, l := memoryUsage(s.stats)
+		g.record(float64(m))
+		g.gauge.Percent = m
+		lim := humanize.Comma(l / 1000 / 1000)
+		g.gauge.Label = "{{percent}}% (" + lim + "MBs) "
+	case "pids":
+		v := s.stats.PidsStats.Current
+		g.show(float64(v), strconv.FormatUint(v, 10))
+	case "status":
+		g.renderStatus(s.container)
+	default:
+		g.renderCounter(counterValue(g.metrics, s.stats), s.stats.Read)
+	}
+}
+
+// renderCounter shows the total of the counter, or the rate per second from the last value
+func (g *gaugeModel) renderCounter(v uint64, at time.Time) {
+	if g.mode == "total" {
+		g.show(float64(v), humanize.Bytes(v))
+		return
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if !g.hasLast || v < g.last || !at.After(g.lastAt) {
+		g.last, g.lastAt, g.hasLast = v, at, true
+		g.gauge.Percent = 0
+		g.gauge.Label = "measuring... "
+		return
+	}
+	rate := float64(v-g.last) / at.Sub(g.lastAt).Seconds()
+	g.last, g.lastAt = v, at
+	g.show(rate, humanize.Bytes(uint64(rate))+"/s")
+}
+
+// show sets the value on the bar scaled by max or the peak so far
+func (g *gaugeModel) show(v float64, label string) {
+	g.record(v)
+	if v > g.peak {
+		g.peak = v
+	}
+	full := g.max
+	if full == 0 {
+		full = g.peak
+	}
+	g.gauge.Percent = 0
+	if full > 0 {
+		g.gauge.Percent = int(v / full * 100)
+	}
+	if g.gauge.Percent > 100 {
+		g.gauge.Percent = 100
+	}
+	g.gauge.Label = label + " "
+}
+
+// record adds the value to the sparkline
+func (g *gaugeModel) record(v float64) {
+	if g.spark != nil {
+		g.spark.history.push(v)
+	}
+}
+
+// renderStatus shows the health check and the restart count of the container
+func (g *gaugeModel) renderStatus(c *docker.Container) {
+	health := c.State.Health.Status
+	switch health {
+	case "healthy":
+		g.gauge.BarColor = ui.ColorGreen
+	case "unhealthy":
+		g.gauge.BarColor = ui.ColorRed
+	case "starting":
+		g.gauge.BarColor = ui.ColorYellow
+	default:
+		health = "no health check"
+		g.gauge.BarColor = ui.ColorBlue
+	}
+	restarts := strconv.Itoa(c.RestartCount) + " restarts"
+	if c.RestartCount == 1 {
+		restarts = "1 restart"
+	}
+	g.gauge.Percent = 100
+	g.gauge.Label = c.State.Status + ", " + health + ", " + restarts + " "
+}
+
+// counterValue returns the cumulative bytes of the metrics in the stats
+func counterValue(metrics string, stats *docker.Stats) (v uint64) {
+	switch metrics {
+	case "net_rx", "net_tx":
+		networks := stats.Networks
+		if len(networks) == 0 {
+			// the API before v1.21
+			networks = map[string]docker.NetworkStats{"": stats.Network}
+		}
+		for _, n := range networks {
+			if metrics == "net_rx" {
+				v += n.RxBytes
+			} else {
+				v += n.TxBytes
+			}
+		}
+	case "block_read", "block_write":
+		op := "read"
+		if metrics == "block_write" {
+			op = "write"
+		}
+		for _, e := range stats.BlkioStats.IOServiceBytesRecursive {
+			// "Read" with cgroup v1 and "read" with v2
+			if strings.EqualFold(e.Op, op) {
+				v += e.Value
+			}
+		}
+	}
+	return
+}
+
+// showError shows the error on all of the gauges
+func (n *DockerStatusWidget) showError(err error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for _, g := range n.gauges {
+		g.active = false
+		g.gauge.Percent = 0
+		g.gauge.Label = err.Error() + " "
+	}
+}
+
 func (n *DockerStatusWidget) buildGauges() (err error) {
-	l := len(n.containers)
-	maxH := n.options.GetHeight()
-	for i, v := range n.containers {
-		g := ui.NewGauge()
-		g.Percent = 0
-		g.Width = n.options.GetWidth()
-		if i == l-1 {
-			g.Height = maxH - (maxH/l)*(l-1)
-		} else {
-			g.Height = maxH / l
-		}
-		g.BorderFg = ui.ColorBlue
-		g.BorderLabelFg = ui.ColorWhite
-		var metrics string
-		if v.Metrics == "cpu" {
-			metrics = "CPU Usage"
-			g.BarColor = ui.ColorGreen
-		} else if v.Metrics == "memory" {
-			metrics = "Memory Usage"
-			g.BarColor = ui.ColorRed
-		} else {
+	for _, v := range n.containers {
+		m, ok := dockerMetrics[v.Metrics]
+		if !ok {
 			return errors.New(v.Metrics + " is not available for the type of docker_status widget")
 		}
-		lbl := v.Name + " (" + v.Container + ")" + " - " + metrics
-		if n.options.GetTitle() == "" {
-			g.BorderLabel = lbl
+		c := &dockerContent{Container: v, metric: m, selected: map[string]*gaugeModel{}}
+		if v.Max != "" {
+			var full uint64
+			if full, err = humanize.ParseBytes(v.Max); err != nil {
+				return fmt.Errorf("max %s of docker_status is invalid: %v", v.Max, err)
+			}
+			c.max = float64(full)
+		}
+		if c.Window == "" {
+			c.Window = defaultDockerWindow
+		}
+		if c.window, err = time.ParseDuration(c.Window); err != nil {
+			return fmt.Errorf("window %s of docker_status is invalid: %v", c.Window, err)
+		}
+		if v.Selector == nil {
+			c.gauge = n.newGauge(c, v.Container, v.Container)
+			c.gauge.missing = "'" + v.Container + "' is not running"
 		} else {
-			g.BorderLabel = n.options.GetTitle() + " - " + lbl
-		}
-		g.Label = "fetching... "
-		g.LabelAlign = ui.AlignRight
-		n.gauges = append(n.gauges, &gaugeModel{
-			gauge:     g,
-			metrics:   v.Metrics,
-			name:      v.Name,
-			container: v.Container,
-		})
+			if c.selector, err = newContainerSelector(v.Selector); err != nil {
+				return
+			}
+			c.gauge = n.newGauge(c, c.selector.String(), "")
+			c.gauge.missing = "no container matches"
+		}
+		n.contents = append(n.contents, c)
+		n.gauges = append(n.gauges, c.gauge)
 	}
+	n.showGauges()
 	return
 }
 
-func (n *DockerStatusWidget) getContainerIDByName(name string) (id string, err error) {
-	var containers []docker.APIContainers
-	containers, err = n.client.ListContainers(docker.ListContainersOptions{
-		All: true,
-		Filters: map[string][]string{
-			"status": []string{"running"},
-		},
-	})
-	if err != nil {
-		return
+// newGauge makes a gauge of the content for the container, the label tells which container it is
+func (n *DockerStatusWidget) newGauge(c *dockerContent, label string, container string) *gaugeModel {
+	m := newGaugeModel(n.options, c.Name+" ("+label+")"+" - "+c.metric.label, c.metric)
+	m.metrics = c.Metrics
+	m.mode = c.Mode
+	m.max = c.max
+	m.name = c.Name
+	m.container = container
+	if c.Sparkline && !c.metric.noHistory {
+		m.showHistory(c.Window, c.window, n.interval)
+	}
+	return m
+}
+
+// newGaugeModel makes a gauge of the metric, the label is prefixed with the title of the widget
+func newGaugeModel(opt *Option, label string, metric gaugeMetric) *gaugeModel {
+	g := ui.NewGauge()
+	g.Percent = 0
+	g.BorderFg = ui.ColorBlue
+	g.BorderLabelFg = ui.ColorWhite
+	g.BarColor = metric.color
+	if opt.GetTitle() == "" {
+		g.BorderLabel = label
+	} else {
+		g.BorderLabel = opt.GetTitle() + " - " + label
+	}
+	g.Label = "fetching... "
+	g.LabelAlign = ui.AlignRight
+	return &gaugeModel{gauge: g}
+}
+
+// showHistory adds the sparkline of the values in the window, a sample is kept every interval
+func (g *gaugeModel) showHistory(title string, window time.Duration, interval time.Duration) {
+	g.spark = &sparkline{
+		title:   "last " + title,
+		color:   g.gauge.BarColor,
+		history: newHistory(int(window / interval)),
+	}
+}
+
+// containerName returns the name of the container without the leading "/",
+// the names of the legacy links like "/web/db" are skipped
+func containerName(c docker.APIContainers) string {
+	for _, name := range c.Names {
+		name = strings.TrimPrefix(name, "/")
+		if !strings.Contains(name, "/") {
+			return name
+		}
 	}
+	return ""
+}
+
+// findContainerID returns the ID of the container of the name, empty if it's not running
+func findContainerID(containers []docker.APIContainers, name string) (id string) {
 	for _, c := range containers {
-		i := strings.LastIndex(c.Names[0], "/"+name)
-		if i > -1 {
-			id = c.ID
-			break
+		if name != "" && containerName(c) == name {
+			return c.ID
 		}
 	}
 	return
@@ -137,41 +649,189 @@ func (n *DockerStatusWidget) getContainerIDByName(name string) (id string, err e
 
 // Activate is the implementation of Widget.Activate
 func (n *DockerStatusWidget) Activate() {
-	go func() {
-		for _, g := range n.gauges {
-			if !g.active {
-				return
+	n.mutex.Lock()
+	n.active = true
+	n.highlight()
+	n.mutex.Unlock()
+	n.setKeyBindings()
+	listable.RenderBody()
+}
+
+// Deactivate is the implementation of Widget.Activate
+func (n *DockerStatusWidget) Deactivate() {
+	n.mutex.Lock()
+	n.active = false
+	n.highlight()
+	n.mutex.Unlock()
+	// termui can't remove a handler, the keys do nothing in the other widgets
+	for _, key := range dockerStatusKeys {
+		ui.Handle("/sys/kbd/"+key, func(ui.Event) {})
+	}
+	listable.RenderBody()
+}
+
+func (n *DockerStatusWidget) setKeyBindings() {
+	for _, key := range []string{"j", "<down>"} {
+		ui.Handle("/sys/kbd/"+key, func(ui.Event) {
+			n.moveCursor(1)
+			listable.RenderBody()
+		})
+	}
+	for _, key := range []string{"k", "<up>"} {
+		ui.Handle("/sys/kbd/"+key, func(ui.Event) {
+			n.moveCursor(-1)
+			listable.RenderBody()
+		})
+	}
+	ui.Handle("/sys/kbd/r", func(ui.Event) {
+		n.restart()
+	})
+	ui.Handle("/sys/kbd/s", func(ui.Event) {
+		n.stopOrStart()
+	})
+	ui.Handle("/sys/kbd/l", func(ui.Event) {
+		n.openLogs()
+	})
+}
+
+// moveCursor moves the cursor to the next (1) or the previous (-1) gauge
+func (n *DockerStatusWidget) moveCursor(d int) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if c := n.cursor + d; c >= 0 && c < len(n.gauges) {
+		n.cursor = c
+	}
+	n.highlight()
+}
+
+// highlight colors the border of the gauge under the cursor while the widget is active,
+// the stack is scrolled to show it
+func (n *DockerStatusWidget) highlight() {
+	n.stack.SetCursor(n.cursor)
+	for i, g := range n.gauges {
+		if n.active && i == n.cursor {
+			g.gauge.BorderFg = ui.ColorGreen
+			g.gauge.BorderLabelFg = ui.ColorGreen
+		} else {
+			g.gauge.BorderFg = ui.ColorBlue
+			g.gauge.BorderLabelFg = ui.ColorWhite
+		}
+	}
+}
+
+// selectedContainer returns the container under the cursor, false if no container is operable
+func (n *DockerStatusWidget) selectedContainer() (t dockerTarget, ok bool) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if n.client == nil || n.cursor >= len(n.gauges) || n.gauges[n.cursor].container == "" {
+		return
+	}
+	g := n.gauges[n.cursor]
+	return dockerTarget{container: g.container, id: g.id, running: g.active, matched: g.matched}, true
+}
+
+// restart restarts the container under the cursor after the confirmation
+func (n *DockerStatusWidget) restart() {
+	t, ok := n.selectedContainer()
+	if !ok || !t.running {
+		return
+	}
+	n.confirm("RESTART", "Restart the container "+escapeMarkup(t.container)+"?", func() {
+		n.runAction(func() error {
+			return n.client.RestartContainer(t.id, dockerStopTimeout)
+		})
+	}, n.Activate)
+}
+
+// stopOrStart stops the running container under the cursor after the confirmation, or starts the stopped one.
+// the gauge of a container matched by a selector is kept after it's stopped to start it again
+func (n *DockerStatusWidget) stopOrStart() {
+	t, ok := n.selectedContainer()
+	if !ok {
+		return
+	}
+	if !t.running {
+		n.runAction(func() error {
+			return n.startContainer(t.container)
+		})
+		return
+	}
+	n.confirm("STOP", "Stop the container "+escapeMarkup(t.container)+"?", func() {
+		n.runAction(func() error {
+			if err := n.client.StopContainer(t.id, dockerStopTimeout); err != nil {
+				return err
 			}
-			if g.metrics == "cpu" {
-				r, err := n.readCPU(g)
-				if err != nil {
-					panic(err)
-				}
-				per := strconv.FormatFloat(r, 'f', 2, 64)
-				g.gauge.Percent = int(r)
-				g.gauge.Label = per + "% "
-			} else if g.metrics == "memory" {
-				m, l, err := n.readMemory(g.id)
-				if err != nil {
-					panic(err)
-				}
-				g.gauge.Percent = m
-				lim := humanize.Comma(l / 1000 / 1000)
-				g.gauge.Label = "{{percent}}% (" + lim + "MBs) "
+			if t.matched {
+				n.mutex.Lock()
+				n.stopped[t.container] = true
+				n.mutex.Unlock()
 			}
-			ui.Render(ui.Body)
+			return nil
+		})
+	}, n.Activate)
+}
+
+// startContainer starts the stopped container of the name
+func (n *DockerStatusWidget) startContainer(name string) (err error) {
+	containers, err := n.client.ListContainers(docker.ListContainersOptions{All: true})
+	if err != nil {
+		return
+	}
+	id := findContainerID(containers, name)
+	if id == "" {
+		return errors.New("container " + name + " is not found")
+	}
+	return n.client.StartContainer(id, nil)
+}
+
+// runAction runs the action in background, the stats are polled after it
+func (n *DockerStatusWidget) runAction(action func() error) {
+	go func() {
+		if err := action(); err != nil {
+			n.alert(err, n.Activate)
+			return
+		}
+		select {
+		case n.refresh <- struct{}{}:
+		default:
 		}
 	}()
-	return
 }
 
-// Deactivate is the implementation of Widget.Activate
-func (n *DockerStatusWidget) Deactivate() {
+// openLogs streams the logs of the container under the cursor in a pane until it's closed
+func (n *DockerStatusWidget) openLogs() {
+	t, ok := n.selectedContainer()
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	pane := listable.NewPopup(&listable.PopupOption{
+		Title: "LOGS - " + t.container + " (q: close)",
+	})
+	pane.Open(func() {
+		cancel()
+		n.Activate()
+	})
+	go func() {
+		err := mccdocker.FollowLogs(ctx, n.client, &mccdocker.LogsOption{ID: t.container, Tail: dockerLogsTail}, func(l *mccdocker.LogLine) {
+			pane.AddBody(formatLogLine(l))
+			pane.MoveCursor("bottom")
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			pane.AddBody(" [" + escapeMarkup(err.Error()) + "](fg-red)")
+		} else {
+			pane.AddBody(" [" + strings.Repeat("-", 10) + " the container stopped " + strings.Repeat("-", 10) + "](fg-yellow)")
+		}
+		pane.MoveCursor("bottom")
+	}()
 }
 
 // IsDisabled is the implementation of Widget.IsDisabled
 func (n *DockerStatusWidget) IsDisabled() bool {
-	return true
+	return n.disabled
 }
 
 // IsReady is the implementation of Widget.IsReady
@@ -181,24 +841,18 @@ func (n *DockerStatusWidget) IsReady() bool {
 
 // GetHighlightenPos is the implementation of Widget.GetHighlightenPos
 func (n *DockerStatusWidget) GetHighlightenPos() int {
-	return 100
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.cursor
 }
 
 // GetGridBufferers is the implementation of widget.Activate
 func (n *DockerStatusWidget) GetGridBufferers() []ui.GridBufferer {
-	var gauges []ui.GridBufferer
-	for _, g := range n.gauges {
-		gauges = append(gauges, g.gauge)
-	}
-	return gauges
+	return []ui.GridBufferer{n.stack}
 }
 
-func (n *DockerStatusWidget) readCPU(g *gaugeModel) (cpuPercent float64, err error) {
-	stats, err := n.getStats(g.id)
-	if err != nil {
-		return
-	}
-
+// cpuPercent computes the CPU usage like "docker stats"
+func cpuPercent(stats *docker.Stats) (cpuPercent float64) {
 	var (
 		previousCPU    = stats.PreCPUStats.CPUUsage.TotalUsage
 		previousSystem = stats.PreCPUStats.SystemCPUUsage
@@ -211,42 +865,45 @@ func (n *DockerStatusWidget) readCPU(g *gaugeModel) (cpuPercent float64, err err
 	if systemDelta > 0.0 && cpuDelta > 0.0 {
 		cpuPercent = (cpuDelta / systemDelta) * float64(len(stats.CPUStats.CPUUsage.PercpuUsage)) * 100.0
 	}
-
 	return
 }
 
-func (n *DockerStatusWidget) readMemory(id string) (usage int, memLimit int64, err error) {
-	stats, err := n.getStats(id)
-	if err != nil {
-		return
+// memoryUsage computes the percentage of the memory usage without the page cache
+func memoryUsage(stats *docker.Stats) (usage int, memLimit int64) {
+	used := stats.MemoryStats.Usage
+	if cache := stats.MemoryStats.Stats.Cache; cache < used {
+		used -= cache
 	}
-	memUsage := int64(stats.MemoryStats.Usage - stats.MemoryStats.Stats.Cache)
 	memLimit = int64(stats.MemoryStats.Limit)
-	memPercent := float64(memUsage) / float64(memLimit) * 100
-	usage = int(memPercent)
+	if memLimit > 0 {
+		usage = int(float64(used) / float64(memLimit) * 100)
+	}
 	return
 }
 
+// getStats reads the stats of the container once
 func (n *DockerStatusWidget) getStats(id string) (s *docker.Stats, err error) {
+	ctx, cancel := context.WithTimeout(n.context(), dockerStatsTimeout)
+	defer cancel()
 	errC := make(chan error, 1)
-	statsC := make(chan *docker.Stats)
+	statsC := make(chan *docker.Stats, 1)
 
 	go func() {
 		errC <- n.client.Stats(docker.StatsOptions{
-			ID:     id,
-			Stats:  statsC,
-			Stream: false,
+			ID:      id,
+			Stats:   statsC,
+			Stream:  false,
+			Context: ctx,
 		})
 	}()
 
 	s, ok := <-statsC
-	if !ok {
-		return s, fmt.Errorf("Bad response getting stats for container: %s", id)
-	}
-
 	err = <-errC
 	if err != nil {
-		return s, err
+		return nil, err
+	}
+	if !ok || s == nil {
+		return nil, fmt.Errorf("Bad response getting stats for container: %s", id)
 	}
 	return s, nil
 }
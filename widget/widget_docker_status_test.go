@@ -0,0 +1,446 @@
+package widget
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ui "github.com/gizak/termui"
+)
+
+// dockerAPI mimics the Docker API with the running containers of the names
+type dockerAPI struct {
+	ids    map[string]string // the names to the IDs of the running containers
+	broken map[string]bool   // the IDs whose stats fail
+	labels map[string]string // the names to the compose projects
+	svcs   map[string]string // the names to the compose services
+	exited map[string]string // the names to the IDs of the stopped containers
+	calls  []string          // the requested actions like "restart c1"
+	stats  int               // the number of the requests of the stats
+	mutex  sync.Mutex
+}
+
+func (d *dockerAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodPost {
+		parts := strings.Split(r.URL.Path, "/")
+		d.calls = append(d.calls, parts[len(parts)-1]+" "+parts[len(parts)-2])
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/containers/json") {
+		var items []string
+		if r.URL.Query().Get("all") == "1" {
+			for name, id := range d.exited {
+				items = append(items, `{"Id": "`+id+`", "Names": ["/`+name+`"], "State": "exited"}`)
+			}
+		}
+		for name, id := range d.ids {
+			labels := `{}`
+			if project, ok := d.labels[name]; ok {
+				labels = `{"com.docker.compose.project": "` + project + `", "com.docker.compose.service": "` + d.svcs[name] + `"}`
+			}
+			items = append(items, `{"Id": "`+id+`", "Names": ["/`+name+`"], "Labels": `+labels+`, "State": "running"}`)
+		}
+		w.Write([]byte("[" + strings.Join(items, ",") + "]"))
+		return
+	}
+	for _, id := range d.ids {
+		if strings.HasSuffix(r.URL.Path, "/containers/"+id+"/json") {
+			w.Write([]byte(`{"Id": "` + id + `", "RestartCount": 2, "State": {"Status": "running", "Health": {"Status": "unhealthy"}}}`))
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/containers/"+id+"/stats") {
+			continue
+		}
+		d.stats++
+		if d.broken[id] {
+			http.Error(w, `{"message": "broken"}`, http.StatusInternalServerError)
+			return
+		}
+		// the counters grow by 1MB a second
+		read := time.Date(2018, 5, 1, 0, 0, d.stats, 0, time.UTC).Format(time.RFC3339Nano)
+		w.Write([]byte(fmt.Sprintf(`{
+			"read": "%s",
+			"cpu_stats": {"cpu_usage": {"total_usage": 300, "percpu_usage": [150, 150]}, "system_cpu_usage": 2000},
+			"precpu_stats": {"cpu_usage": {"total_usage": 100}, "system_cpu_usage": 1000},
+			"memory_stats": {"usage": 600000000, "limit": 2000000000, "stats": {"cache": 100000000}},
+			"networks": {"eth0": {"rx_bytes": %d, "tx_bytes": 500}, "eth1": {"rx_bytes": %d, "tx_bytes": 500}},
+			"blkio_stats": {"io_service_bytes_recursive": [{"op": "Read", "value": 4096}, {"op": "Write", "value": %d}]},
+			"pids_stats": {"current": 12}
+		}`, read, d.stats*500000, d.stats*500000, d.stats*1000000)))
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func newDockerStatusWidgetForTest(t *testing.T, host string, content interface{}) *DockerStatusWidget {
+	n, err := NewDockerStatusWidget(&Option{
+		Height:     10,
+		DockerHost: host,
+		Content:    content,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.buildGauges(); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestDockerStatusWidgetUpdate(t *testing.T) {
+	api := &dockerAPI{ids: map[string]string{"web": "c1", "db": "c2"}, broken: map[string]bool{}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	n := newDockerStatusWidgetForTest(t, "tcp://"+srv.Listener.Addr().String(), []map[string]string{
+		{"name": "web", "container": "web", "metrics": "cpu"},
+		{"name": "web", "container": "web", "metrics": "memory"},
+		{"name": "db", "container": "db", "metrics": "cpu"},
+		{"name": "worker", "container": "worker", "metrics": "cpu"},
+	})
+	n.update()
+	// (300-100) / (2000-1000) * 2 CPUs
+	if n.gauges[0].id != "c1" || n.gauges[0].gauge.Percent != 40 || n.gauges[0].gauge.Label != "40.00% " {
+		t.Fatalf("CPU usage of web should be 40%% but %d%%, %q", n.gauges[0].gauge.Percent, n.gauges[0].gauge.Label)
+	}
+	if n.gauges[1].gauge.Percent != 25 || n.gauges[1].gauge.Label != "{{percent}}% (2,000MBs) " {
+		t.Fatalf("memory usage of web should be 25%% of 2,000MBs but %d%%, %q", n.gauges[1].gauge.Percent, n.gauges[1].gauge.Label)
+	}
+	if n.gauges[3].active || n.gauges[3].gauge.Label != "'worker' is not running " {
+		t.Fatalf("worker should be shown as not running but %q", n.gauges[3].gauge.Label)
+	}
+	if api.stats != 2 {
+		t.Fatalf("the stats should be read once for each container but %d times", api.stats)
+	}
+
+	// an error of a container is shown only on its gauges
+	api.broken["c2"] = true
+	n.update()
+	if !strings.Contains(n.gauges[2].gauge.Label, "broken") || n.gauges[2].gauge.Percent != 0 {
+		t.Fatalf("the error should be shown on the gauge of db but %q", n.gauges[2].gauge.Label)
+	}
+	if n.gauges[0].gauge.Label != "40.00% " {
+		t.Fatalf("web should be shown regardless of db but %q", n.gauges[0].gauge.Label)
+	}
+
+	// a restarted container is followed by the new ID
+	api.mutex.Lock()
+	api.ids["db"] = "c3"
+	api.ids["worker"] = "c4"
+	api.mutex.Unlock()
+	n.update()
+	if n.gauges[2].id != "c3" || n.gauges[2].gauge.Label != "40.00% " {
+		t.Fatalf("db should be followed after the restart but %s, %q", n.gauges[2].id, n.gauges[2].gauge.Label)
+	}
+	if !n.gauges[3].active || n.gauges[3].id != "c4" {
+		t.Fatalf("worker should be found after it starts but %+v", n.gauges[3])
+	}
+}
+
+func TestDockerStatusWidgetConnectionError(t *testing.T) {
+	srv := httptest.NewServer(&dockerAPI{})
+	host := "tcp://" + srv.Listener.Addr().String()
+	srv.Close()
+
+	n := newDockerStatusWidgetForTest(t, host, []map[string]string{{"name": "web", "container": "web", "metrics": "cpu"}})
+	n.update()
+	if n.gauges[0].active || !strings.HasPrefix(n.gauges[0].gauge.Label, "cannot connect to "+host) {
+		t.Fatalf("the connection error should be shown on the gauge but %q", n.gauges[0].gauge.Label)
+	}
+}
+
+func TestNewDockerStatusWidgetInterval(t *testing.T) {
+	n, err := NewDockerStatusWidget(&Option{Interval: "5s"})
+	if err != nil || n.interval.String() != "5s" {
+		t.Fatalf("the interval should be 5s but %v, error: %v", n.interval, err)
+	}
+	if _, err = NewDockerStatusWidget(&Option{Interval: "5"}); err == nil {
+		t.Fatal("interval without a unit should be an error")
+	}
+}
+
+func TestDockerStatusWidgetMetrics(t *testing.T) {
+	api := &dockerAPI{ids: map[string]string{"web": "c1"}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	n := newDockerStatusWidgetForTest(t, "tcp://"+srv.Listener.Addr().String(), []map[string]string{
+		{"name": "web", "container": "web", "metrics": "net_rx"},
+		{"name": "web", "container": "web", "metrics": "net_tx", "mode": "total", "max": "4KB"},
+		{"name": "web", "container": "web", "metrics": "block_write", "max": "2MB"},
+		{"name": "web", "container": "web", "metrics": "block_read", "mode": "total"},
+		{"name": "web", "container": "web", "metrics": "pids", "max": "48"},
+		{"name": "web", "container": "web", "metrics": "status"},
+	})
+	n.update()
+	if n.gauges[0].gauge.Label != "measuring... " {
+		t.Fatalf("the rate should be measured from the second poll but %q", n.gauges[0].gauge.Label)
+	}
+	n.update()
+	expected := []struct {
+		percent int
+		label   string
+	}{
+		{100, "1.0 MB/s "},
+		{25, "1.0 kB "},
+		{50, "1.0 MB/s "},
+		{100, "4.1 kB "},
+		{25, "12 "},
+		{100, "running, unhealthy, 2 restarts "},
+	}
+	for i, e := range expected {
+		g := n.gauges[i].gauge
+		if g.Percent != e.percent || g.Label != e.label {
+			t.Fatalf("%s should be %d%% %q but %d%% %q", n.gauges[i].metrics, e.percent, e.label, g.Percent, g.Label)
+		}
+	}
+	if api.stats != 2 {
+		t.Fatalf("the stats should be read once a poll but %d times", api.stats)
+	}
+}
+
+func TestDockerStatusWidgetSelector(t *testing.T) {
+	api := &dockerAPI{
+		ids:    map[string]string{"app_web_1": "c1", "app_web_2": "c2", "other_web_1": "c3", "app_db_1": "c4"},
+		labels: map[string]string{"app_web_1": "app", "app_web_2": "app", "other_web_1": "other", "app_db_1": "app"},
+	}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	n, err := NewDockerStatusWidget(&Option{
+		Height:     10,
+		DockerHost: "tcp://" + srv.Listener.Addr().String(),
+		Content: []map[string]interface{}{
+			{"name": "web", "metrics": "cpu", "selector": map[string]interface{}{"project": "app", "name": "^app_web_"}},
+			{"name": "db", "container": "app_db", "metrics": "cpu"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.buildGauges(); err != nil {
+		t.Fatal(err)
+	}
+	containers := func() (names []string) {
+		for _, g := range n.gauges {
+			names = append(names, g.container)
+		}
+		return
+	}
+
+	n.update()
+	if c := containers(); strings.Join(c, ",") != "app_web_1,app_web_2,app_db" {
+		t.Fatalf("a gauge should be shown for each of the matched containers but %v", c)
+	}
+	if n.gauges[0].gauge.BorderLabel != "web (app_web_1) - CPU Usage" || n.gauges[0].gauge.Label != "40.00% " {
+		t.Fatalf("the gauge of app_web_1 should be shown but %q, %q", n.gauges[0].gauge.BorderLabel, n.gauges[0].gauge.Label)
+	}
+	// the name of a container is matched exactly
+	if n.gauges[2].active || n.gauges[2].gauge.Label != "'app_db' is not running " {
+		t.Fatalf("app_db should not match app_db_1 but %q", n.gauges[2].gauge.Label)
+	}
+
+	// the gauges follow the containers which start and stop
+	web1 := n.gauges[0]
+	api.mutex.Lock()
+	delete(api.ids, "app_web_2")
+	api.ids["app_web_3"] = "c5"
+	api.labels["app_web_3"] = "app"
+	api.mutex.Unlock()
+	n.update()
+	if c := containers(); strings.Join(c, ",") != "app_web_1,app_web_3,app_db" {
+		t.Fatalf("app_web_2 should be replaced with app_web_3 but %v", c)
+	}
+	if n.gauges[0] != web1 {
+		t.Fatal("the gauge of the running container should be kept")
+	}
+
+	api.mutex.Lock()
+	delete(api.ids, "app_web_1")
+	delete(api.ids, "app_web_3")
+	api.mutex.Unlock()
+	n.update()
+	if len(n.gauges) != 2 || n.gauges[0].gauge.Label != "no container matches " {
+		t.Fatalf("the selector without containers should be shown but %v", containers())
+	}
+	if n.gauges[0].gauge.BorderLabel != "web (project=app, name=^app_web_) - CPU Usage" {
+		t.Fatalf("the selector should be described but %q", n.gauges[0].gauge.BorderLabel)
+	}
+}
+
+func TestDockerStatusWidgetSparkline(t *testing.T) {
+	api := &dockerAPI{ids: map[string]string{"web": "c1"}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	n := newDockerStatusWidgetForTest(t, "tcp://"+srv.Listener.Addr().String(), []map[string]interface{}{
+		{"name": "web", "container": "web", "metrics": "cpu"},
+		{"name": "web", "container": "web", "metrics": "net_rx", "sparkline": true, "window": "3s"},
+		{"name": "worker", "container": "worker", "metrics": "memory", "sparkline": true},
+	})
+	if n.gauges[0].spark != nil {
+		t.Fatal("the sparkline should be shown only if it's set")
+	}
+	if s := n.gauges[2].spark; s == nil || s.title != "last 5m" || len(s.history.samples) != 300 {
+		t.Fatalf("the sparkline should keep the samples of 5 minutes by default but %+v", s)
+	}
+	for i := 0; i < 5; i++ {
+		n.update()
+	}
+	// a sample every second in 3 seconds, the first poll only measures the rate
+	if vs := n.gauges[1].spark.history.values(); len(vs) != 3 || vs[2] != 1000000 {
+		t.Fatalf("the rates of the last 3 seconds should be kept but %v", vs)
+	}
+	// a container not running is recorded as 0
+	if vs := n.gauges[2].spark.history.values(); len(vs) != 5 || vs[4] != 0 {
+		t.Fatalf("the samples of worker should be 0 but %v", vs)
+	}
+}
+
+// confirmForTest answers the confirmations of the widget, the questions are recorded
+func confirmForTest(n *DockerStatusWidget, yes bool) *[]string {
+	questions := &[]string{}
+	n.confirm = func(title string, question string, onYes func(), onClose func()) {
+		*questions = append(*questions, question)
+		if yes {
+			onYes()
+		}
+	}
+	return questions
+}
+
+// waitAction waits until the action requested by the widget finishes
+func waitAction(t *testing.T, n *DockerStatusWidget) {
+	select {
+	case <-n.refresh:
+	case <-time.After(3 * time.Second):
+		t.Fatal("the action should finish")
+	}
+}
+
+func TestDockerStatusWidgetActions(t *testing.T) {
+	api := &dockerAPI{ids: map[string]string{"web": "c1"}, exited: map[string]string{"worker": "c2"}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	n := newDockerStatusWidgetForTest(t, "tcp://"+srv.Listener.Addr().String(), []map[string]string{
+		{"name": "web", "container": "web", "metrics": "cpu"},
+		{"name": "worker", "container": "worker", "metrics": "cpu"},
+	})
+	n.update()
+
+	// the cursor stays in the gauges and the one under it is highlightened
+	n.active = true
+	n.moveCursor(-1)
+	if c, ok := n.selectedContainer(); !ok || c.container != "web" || !c.running || n.gauges[0].gauge.BorderFg != ui.ColorGreen {
+		t.Fatalf("the cursor should be on web but %+v", c)
+	}
+
+	// nothing is done unless it's confirmed
+	questions := confirmForTest(n, false)
+	n.restart()
+	n.stopOrStart()
+	if len(*questions) != 2 || (*questions)[0] != "Restart the container web?" || (*questions)[1] != "Stop the container web?" {
+		t.Fatalf("restarting and stopping should be confirmed but %v", *questions)
+	}
+	questions = confirmForTest(n, true)
+	n.restart()
+	waitAction(t, n)
+	n.stopOrStart()
+	waitAction(t, n)
+
+	// a stopped container is started without the confirmation, it's not restarted
+	n.moveCursor(1)
+	n.moveCursor(1)
+	if c, ok := n.selectedContainer(); !ok || c.container != "worker" || c.running || n.gauges[0].gauge.BorderFg != ui.ColorBlue {
+		t.Fatalf("the cursor should be on worker but %+v", c)
+	}
+	n.restart()
+	n.stopOrStart()
+	waitAction(t, n)
+	if len(*questions) != 2 {
+		t.Fatalf("starting a container should not be confirmed but %v", *questions)
+	}
+	api.mutex.Lock()
+	calls := strings.Join(api.calls, ",")
+	api.mutex.Unlock()
+	if calls != "restart c1,stop c1,start c2" {
+		t.Fatalf("web should be restarted and stopped, and worker started but %v", calls)
+	}
+
+	// an error of the action is shown
+	failed := make(chan error, 1)
+	n.alert = func(err error, onClose func()) {
+		failed <- err
+	}
+	n.runAction(func() error {
+		return n.startContainer("db")
+	})
+	select {
+	case err := <-failed:
+		if err.Error() != "container db is not found" {
+			t.Fatalf("the container not found should be an error but %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("the error should be shown")
+	}
+}
+
+func TestDockerStatusWidgetStopSelected(t *testing.T) {
+	api := &dockerAPI{
+		ids:    map[string]string{"app_web_1": "c1", "app_web_2": "c2"},
+		labels: map[string]string{"app_web_1": "app", "app_web_2": "app"},
+		exited: map[string]string{},
+	}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	n := newDockerStatusWidgetForTest(t, "tcp://"+srv.Listener.Addr().String(), []map[string]interface{}{
+		{"name": "web", "metrics": "cpu", "selector": map[string]interface{}{"project": "app"}},
+	})
+	n.update()
+	confirmForTest(n, true)
+	n.stopOrStart()
+	waitAction(t, n)
+
+	// the container stopped by the widget is kept to be started again
+	api.mutex.Lock()
+	delete(api.ids, "app_web_1")
+	api.exited["app_web_1"] = "c1"
+	api.mutex.Unlock()
+	n.update()
+	if len(n.gauges) != 2 || n.gauges[0].container != "app_web_1" || n.gauges[0].gauge.Label != "'app_web_1' is not running " {
+		t.Fatalf("the stopped container should be shown but %+v", n.gauges[0])
+	}
+	n.stopOrStart()
+	waitAction(t, n)
+	api.mutex.Lock()
+	calls := strings.Join(api.calls, ",")
+	api.ids["app_web_1"] = "c1"
+	delete(api.exited, "app_web_1")
+	api.mutex.Unlock()
+	if calls != "stop c1,start c1" {
+		t.Fatalf("app_web_1 should be stopped and started but %v", calls)
+	}
+	n.update()
+	if !n.gauges[0].active || len(n.stopped) != 0 {
+		t.Fatalf("the started container should be followed again but %+v", n.gauges[0])
+	}
+
+	// a container stopped outside is not kept
+	api.mutex.Lock()
+	delete(api.ids, "app_web_2")
+	api.mutex.Unlock()
+	n.update()
+	if len(n.gauges) != 1 {
+		t.Fatalf("only app_web_1 should be shown but %d gauges", len(n.gauges))
+	}
+}
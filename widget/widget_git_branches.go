@@ -0,0 +1,260 @@
+package widget
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	ui "github.com/gizak/termui"
+	mccgit "github.com/qmu/mcc/git"
+	"github.com/qmu/mcc/widget/listable"
+)
+
+// GitBranchesWidget lists the local and remote branches, Enter checks out the branch under the cursor
+type GitBranchesWidget struct {
+	lifecycle
+	options  *Option
+	renderer *listable.ListWrapper
+	location *time.Location
+	repo     *mccgit.Repository
+	branches []*mccgit.Branch
+	active   bool
+	isReady  bool
+	disabled bool
+	mutex    sync.Mutex
+}
+
+// NewGitBranchesWidget constructs a New GitBranchesWidget
+func NewGitBranchesWidget(opt *Option) (g *GitBranchesWidget, err error) {
+	g = new(GitBranchesWidget)
+	g.options = opt
+	if g.location, err = time.LoadLocation(opt.Timezone); err != nil {
+		g.location, err = time.Local, nil
+	}
+	return
+}
+
+// Init is the implementation of widget.Init
+func (g *GitBranchesWidget) Init() (err error) {
+	g.renderer = listable.NewListWrapper(&listable.ListWrapperOption{
+		Title:         g.options.GetTitle(),
+		RealHeight:    g.options.GetHeight(),
+		Header:        g.buildHeader(),
+		LineHighLight: true,
+	})
+	g.isReady = true
+
+	if g.repo, err = mccgit.NewRepository(g.options.GetRepoPath()); err != nil {
+		// the widget is not focused without a repository
+		g.Disable()
+		g.renderer.SetBody([]string{" [" + escapeMarkup(err.Error()) + "](fg-blue)"})
+		return nil
+	}
+	go func() {
+		g.refresh()
+		// the branches are shown without refreshing if the refs can't be watched
+		if w, err := mccgit.NewWatcher(&mccgit.WatcherOption{
+			Repository: g.repo,
+			RefsOnly:   true,
+			OnChange:   g.refresh,
+		}); err == nil {
+			g.keep(w)
+		}
+	}()
+	return
+}
+
+// refresh reloads the branches, the cursor stays on the same branch
+func (g *GitBranchesWidget) refresh() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	selected := ""
+	if cursor := g.renderer.GetCursor(); cursor < len(g.branches) {
+		selected = g.branches[cursor].Name
+	}
+
+	branches, err := g.repo.Branches()
+	if err != nil {
+		g.branches = nil
+		g.renderer.SetBody([]string{" [" + escapeMarkup(err.Error()) + "](fg-red)"})
+	} else if len(branches) == 0 {
+		g.branches = nil
+		g.renderer.SetBody([]string{" no branch"})
+	} else {
+		g.branches = branches
+		header, body := g.buildBody(time.Now())
+		g.renderer.SetHeader(header)
+		g.renderer.SetBody(body)
+	}
+	for i, b := range g.branches {
+		if b.Name == selected {
+			g.renderer.SetCursor(i)
+		}
+	}
+	if g.active {
+		g.renderer.Render()
+	} else {
+		g.renderer.Deactivate()
+	}
+}
+
+func (g *GitBranchesWidget) buildHeader() []string {
+	return g.buildColumnHeader(len("BRANCH"), len("DATE"), len("UPSTREAM"))
+}
+
+func (g *GitBranchesWidget) buildColumnHeader(nameWidth int, dateWidth int, upstreamWidth int) []string {
+	return []string{
+		" [  " + fitWidth("BRANCH", nameWidth) + " " + fitWidth("DATE", dateWidth) + " " + fitWidth("UPSTREAM", upstreamWidth) + " SUBJECT](fg-blue)",
+		" [" + strings.Repeat("-", 500) + "](fg-blue)",
+	}
+}
+
+// buildBody builds the lines of the branches and the header aligned with them
+func (g *GitBranchesWidget) buildBody(now time.Time) (header []string, body []string) {
+	nameWidth, dateWidth, upstreamWidth := len("BRANCH"), len("DATE"), len("UPSTREAM")
+	dates := make([]string, len(g.branches))
+	upstreams := make([]string, len(g.branches))
+	for i, b := range g.branches {
+		if n := utf8.RuneCountInString(b.Name); n > nameWidth {
+			nameWidth = n
+		}
+		dates[i] = relativeDate(b.When, now, g.location)
+		if len(dates[i]) > dateWidth {
+			dateWidth = len(dates[i])
+		}
+		upstreams[i] = upstreamLabel(b)
+		if n := utf8.RuneCountInString(upstreams[i]); n > upstreamWidth {
+			upstreamWidth = n
+		}
+	}
+	for i, b := range g.branches {
+		mark := " "
+		name := "[" + fitWidth(escapeMarkup(b.Name), nameWidth) + "]"
+		switch {
+		case b.Head:
+			mark = "[*](fg-green)"
+			name += "(fg-green,fg-bold)"
+		case b.Remote:
+			name += "(fg-red)"
+		default:
+			name += "(fg-white)"
+		}
+		upstream := upstreams[i] + strings.Repeat(" ", upstreamWidth-utf8.RuneCountInString(upstreams[i]))
+		body = append(body, " "+mark+" "+name+
+			" ["+dates[i]+strings.Repeat(" ", dateWidth-len(dates[i]))+"](fg-blue)"+
+			" "+upstream+
+			" "+escapeMarkup(b.Subject))
+	}
+	return g.buildColumnHeader(nameWidth, dateWidth, upstreamWidth), body
+}
+
+// upstreamLabel shows the upstream of a local branch with the ahead/behind counts
+func upstreamLabel(b *mccgit.Branch) (label string) {
+	if b.Upstream == "" {
+		return
+	}
+	label = b.Upstream
+	if b.Ahead > 0 {
+		label += " ↑" + strconv.Itoa(b.Ahead)
+	}
+	if b.Behind > 0 {
+		label += " ↓" + strconv.Itoa(b.Behind)
+	}
+	return
+}
+
+// selectedBranch returns the branch under the cursor
+func (g *GitBranchesWidget) selectedBranch() *mccgit.Branch {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	cursor := g.renderer.GetCursor()
+	if g.repo == nil || cursor >= len(g.branches) {
+		return nil
+	}
+	return g.branches[cursor]
+}
+
+// checkout checks out the branch under the cursor, it asks before that if the worktree has changes
+func (g *GitBranchesWidget) checkout() {
+	b := g.selectedBranch()
+	if b == nil || b.Head {
+		return
+	}
+	dirty, err := g.repo.IsDirty()
+	if err != nil {
+		openError(err, g.Activate)
+		return
+	}
+	run := func() {
+		if err := g.repo.Checkout(b); err != nil {
+			openError(err, g.Activate)
+			return
+		}
+		g.refresh()
+	}
+	if !dirty {
+		run()
+		return
+	}
+	openConfirm("CHECKOUT", "The worktree has changes, check out "+escapeMarkup(b.Name)+" carrying them over?", run, g.Activate)
+}
+
+// Activate is the implementation of Widget.Activate
+func (g *GitBranchesWidget) Activate() {
+	g.mutex.Lock()
+	g.active = true
+	g.mutex.Unlock()
+	ui.Handle("/sys/kbd/<enter>", func(ui.Event) {
+		g.checkout()
+	})
+	g.renderer.Activate()
+}
+
+// Deactivate is the implementation of Widget.Deactivate
+func (g *GitBranchesWidget) Deactivate() {
+	g.mutex.Lock()
+	g.active = false
+	g.mutex.Unlock()
+	g.renderer.Deactivate()
+}
+
+// IsDisabled is the implementation of Widget.IsDisabled
+func (g *GitBranchesWidget) IsDisabled() bool {
+	return g.disabled
+}
+
+// IsReady is the implementation of Widget.IsReady
+func (g *GitBranchesWidget) IsReady() bool {
+	return g.isReady
+}
+
+// GetHighlightenPos is the implementation of Widget.GetHighlightenPos
+func (g *GitBranchesWidget) GetHighlightenPos() int {
+	return g.renderer.GetCursor()
+}
+
+// GetGridBufferers is the implementation of Widget.GetGridBufferers
+func (g *GitBranchesWidget) GetGridBufferers() []ui.GridBufferer {
+	return []ui.GridBufferer{g.renderer.GetWidget()}
+}
+
+// GetWidth is the implementation of widget.Init
+func (g *GitBranchesWidget) GetWidth() int {
+	return g.renderer.GetWidth()
+}
+
+// GetHeight is the implementation of widget.Init
+func (g *GitBranchesWidget) GetHeight() int {
+	return g.renderer.GetHeight()
+}
+
+// Disable is the implementation of Widget.Disable
+func (g *GitBranchesWidget) Disable() {
+	g.disabled = true
+}
+
+// SetOption is
+func (g *GitBranchesWidget) SetOption(opt *AdditionalWidgetOption) {
+}
@@ -0,0 +1,31 @@
+package widget
+
+import (
+	"testing"
+	"time"
+
+	mccgit "github.com/qmu/mcc/git"
+)
+
+func TestGitBranchesBuildBody(t *testing.T) {
+	g := &GitBranchesWidget{location: time.UTC}
+	when := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	g.branches = []*mccgit.Branch{
+		{Name: "master", Head: true, When: when, Subject: "fix [ci]", Upstream: "origin/master", Ahead: 1, Behind: 2},
+		{Name: "origin/feature", Remote: true, When: when.Add(-24 * time.Hour), Subject: "add"},
+	}
+	header, body := g.buildBody(when)
+	expected := []string{
+		" [  BRANCH         DATE            UPSTREAM            SUBJECT](fg-blue)",
+		" [*](fg-green) [master        ](fg-green,fg-bold) [today 12:00    ](fg-blue) origin/master ↑1 ↓2 fix (ci)",
+		"   [origin/feature](fg-red) [yesterday 12:00](fg-blue)                     add",
+	}
+	if header[0] != expected[0] {
+		t.Fatalf("the header should be\n%s\nbut\n%s", expected[0], header[0])
+	}
+	for i, l := range body {
+		if l != expected[i+1] {
+			t.Fatalf("the line should be\n%s\nbut\n%s", expected[i+1], l)
+		}
+	}
+}
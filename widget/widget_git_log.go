@@ -0,0 +1,288 @@
+package widget
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	ui "github.com/gizak/termui"
+	m2s "github.com/mitchellh/mapstructure"
+	mccgit "github.com/qmu/mcc/git"
+	"github.com/qmu/mcc/widget/listable"
+)
+
+const maxAuthorWidth = 16
+
+// GitLogWidget shows the recent commits with the graph of the branches
+type GitLogWidget struct {
+	lifecycle
+	options  *Option
+	renderer *listable.ListWrapper
+	logOpt   *mccgit.LogOption
+	location *time.Location
+	repo     *mccgit.Repository
+	commits  []*mccgit.Commit
+	lines    []int // the index of the commit of each line
+	active   bool
+	isReady  bool
+	disabled bool
+	mutex    sync.Mutex
+}
+
+// NewGitLogWidget constructs a New GitLogWidget
+func NewGitLogWidget(opt *Option) (g *GitLogWidget, err error) {
+	g = new(GitLogWidget)
+	g.options = opt
+	g.logOpt = new(mccgit.LogOption)
+	if opt.Content != nil {
+		if err = m2s.Decode(opt.Content, g.logOpt); err != nil {
+			return
+		}
+	}
+	if g.location, err = time.LoadLocation(opt.Timezone); err != nil {
+		g.location, err = time.Local, nil
+	}
+	return
+}
+
+// Init is the implementation of widget.Init
+func (g *GitLogWidget) Init() (err error) {
+	g.renderer = listable.NewListWrapper(&listable.ListWrapperOption{
+		Title:         g.options.GetTitle(),
+		RealHeight:    g.options.GetHeight(),
+		Header:        g.buildHeader(),
+		LineHighLight: true,
+	})
+	g.isReady = true
+
+	if g.repo, err = mccgit.NewRepository(g.options.GetRepoPath()); err != nil {
+		// the widget is not focused without a repository
+		g.Disable()
+		g.renderer.SetBody([]string{" [" + escapeMarkup(err.Error()) + "](fg-blue)"})
+		return nil
+	}
+	go func() {
+		g.refresh()
+		// the log is shown without refreshing if the refs can't be watched
+		if w, err := mccgit.NewWatcher(&mccgit.WatcherOption{
+			Repository: g.repo,
+			RefsOnly:   true,
+			OnChange:   g.refresh,
+		}); err == nil {
+			g.keep(w)
+		}
+	}()
+	return
+}
+
+// refresh reloads the commits, the cursor stays on the same commit
+func (g *GitLogWidget) refresh() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	selected := ""
+	if c := g.selectedCommit(); c != nil {
+		selected = c.Hash.String()
+	}
+
+	commits, err := g.repo.Log(g.logOpt)
+	if err != nil {
+		g.commits, g.lines = nil, nil
+		g.renderer.SetBody([]string{" [" + escapeMarkup(err.Error()) + "](fg-red)"})
+	} else if len(commits) == 0 {
+		g.commits, g.lines = nil, nil
+		g.renderer.SetBody([]string{" no commit"})
+	} else {
+		g.commits = commits
+		g.renderer.SetBody(g.buildBody(time.Now()))
+	}
+	for i, c := range g.lines {
+		if g.commits[c].Hash.String() == selected {
+			g.renderer.SetCursor(i)
+		}
+	}
+	if g.active {
+		g.renderer.Render()
+	} else {
+		g.renderer.Deactivate()
+	}
+}
+
+func (g *GitLogWidget) buildHeader() []string {
+	ref := g.logOpt.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	line := " [REF  :](fg-blue) " + escapeMarkup(ref)
+	if g.logOpt.Path != "" {
+		line += " [| PATH :](fg-blue) " + escapeMarkup(g.logOpt.Path)
+	}
+	return []string{
+		line,
+		" [" + strings.Repeat("-", 500) + "](fg-blue)",
+	}
+}
+
+// buildBody builds the lines of the graph and the commits, g.lines maps them to the commits
+func (g *GitLogWidget) buildBody(now time.Time) (body []string) {
+	g.lines = nil
+	graphWidth, authorWidth, dateWidth := 0, 0, 0
+	dates := make([]string, len(g.commits))
+	for i, c := range g.commits {
+		for _, l := range c.Graph {
+			if len(l) > graphWidth {
+				graphWidth = len(l)
+			}
+		}
+		if n := utf8.RuneCountInString(c.Author); n > authorWidth {
+			authorWidth = n
+		}
+		dates[i] = relativeDate(c.When, now, g.location)
+		if len(dates[i]) > dateWidth {
+			dateWidth = len(dates[i])
+		}
+	}
+	if authorWidth > maxAuthorWidth {
+		authorWidth = maxAuthorWidth
+	}
+	for i, c := range g.commits {
+		for j, l := range c.Graph {
+			graph := "[" + l + strings.Repeat(" ", graphWidth-len(l)) + "](fg-magenta)"
+			g.lines = append(g.lines, i)
+			if j < len(c.Graph)-1 {
+				body = append(body, " "+graph)
+				continue
+			}
+			body = append(body, " "+graph+
+				" ["+c.Hash.String()[:7]+"](fg-yellow)"+
+				" ["+fitWidth(escapeMarkup(c.Author), authorWidth)+"](fg-green)"+
+				" ["+dates[i]+strings.Repeat(" ", dateWidth-len(dates[i]))+"](fg-blue)"+
+				" "+escapeMarkup(c.Subject))
+		}
+	}
+	return
+}
+
+// fitWidth pads or cuts the string to the width
+func fitWidth(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+// relativeDate formats the time relative to now by the days in the location
+func relativeDate(t time.Time, now time.Time, loc *time.Location) string {
+	t, now = t.In(loc), now.In(loc)
+	day := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	days := int(day(now).Sub(day(t)).Hours()/24 + 0.5)
+	switch {
+	case days == 0:
+		return "today " + t.Format("15:04")
+	case days == 1:
+		return "yesterday " + t.Format("15:04")
+	case days > 1 && days < 7:
+		return t.Format("Mon 15:04")
+	case t.Year() == now.Year():
+		return t.Format("Jan 2")
+	}
+	return t.Format("2006-01-02")
+}
+
+// selectedCommit returns the commit of the line under the cursor, nil if there is no commit
+func (g *GitLogWidget) selectedCommit() *mccgit.Commit {
+	cursor := g.renderer.GetCursor()
+	if cursor >= len(g.lines) {
+		return nil
+	}
+	return g.commits[g.lines[cursor]]
+}
+
+// openCommit shows the message, the stats and the diff of the commit under the cursor
+func (g *GitLogWidget) openCommit() {
+	g.mutex.Lock()
+	c := g.selectedCommit()
+	g.mutex.Unlock()
+	if c == nil {
+		return
+	}
+	text, err := g.repo.Show(c.Hash)
+	if err != nil {
+		text = "ERROR: " + err.Error()
+	}
+	header := "commit " + c.Hash.String() + "\n"
+	if len(c.Parents) > 1 {
+		header += "Merge: "
+		for _, p := range c.Parents {
+			header += " " + p.String()[:7]
+		}
+		header += " (the diff from the first parent)\n"
+	}
+	header += "Author: " + c.Author + " <" + c.Email + ">\n"
+	header += "Date:   " + c.When.In(g.location).Format("Mon Jan 2 15:04:05 2006 -0700") + "\n\n"
+	for _, l := range strings.Split(c.Message, "\n") {
+		header += "    " + l + "\n"
+	}
+	newDiffPane(c.Hash.String()[:7]+" "+c.Subject, header+"\n"+text).Open(g.Activate)
+}
+
+// Activate is the implementation of Widget.Activate
+func (g *GitLogWidget) Activate() {
+	g.mutex.Lock()
+	g.active = true
+	g.mutex.Unlock()
+	ui.Handle("/sys/kbd/<enter>", func(ui.Event) {
+		g.openCommit()
+	})
+	g.renderer.Activate()
+}
+
+// Deactivate is the implementation of Widget.Deactivate
+func (g *GitLogWidget) Deactivate() {
+	g.mutex.Lock()
+	g.active = false
+	g.mutex.Unlock()
+	g.renderer.Deactivate()
+}
+
+// IsDisabled is the implementation of Widget.IsDisabled
+func (g *GitLogWidget) IsDisabled() bool {
+	return g.disabled
+}
+
+// IsReady is the implementation of Widget.IsReady
+func (g *GitLogWidget) IsReady() bool {
+	return g.isReady
+}
+
+// GetHighlightenPos is the implementation of Widget.GetHighlightenPos
+func (g *GitLogWidget) GetHighlightenPos() int {
+	return g.renderer.GetCursor()
+}
+
+// GetGridBufferers is the implementation of Widget.GetGridBufferers
+func (g *GitLogWidget) GetGridBufferers() []ui.GridBufferer {
+	return []ui.GridBufferer{g.renderer.GetWidget()}
+}
+
+// GetWidth is the implementation of widget.Init
+func (g *GitLogWidget) GetWidth() int {
+	return g.renderer.GetWidth()
+}
+
+// GetHeight is the implementation of widget.Init
+func (g *GitLogWidget) GetHeight() int {
+	return g.renderer.GetHeight()
+}
+
+// Disable is the implementation of Widget.Disable
+func (g *GitLogWidget) Disable() {
+	g.disabled = true
+}
+
+// SetOption is
+func (g *GitLogWidget) SetOption(opt *AdditionalWidgetOption) {
+}
@@ -0,0 +1,55 @@
+package widget
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	mccgit "github.com/qmu/mcc/git"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func TestRelativeDate(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+	now := time.Date(2018, 5, 10, 1, 0, 0, 0, tokyo)
+	cases := []struct {
+		t        time.Time
+		expected string
+	}{
+		{time.Date(2018, 5, 10, 0, 30, 0, 0, tokyo), "today 00:30"},
+		// 2018-05-09 15:30 in UTC is 2018-05-10 in Tokyo
+		{time.Date(2018, 5, 9, 15, 30, 0, 0, time.UTC), "today 00:30"},
+		{time.Date(2018, 5, 9, 14, 30, 0, 0, time.UTC), "yesterday 23:30"},
+		{time.Date(2018, 5, 6, 12, 0, 0, 0, tokyo), "Sun 12:00"},
+		{time.Date(2018, 1, 2, 12, 0, 0, 0, tokyo), "Jan 2"},
+		{time.Date(2017, 12, 31, 12, 0, 0, 0, tokyo), "2017-12-31"},
+	}
+	for _, c := range cases {
+		if s := relativeDate(c.t, now, tokyo); s != c.expected {
+			t.Fatalf("%v should be %q but %q", c.t, c.expected, s)
+		}
+	}
+}
+
+func TestGitLogBuildBody(t *testing.T) {
+	g := &GitLogWidget{location: time.UTC}
+	when := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	g.commits = []*mccgit.Commit{
+		{Hash: plumbing.NewHash("1111111111111111111111111111111111111111"), Author: "a", When: when, Subject: "merge [skip ci]", Graph: []string{"*"}},
+		{Hash: plumbing.NewHash("2222222222222222222222222222222222222222"), Author: "a very long author name", When: when, Subject: "side", Graph: []string{"|\\", "| *"}},
+	}
+	body := g.buildBody(when.Add(time.Hour))
+	if len(body) != 3 || len(g.lines) != 3 || g.lines[1] != 1 || g.lines[2] != 1 {
+		t.Fatalf("the graph line should belong to the next commit but %v %v", body, g.lines)
+	}
+	expected := " [*  ](fg-magenta) [1111111](fg-yellow) [a               ](fg-green) [today 12:00](fg-blue) merge (skip ci)"
+	if body[0] != expected {
+		t.Fatalf("the line should be\n%s\nbut\n%s", expected, body[0])
+	}
+	if !strings.HasPrefix(body[2], " [| *](fg-magenta) [2222222](fg-yellow) [a very long aut…](fg-green)") {
+		t.Fatalf("the author should be cut but %s", body[2])
+	}
+}
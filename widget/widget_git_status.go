@@ -5,23 +5,30 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"unicode/utf8"
 
 	ui "github.com/gizak/termui"
-	"github.com/qmu/mcc/utils"
+	mccgit "github.com/qmu/mcc/git"
 	"github.com/qmu/mcc/widget/listable"
 	"gopkg.in/src-d/go-git.v4"
 )
 
-// GitStatusWidget is a command launcher
+// GitStatusWidget shows the status of the worktree and refreshes it when files are changed
 type GitStatusWidget struct {
+	lifecycle
 	options     *Option
 	renderer    *listable.ListWrapper
 	isReady     bool
 	disabled    bool
 	statusItems StatusItems
+	active      bool
+	repo        *mccgit.Repository
+	mutex       sync.Mutex
 }
 
 // NewGitStatusWidget constructs a New GitStatusWidget
@@ -44,95 +51,114 @@ func (g *GitStatusWidget) Init() (err error) {
 	g.renderer = listable.NewListWrapper(lopt)
 	g.isReady = true
 
+	if g.repo, err = mccgit.NewRepository(g.options.GetRepoPath()); err != nil {
+		// the widget is not focused without a repository
+		g.Disable()
+		g.renderer.SetBody([]string{" [" + escapeMarkup(err.Error()) + "](fg-blue)"})
+		return nil
+	}
 	go func() {
-		body, err := g.buildBody(g.options.ExecPath)
-		if err != nil {
-			return
-		}
-		if body == nil {
-			body = []string{
-				"Worktree is clean",
-			}
+		g.refresh()
+		// the status is shown without refreshing if the files can't be watched
+		if w, err := mccgit.NewWatcher(&mccgit.WatcherOption{
+			Repository: g.repo,
+			OnChange:   g.refresh,
+		}); err == nil {
+			g.keep(w)
 		}
-		g.renderer.SetBody(body)
-		g.renderer.ResetRender()
 	}()
 
 	return
 }
 
+// refresh recomputes the status, the cursor stays on the same file
+func (g *GitStatusWidget) refresh() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	var selected StatusItem
+	if cursor := g.renderer.GetCursor(); cursor < len(g.statusItems) {
+		selected = g.statusItems[cursor]
+	}
+
+	body, err := g.buildBody()
+	if err != nil {
+		body = []string{" [" + err.Error() + "](fg-red)"}
+	} else if body == nil {
+		body = []string{
+			"Worktree is clean",
+		}
+	}
+	g.renderer.SetHeader(g.buildHeader())
+	g.renderer.SetBody(body)
+	for i, item := range g.statusItems {
+		if item.Path == selected.Path && item.Staged == selected.Staged {
+			g.renderer.SetCursor(i)
+		}
+	}
+	if g.active {
+		g.renderer.Render()
+	} else {
+		g.renderer.Deactivate()
+	}
+}
+
 func (g *GitStatusWidget) buildHeader() (header []string) {
 	n1, n2, n3 := g.getLongest()
 	c1 := g.fillSpaces("STAGE ", n1)
 	c2 := g.fillSpaces("STATUS ", n2)
 	c3 := g.fillSpaces("PATH ", n3)
 	header = []string{
+		g.buildHeadLine(),
 		" [" + c1 + " | " + c2 + " | " + c3 + "](fg-blue)\n",
 		" [" + strings.Repeat("-", 500) + "](fg-blue)\n"}
 	return
 }
 
-func (g *GitStatusWidget) buildBody(execPath string) (result []string, err error) {
-	status, err := g.getStatus(execPath)
+// buildHeadLine shows the branch, the ahead/behind counts of the upstream and the number of stashes
+func (g *GitStatusWidget) buildHeadLine() string {
+	if g.repo == nil {
+		return " [BRANCH :](fg-blue) -"
+	}
+	h, err := g.repo.Head()
 	if err != nil {
-		return
+		return " [BRANCH :](fg-blue) -"
 	}
-	if status.IsClean() {
-		return nil, err
+	line := " [BRANCH :](fg-blue) " + h.Branch
+	if h.Detached {
+		line += " [(detached)](fg-yellow)"
 	}
-
-	for path, s := range status {
-		if s.Staging == git.Unmodified && s.Worktree == git.Unmodified {
-			continue
-		}
-		if s.Staging == git.Renamed {
-			path = fmt.Sprintf("%s -> %s", path, s.Extra)
+	if h.Upstream != "" {
+		line += " [-> " + h.Upstream + "](fg-blue)"
+		if h.Ahead > 0 {
+			line += " [↑" + strconv.Itoa(h.Ahead) + "](fg-green)"
 		}
-
-		var gs git.StatusCode
-		var stage string
-		if s.Staging != git.Unmodified {
-			gs = s.Staging
-			stage = "STAGED"
-		} else {
-			gs = s.Worktree
-			stage = "UNSTAGED"
+		if h.Behind > 0 {
+			line += " [↓" + strconv.Itoa(h.Behind) + "](fg-red)"
 		}
-		var statusStr string
-		var statusNo int
-		if gs == git.Untracked {
-			statusStr = "New File"
-			statusNo = 1
-		} else if gs == git.Modified {
-			statusStr = "Modified"
-			statusNo = 2
-		} else if gs == git.Added {
-			statusStr = "Added"
-			statusNo = 3
-		} else if gs == git.Deleted {
-			statusStr = "Deleted"
-			statusNo = 4
-		} else if gs == git.Renamed {
-			statusStr = "Renamed"
-			statusNo = 5
-		} else if gs == git.Copied {
-			statusStr = "Copied"
-			statusNo = 6
-		} else if gs == git.UpdatedButUnmerged {
-			statusStr = "UpdatedButUnmerged"
-			statusNo = 7
+		if h.Ahead == 0 && h.Behind == 0 {
+			line += " up to date"
 		}
-		g.statusItems = append(g.statusItems, StatusItem{
-			Staged:   s.Staging != git.Unmodified,
-			Stage:    stage,
-			Status:   statusStr,
-			StatusNo: statusNo,
-			Path:     path,
-		})
 	}
+	if h.Stashes > 0 {
+		line += " [| STASH :](fg-blue) " + strconv.Itoa(h.Stashes)
+	}
+	return line
+}
+
+func (g *GitStatusWidget) buildBody() (result []string, err error) {
+	g.statusItems = nil
+	status, err := g.repo.Status()
+	if err != nil {
+		return
+	}
+	if status.IsClean() {
+		return nil, err
+	}
+
+	g.statusItems = newStatusItems(status)
 	// sort
 	sort.Sort(ByPath{g.statusItems})
-	sort.Sort(ByStage{g.statusItems})
+	sort.Stable(ByStage{g.statusItems})
 
 	// build body
 	n1, n2, _ := g.getLongest()
@@ -152,24 +178,6 @@ func (g *GitStatusWidget) buildBody(execPath string) (result []string, err error
 	return
 }
 
-func (g *GitStatusWidget) getStatus(execPath string) (status git.Status, err error) {
-	// Load worktree status
-	dotGitPath, err := utils.GetDotGitPath(execPath)
-	r, err := git.PlainOpen(dotGitPath)
-	if err != nil {
-		return
-	}
-	w, err := r.Worktree()
-	if err != nil {
-		return
-	}
-	status, err = w.Status()
-	if err != nil {
-		return
-	}
-	return
-}
-
 func (g *GitStatusWidget) fillSpaces(s string, longest int) string {
 	var l = longest - utf8.RuneCountInString(s)
 	for i := 0; i < l; i++ {
@@ -201,15 +209,118 @@ func (g *GitStatusWidget) getLongest() (n1 int, n2 int, n3 int) {
 
 // Activate is the implementation of Widget.Activate
 func (g *GitStatusWidget) Activate() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.active = true
 	g.setKeyBindings()
 	g.renderer.Activate()
 }
 
 // Deactivate is the implementation of Widget.Activate
 func (g *GitStatusWidget) Deactivate() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.active = false
+	// termui can't remove a handler, the keys do nothing in the other widgets
+	for _, key := range gitStatusKeys {
+		ui.Handle("/sys/kbd/"+key, func(ui.Event) {})
+	}
 	g.renderer.Deactivate()
 }
 
+// selectedItem returns the StatusItem under the cursor
+func (g *GitStatusWidget) selectedItem() (item StatusItem, ok bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	cursor := g.renderer.GetCursor()
+	if g.repo == nil || cursor >= len(g.statusItems) {
+		return
+	}
+	return g.statusItems[cursor], true
+}
+
+// act runs a git operation on the StatusItem under the cursor and refreshes the list
+func (g *GitStatusWidget) act(fn func(item StatusItem) error) {
+	item, ok := g.selectedItem()
+	if !ok {
+		return
+	}
+	if err := fn(item); err != nil {
+		g.openError(err)
+		return
+	}
+	g.refresh()
+}
+
+// confirmDiscard asks before discarding the changes of the StatusItem under the cursor
+func (g *GitStatusWidget) confirmDiscard() {
+	item, ok := g.selectedItem()
+	if !ok {
+		return
+	}
+	openConfirm("DISCARD", discardQuestion(item), func() {
+		g.act(func(item StatusItem) error {
+			return g.repo.Discard(item.File, item.Staged)
+		})
+	}, g.Activate)
+}
+
+// discardQuestion tells what is lost by discarding the item,
+// a file which is not in HEAD like an added or untracked one is deleted
+func discardQuestion(item StatusItem) string {
+	file := escapeMarkup(item.File)
+	switch {
+	case item.StatusNo == 1:
+		return "Delete the untracked file " + file + "?"
+	case item.Staged && (item.StatusNo == 3 || item.StatusNo == 5 || item.StatusNo == 6):
+		// the added, renamed or copied file is unstaged and removed from the worktree
+		return "Unstage and delete the file " + file + "? It's not in HEAD"
+	case item.Staged:
+		return "Discard the staged and unstaged changes of " + file + "?"
+	}
+	return "Discard the changes of " + file + "?"
+}
+
+// openCommitEditor commits the staged changes with the message written in an editor
+func (g *GitStatusWidget) openCommitEditor() {
+	if g.repo == nil {
+		return
+	}
+	e := listable.NewEditor(&listable.EditorOption{
+		Title: "COMMIT MESSAGE",
+	})
+	e.Open(func(message string) error {
+		if _, err := g.repo.Commit(message); err != nil {
+			return err
+		}
+		g.refresh()
+		return nil
+	}, g.Activate)
+}
+
+// openDiff shows the diff of the side of the StatusItem under the cursor,
+// the staged row of a partially staged file shows the index and the unstaged row shows the worktree
+func (g *GitStatusWidget) openDiff() {
+	item, ok := g.selectedItem()
+	if !ok {
+		return
+	}
+	text, err := g.repo.Diff(item.File, item.Staged)
+	if err != nil {
+		g.openError(err)
+		return
+	}
+	title := "UNSTAGED - " + item.File
+	if item.Staged {
+		title = "STAGED - " + item.File
+	}
+	newDiffPane(title, text).Open(g.Activate)
+}
+
+func (g *GitStatusWidget) openError(err error) {
+	openError(err, g.Activate)
+}
+
 // IsDisabled is the implementation of Widget.IsDisabled
 func (g *GitStatusWidget) IsDisabled() bool {
 	return g.disabled
@@ -230,13 +341,42 @@ func (g *GitStatusWidget) GetGridBufferers() []ui.GridBufferer {
 	return []ui.GridBufferer{g.renderer.GetWidget()}
 }
 
+// gitStatusKeys are the keys of the git operations
+var gitStatusKeys = []string{"s", "u", "d", "c", "p"}
+
 func (g *GitStatusWidget) setKeyBindings() error {
+	ui.Handle("/sys/kbd/s", func(ui.Event) {
+		g.act(func(item StatusItem) error {
+			return g.repo.Stage(item.File)
+		})
+	})
+	ui.Handle("/sys/kbd/u", func(ui.Event) {
+		g.act(func(item StatusItem) error {
+			if !item.Staged {
+				return nil
+			}
+			return g.repo.Unstage(item.File)
+		})
+	})
+	ui.Handle("/sys/kbd/d", func(ui.Event) {
+		g.confirmDiscard()
+	})
+	ui.Handle("/sys/kbd/c", func(ui.Event) {
+		g.openCommitEditor()
+	})
+	ui.Handle("/sys/kbd/p", func(ui.Event) {
+		g.openDiff()
+	})
+
 	// exec command by Enter
 	ui.Handle("/sys/kbd/<enter>", func(ui.Event) {
+		cursor := g.renderer.GetCursor()
+		if cursor >= len(g.statusItems) {
+			return
+		}
 		ui.StopLoop()
 		ui.Close()
 
-		cursor := g.renderer.GetCursor()
 		hasEditor := os.Getenv("EDITOR") != ""
 		editorCmd := ""
 		if hasEditor {
@@ -253,7 +393,7 @@ func (g *GitStatusWidget) setKeyBindings() error {
 			log.Println("Set an enviromental variable \"EDITOR\" to open file")
 			os.Exit(0)
 		} else {
-			cmd := exec.Command(editorCmd, g.statusItems[cursor].Path)
+			cmd := exec.Command(editorCmd, filepath.Join(g.repo.Path(), g.statusItems[cursor].File))
 			// load env vars
 			cmd.Env = os.Environ()
 			for _, env := range g.options.Envs {
@@ -282,21 +422,77 @@ func (g *GitStatusWidget) GetHeight() int {
 	return g.renderer.GetHeight()
 }
 
-// Disable is
+// Disable is the implementation of Widget.Disable
 func (g *GitStatusWidget) Disable() {
+	g.disabled = true
 }
 
 // SetOption is
 func (g *GitStatusWidget) SetOption(opt *AdditionalWidgetOption) {
 }
 
+// newStatusItems makes the rows of the status like "git status",
+// a file modified in both the index and the worktree has a staged row and an unstaged row
+func newStatusItems(status git.Status) (items StatusItems) {
+	for path, s := range status {
+		file := path
+		if s.Staging == git.Renamed {
+			path = fmt.Sprintf("%s -> %s", path, s.Extra)
+		}
+		// an untracked file is not staged though go-git marks its Staging as Untracked
+		if s.Staging != git.Unmodified && s.Staging != git.Untracked {
+			items = append(items, newStatusItem(file, path, s.Staging, true))
+		}
+		if s.Worktree != git.Unmodified {
+			items = append(items, newStatusItem(file, path, s.Worktree, false))
+		}
+	}
+	return
+}
+
+func newStatusItem(file string, path string, gs git.StatusCode, staged bool) (item StatusItem) {
+	item = StatusItem{
+		Staged: staged,
+		File:   file,
+		Stage:  "UNSTAGED",
+		Path:   path,
+	}
+	if staged {
+		item.Stage = "STAGED"
+	}
+	if gs == git.Untracked {
+		item.Status = "New File"
+		item.StatusNo = 1
+	} else if gs == git.Modified {
+		item.Status = "Modified"
+		item.StatusNo = 2
+	} else if gs == git.Added {
+		item.Status = "Added"
+		item.StatusNo = 3
+	} else if gs == git.Deleted {
+		item.Status = "Deleted"
+		item.StatusNo = 4
+	} else if gs == git.Renamed {
+		item.Status = "Renamed"
+		item.StatusNo = 5
+	} else if gs == git.Copied {
+		item.Status = "Copied"
+		item.StatusNo = 6
+	} else if gs == git.UpdatedButUnmerged {
+		item.Status = "UpdatedButUnmerged"
+		item.StatusNo = 7
+	}
+	return
+}
+
 // StatusItem is a struct which stores each file status of git status
 type StatusItem struct {
 	Staged   bool
 	StatusNo int
 	Stage    string
 	Status   string
-	Path     string
+	Path     string // the path to show, "from -> to" for a renamed file
+	File     string // the path in the worktree
 }
 
 // StatusItems is a collection of StatusItem, and implements sorting
@@ -0,0 +1,46 @@
+package widget
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4"
+)
+
+func TestNewStatusItems(t *testing.T) {
+	items := newStatusItems(git.Status{
+		"partial.go": {Staging: git.Modified, Worktree: git.Modified},
+		"new.go":     {Staging: git.Untracked, Worktree: git.Untracked},
+		"added.go":   {Staging: git.Added, Worktree: git.Unmodified},
+		"clean.go":   {Staging: git.Unmodified, Worktree: git.Unmodified},
+	})
+	sort.Sort(ByPath{items})
+	sort.Stable(ByStage{items})
+	var rows []string
+	for _, item := range items {
+		rows = append(rows, item.Stage+" "+item.Status+" "+item.File)
+	}
+	expected := "STAGED Added added.go, STAGED Modified partial.go, UNSTAGED New File new.go, UNSTAGED Modified partial.go"
+	if strings.Join(rows, ", ") != expected {
+		t.Fatalf("the partially staged file should have both rows but %v", rows)
+	}
+}
+
+func TestDiscardQuestion(t *testing.T) {
+	cases := []struct {
+		item     StatusItem
+		expected string
+	}{
+		{StatusItem{StatusNo: 2, File: "a.go"}, "Discard the changes of a.go?"},
+		{StatusItem{Staged: true, StatusNo: 2, File: "a.go"}, "Discard the staged and unstaged changes of a.go?"},
+		{StatusItem{StatusNo: 1, File: "new.go"}, "Delete the untracked file new.go?"},
+		{StatusItem{Staged: true, StatusNo: 3, File: "added.go"}, "Unstage and delete the file added.go?"},
+		{StatusItem{Staged: true, StatusNo: 5, File: "to.go"}, "Unstage and delete the file to.go?"},
+	}
+	for _, c := range cases {
+		if q := discardQuestion(c.item); !strings.HasPrefix(q, c.expected) {
+			t.Fatalf("%+v should be asked %q but %q", c.item, c.expected, q)
+		}
+	}
+}
@@ -2,18 +2,28 @@ package widget
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	ui "github.com/gizak/termui"
+	mccgit "github.com/qmu/mcc/git"
 	"github.com/qmu/mcc/github"
 	"github.com/qmu/mcc/widget/listable"
 	"golang.org/x/text/width"
 )
 
+const (
+	issueView       = "issue"
+	pullRequestView = "pr"
+)
+
 // GithubIssueWidget is a stack which shows a issue
-// of the current branch referering its name including issue id
+// of the current branch referering its name including issue id,
+// and the pull request of the current branch which is switched by "t"
 type GithubIssueWidget struct {
+	lifecycle
 	options    *Option
 	renderer   *listable.ListWrapper
 	active     bool
@@ -23,6 +33,11 @@ type GithubIssueWidget struct {
 	isReady    bool
 	disabled   bool
 	issueRegex string
+	view       string
+	issueBody  []string
+	prBody     []string
+	watcher    *mccgit.Watcher
+	mutex      sync.Mutex
 }
 
 // NewGithubIssueWidget constructs a New GithubIssueWidget
@@ -47,7 +62,12 @@ func (g *GithubIssueWidget) Init() (err error) {
 
 // Activate is the implementation of Widget.Activate
 func (g *GithubIssueWidget) Activate() {
+	g.mutex.Lock()
 	g.active = true
+	g.mutex.Unlock()
+	ui.Handle("/sys/kbd/t", func(ui.Event) {
+		g.toggle()
+	})
 	if g.isReady {
 		g.renderer.Activate()
 	}
@@ -55,12 +75,47 @@ func (g *GithubIssueWidget) Activate() {
 
 // Deactivate is the implementation of Widget.Deactivate
 func (g *GithubIssueWidget) Deactivate() {
+	g.mutex.Lock()
 	g.active = false
+	g.mutex.Unlock()
+	// termui can't remove a handler, "t" does nothing in the other widgets
+	ui.Handle("/sys/kbd/t", func(ui.Event) {})
 	if g.isReady {
 		g.renderer.Deactivate()
 	}
 }
 
+// toggle switches the issue view and the pull request view
+func (g *GithubIssueWidget) toggle() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if !g.isReady || g.view == "" {
+		return
+	}
+	if g.view == issueView {
+		g.view = pullRequestView
+	} else {
+		g.view = issueView
+	}
+	g.render()
+}
+
+// render shows the current view, the caller must hold the mutex
+func (g *GithubIssueWidget) render() {
+	if g.view == issueView {
+		g.renderer.SetTitle(g.options.GetTitle() + " (ISSUE, t: pull request)")
+		g.renderer.SetBody(g.issueBody)
+	} else {
+		g.renderer.SetTitle(g.options.GetTitle() + " (PULL REQUEST, t: issue)")
+		g.renderer.SetBody(g.prBody)
+	}
+	if g.active {
+		g.renderer.Render()
+	} else {
+		g.renderer.ResetRender()
+	}
+}
+
 // IsDisabled is the implementation of Widget.IsDisabled
 func (g *GithubIssueWidget) IsDisabled() bool {
 	return g.disabled
@@ -80,7 +135,7 @@ func (g *GithubIssueWidget) GetHighlightenPos() int {
 func (g *GithubIssueWidget) Disable() {
 	g.disabled = true
 	g.renderer.SetBody([]string{"Could not load issue number from branch name..."})
-	ui.Render(ui.Body)
+	listable.RenderBody()
 }
 
 // GetGridBufferers is the implementation of Widget.Activate
@@ -144,6 +199,51 @@ func (g *GithubIssueWidget) buildBody() (body []string, err error) {
 	return
 }
 
+// buildPullRequestBody shows the status, reviewers and review comments
+// of the open pull request of the current branch
+func (g *GithubIssueWidget) buildPullRequestBody() (body []string, err error) {
+	pr, comments, err := g.client.GetBranchPullRequest()
+	if err != nil {
+		return
+	}
+	if pr == nil {
+		return []string{" no open pull request for the branch " + escapeMarkup(g.client.GetBranch())}, nil
+	}
+	body = pullRequestSummary(pr)
+	if len(comments) == 0 {
+		return append(body, " no review comment"), nil
+	}
+	loc, err := time.LoadLocation(g.timezone)
+	if err != nil {
+		loc = time.Local
+		err = nil
+	}
+	width := g.GetWidth() - 6
+	for i, c := range comments {
+		if i > 0 {
+			body = append(body, " ["+strings.Repeat(". ", 150)+"](fg-blue)")
+		}
+		label := "COMMENTED"
+		if c.State != "" {
+			label = strings.Replace(c.State, "_", " ", -1)
+		}
+		head := " [" + label + " BY ](fg-blue)" + c.User + " [ON " + fmt.Sprint(c.CreatedAt.In(loc)) + "](fg-blue)"
+		if c.Path != "" {
+			head += " " + escapeMarkup(c.Path)
+			if c.Position > 0 {
+				head += ":" + strconv.Itoa(c.Position)
+			}
+		}
+		body = append(body, head)
+		for _, l := range strings.Split(strings.Replace(c.Body, "\r\n", "\n", -1), "\n") {
+			for _, w := range wrapLine(escapeMarkup(l), width) {
+				body = append(body, "   "+w)
+			}
+		}
+	}
+	return
+}
+
 func (g *GithubIssueWidget) overflow(text string) (result string) {
 	lines := strings.Split(text, "\n")
 	splitlen := g.GetWidth() - 2 - g.indent
@@ -195,16 +295,66 @@ func (g *GithubIssueWidget) GetHeight() int {
 // SetOption is
 func (g *GithubIssueWidget) SetOption(opt *AdditionalWidgetOption) {
 	g.client = opt.GithubClient
+	if opt.GithubError != nil {
+		g.renderer.SetBody([]string{
+			" [Could not authenticate GitHub](fg-red)",
+			" " + opt.GithubError.Error(),
+		})
+		g.isReady = true
+		g.renderer.ResetRender()
+		return
+	}
 	if g.client == nil {
 		return
 	}
-	go func() {
-		body, err := g.buildBody()
-		if err != nil {
-			return
-		}
-		g.renderer.SetBody(body)
-		g.isReady = true
-		g.renderer.ResetRender()
-	}()
+	go g.load()
+	go g.watchBranch()
+}
+
+// watchBranch reloads the issue and the pull request when the branch is switched
+func (g *GithubIssueWidget) watchBranch() {
+	if g.watcher != nil {
+		return
+	}
+	repo, err := mccgit.NewRepository(g.options.GetRepoPath())
+	if err != nil {
+		return
+	}
+	// the branch is not followed if the refs can't be watched
+	g.watcher, err = mccgit.NewWatcher(&mccgit.WatcherOption{
+		Repository: repo,
+		RefsOnly:   true,
+		OnChange: func() {
+			if changed, err := g.client.UpdateBranch(); err == nil && changed {
+				g.load()
+			}
+		},
+	})
+	if err == nil {
+		g.keep(g.watcher)
+	}
+}
+
+// load requests the issue and the pull request,
+// the issue is shown first if the branch name includes the issue number
+func (g *GithubIssueWidget) load() {
+	issueBody, err := g.buildBody()
+	if err != nil {
+		issueBody = []string{" " + escapeMarkup(err.Error())}
+	}
+	prBody, err := g.buildPullRequestBody()
+	if err != nil {
+		prBody = []string{" [Could not load the pull request](fg-red)", " " + escapeMarkup(err.Error())}
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.issueBody = issueBody
+	g.prBody = prBody
+	if _, err := g.client.IssueNumber(g.issueRegex); err == nil {
+		g.view = issueView
+	} else {
+		g.view = pullRequestView
+	}
+	g.isReady = true
+	g.render()
 }
@@ -0,0 +1,307 @@
+package widget
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	ui "github.com/gizak/termui"
+	m2s "github.com/mitchellh/mapstructure"
+	"github.com/qmu/mcc/github"
+	"github.com/qmu/mcc/widget/listable"
+)
+
+const (
+	defaultPullRequestsInterval = time.Minute
+)
+
+// GithubPullRequestsWidget lists the open pull requests with their CI and review status
+type GithubPullRequestsWidget struct {
+	lifecycle
+	options  *Option
+	renderer *listable.ListWrapper
+	client   *github.Client
+	filter   *github.PullRequestFilter
+	interval time.Duration
+	prs      []*github.PullRequest
+	active   bool
+	started  bool
+	isReady  bool
+	disabled bool
+	mutex    sync.Mutex
+}
+
+// NewGithubPullRequestsWidget constructs a New GithubPullRequestsWidget
+func NewGithubPullRequestsWidget(opt *Option) (g *GithubPullRequestsWidget, err error) {
+	g = new(GithubPullRequestsWidget)
+	g.options = opt
+	g.filter = new(github.PullRequestFilter)
+	if opt.Content != nil {
+		if err = m2s.Decode(opt.Content, g.filter); err != nil {
+			return
+		}
+	}
+	g.interval = defaultPullRequestsInterval
+	if opt.Interval != "" {
+		if g.interval, err = time.ParseDuration(opt.Interval); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Init is the implementation of stack.Init
+func (g *GithubPullRequestsWidget) Init() (err error) {
+	g.renderer = listable.NewListWrapper(&listable.ListWrapperOption{
+		Title:         g.options.GetTitle(),
+		RealHeight:    g.options.GetHeight(),
+		Header:        g.buildHeader(),
+		LineHighLight: true,
+	})
+	g.isReady = true
+	return
+}
+
+// watch refreshes the list on the interval,
+// it waits until the rate limit is reset when the limit has run out
+func (g *GithubPullRequestsWidget) watch() {
+	ctx := g.context()
+	for {
+		prs, err := g.client.ListPullRequests(g.filter)
+		wait := g.interval
+		now := time.Now()
+		status := strconv.Itoa(len(prs)) + " open, " + now.Format("15:04:05")
+		if d, limited := github.RetryAfter(err); limited {
+			wait = d
+			status = "rate limited until " + now.Add(d).Format("15:04:05")
+		} else if err != nil {
+			status = "ERROR: " + err.Error()
+		}
+		if remaining, reset := g.client.RateLimit(); remaining == 0 && reset.Sub(now) > wait {
+			wait = reset.Sub(now)
+			status += ", rate limited until " + reset.Format("15:04:05")
+		}
+		g.update(prs, prs != nil || err == nil, status)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (g *GithubPullRequestsWidget) update(prs []*github.PullRequest, fetched bool, status string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if fetched {
+		// keep the last checks and reviews if they were skipped for the rate limit
+		for _, pr := range prs {
+			if pr.Detailed {
+				continue
+			}
+			for _, old := range g.prs {
+				if old.Number == pr.Number {
+					pr.Checks, pr.CheckState = old.Checks, old.CheckState
+					pr.Reviews, pr.ReviewDecision = old.Reviews, old.ReviewDecision
+				}
+			}
+		}
+		g.prs = prs
+	}
+	g.renderer.SetTitle(g.options.GetTitle() + " (" + status + ")")
+	g.renderer.SetBody(g.buildBody())
+	if g.active {
+		g.renderer.Render()
+	} else {
+		g.renderer.Deactivate()
+	}
+}
+
+func (g *GithubPullRequestsWidget) buildHeader() []string {
+	return []string{
+		" [NO     | CI | REVIEW            | TITLE](fg-blue)",
+		" [" + strings.Repeat("-", 500) + "](fg-blue)",
+	}
+}
+
+func (g *GithubPullRequestsWidget) buildBody() (body []string) {
+	if len(g.prs) == 0 {
+		return []string{" no open pull request"}
+	}
+	for _, pr := range g.prs {
+		no := "#" + strconv.Itoa(pr.Number)
+		no += strings.Repeat(" ", 6-utf8.RuneCountInString(no))
+		title := escapeMarkup(pr.Title) + " (" + pr.Author + ")"
+		if pr.Draft {
+			title = "[draft](fg-blue) " + title
+		}
+		body = append(body, " "+no+" [|](fg-blue) "+checkMark(pr.CheckState)+"  [|](fg-blue) "+reviewLabel(pr.ReviewDecision)+" [|](fg-blue) "+title)
+	}
+	return
+}
+
+func checkMark(state string) string {
+	switch state {
+	case "success":
+		return "[✔](fg-green)"
+	case "failure":
+		return "[✘](fg-red)"
+	case "pending":
+		return "[●](fg-yellow)"
+	}
+	return "-"
+}
+
+func reviewLabel(decision string) string {
+	switch decision {
+	case "approved":
+		return "[approved         ](fg-green)"
+	case "changes_requested":
+		return "[changes requested](fg-red)"
+	case "review_required":
+		return "[review required  ](fg-yellow)"
+	}
+	return "-                "
+}
+
+// openDetail shows the pull request under the cursor in a pane
+func (g *GithubPullRequestsWidget) openDetail() {
+	g.mutex.Lock()
+	cursor := g.renderer.GetCursor()
+	if cursor >= len(g.prs) {
+		g.mutex.Unlock()
+		return
+	}
+	pr := g.prs[cursor]
+	g.mutex.Unlock()
+
+	p := listable.NewPopup(&listable.PopupOption{
+		Title: "#" + strconv.Itoa(pr.Number) + " " + pr.Title,
+	})
+	width := p.GetWidth() - 4
+	text := pullRequestSummary(pr)
+	for _, l := range strings.Split(strings.Replace(pr.Body, "\r\n", "\n", -1), "\n") {
+		for _, w := range wrapLine(escapeMarkup(l), width) {
+			text = append(text, " "+w)
+		}
+	}
+	p.SetBody(text)
+	p.Open(func() {
+		g.Activate()
+	})
+}
+
+// pullRequestSummary builds the lines of the status of a pull request followed by a separator
+func pullRequestSummary(pr *github.PullRequest) (text []string) {
+	text = []string{
+		" [TITLE   :](fg-blue) " + escapeMarkup(pr.Title),
+		" [NO      :](fg-blue) #" + strconv.Itoa(pr.Number),
+		" [BY      :](fg-blue) " + pr.Author,
+		" [BRANCH  :](fg-blue) " + pr.Head + " -> " + pr.Base,
+		" [URL     :](fg-blue) " + pr.URL,
+	}
+	if pr.Draft {
+		text = append(text, " [DRAFT   :](fg-blue) yes")
+	}
+	if len(pr.Labels) > 0 {
+		text = append(text, " [LABEL   :](fg-blue) "+escapeMarkup(strings.Join(pr.Labels, ", ")))
+	}
+	text = append(text, " [CHECKS  :](fg-blue) "+checkMark(pr.CheckState))
+	for _, c := range pr.Checks {
+		text = append(text, "           "+checkMark(c.State)+" "+escapeMarkup(c.Name))
+	}
+	text = append(text, " [REVIEWS :](fg-blue) "+reviewLabel(pr.ReviewDecision))
+	for _, r := range pr.Reviews {
+		text = append(text, "           "+r.State+" by "+r.User)
+	}
+	for _, u := range pr.Reviewers {
+		text = append(text, "           REQUESTED "+u)
+	}
+	text = append(text, " ["+strings.Repeat("-", 500)+"](fg-blue)")
+	return
+}
+
+func escapeMarkup(s string) string {
+	return strings.NewReplacer("[", "(", "]", ")").Replace(s)
+}
+
+// wrapLine splits a line into the lines which fit the width
+func wrapLine(line string, width int) (lines []string) {
+	runes := []rune(line)
+	if width <= 0 || len(runes) <= width {
+		return []string{line}
+	}
+	for len(runes) > width {
+		lines = append(lines, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(lines, string(runes))
+}
+
+// Activate is the implementation of Widget.Activate
+func (g *GithubPullRequestsWidget) Activate() {
+	g.mutex.Lock()
+	g.active = true
+	g.mutex.Unlock()
+	ui.Handle("/sys/kbd/<enter>", func(ui.Event) {
+		g.openDetail()
+	})
+	g.renderer.Activate()
+}
+
+// Deactivate is the implementation of Widget.Deactivate
+func (g *GithubPullRequestsWidget) Deactivate() {
+	g.mutex.Lock()
+	g.active = false
+	g.mutex.Unlock()
+	g.renderer.Deactivate()
+}
+
+// IsDisabled is the implementation of Widget.IsDisabled
+func (g *GithubPullRequestsWidget) IsDisabled() bool {
+	return g.disabled
+}
+
+// IsReady is the implementation of Widget.IsReady
+func (g *GithubPullRequestsWidget) IsReady() bool {
+	return g.isReady
+}
+
+// GetHighlightenPos is the implementation of Widget.GetHighlightenPos
+func (g *GithubPullRequestsWidget) GetHighlightenPos() int {
+	return g.renderer.GetCursor()
+}
+
+// GetGridBufferers is the implementation of Widget.GetGridBufferers
+func (g *GithubPullRequestsWidget) GetGridBufferers() []ui.GridBufferer {
+	return []ui.GridBufferer{g.renderer.GetWidget()}
+}
+
+// Disable sets a GithubPullRequestsWidget instance as disabled
+func (g *GithubPullRequestsWidget) Disable() {
+	g.disabled = true
+	g.renderer.SetBody([]string{" No GitHub repository is found..."})
+	listable.RenderBody()
+}
+
+// SetOption starts refreshing the list with the client
+func (g *GithubPullRequestsWidget) SetOption(opt *AdditionalWidgetOption) {
+	if opt.GithubError != nil {
+		g.renderer.SetBody([]string{
+			" [Could not authenticate GitHub](fg-red)",
+			" " + escapeMarkup(opt.GithubError.Error()),
+		})
+		g.renderer.ResetRender()
+		return
+	}
+	if opt.GithubClient == nil || g.started {
+		return
+	}
+	g.client = opt.GithubClient
+	g.started = true
+	go g.watch()
+}
@@ -1,15 +1,20 @@
 package widget
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unicode/utf8"
 
 	ui "github.com/gizak/termui"
 	m2s "github.com/mitchellh/mapstructure"
+	"github.com/qmu/mcc/utils"
 	"github.com/qmu/mcc/widget/listable"
 )
 
@@ -85,42 +90,138 @@ func (m *MenuWidget) GetGridBufferers() []ui.GridBufferer {
 func (m *MenuWidget) setKeyBindings() error {
 	// exec command by Enter
 	ui.Handle("/sys/kbd/<enter>", func(ui.Event) {
-		ui.StopLoop()
-		ui.Close()
-
-		cursor := m.renderer.GetCursor()
-		// straighten multi line commands
-		cmds := strings.Split(m.menus[cursor].Command, "\n")
-		cmdStr := ""
-		for _, c := range cmds {
-			if c != "" {
-				cmdStr = cmdStr + c + "; "
-			}
+		menu := m.menus[m.renderer.GetCursor()]
+		if menu.Interactive {
+			m.execInteractive(menu)
+		} else {
+			m.execInPane(menu)
 		}
+	})
+	return nil
+}
+
+// execInteractive hands the terminal over to the command and exits mcc with its status
+func (m *MenuWidget) execInteractive(menu Menu) {
+	ui.StopLoop()
+	ui.Close()
+
+	fmt.Println("---------- executing --------------")
+	fmt.Println(utils.StraightenCommand(menu.Command))
+	fmt.Println("-----------------------------------")
+	fmt.Println("")
 
-		fmt.Println("---------- executing --------------")
-		fmt.Println(cmdStr)
-		fmt.Println("-----------------------------------")
-		fmt.Println("")
+	cmd := utils.NewShellCommand(menu.Command, m.envs)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
 
-		cmd := exec.Command("sh", "-c", cmdStr)
+// execInPane runs the command in a popup which streams its stdout and stderr,
+// Ctrl-C cancels the command and q or Esc closes the popup
+func (m *MenuWidget) execInPane(menu Menu) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// running and canceled are shared by the runner and the key handler
+	var mutex sync.Mutex
+	running, canceled := true, false
+	finish := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		running = false
+		return canceled
+	}
+	pane := listable.NewPopup(&listable.PopupOption{
+		Title: "RUNNING - " + menu.Name + " (Ctrl-C: cancel)",
+		Body:  []string{" [$ " + utils.StraightenCommand(menu.Command) + "](fg-blue)"},
+	})
+	pane.Handle("C-c", func() {
+		mutex.Lock()
+		r := running
+		if r {
+			canceled = true
+		}
+		mutex.Unlock()
+		if r {
+			cancel()
+		} else {
+			pane.Close()
+		}
+	})
+	pane.Open(func() {
+		cancel()
+		m.Activate()
+	})
 
-		// load env vars
-		cmd.Env = os.Environ()
-		for _, env := range m.envs {
-			cmd.Env = append(cmd.Env, env["name"]+"="+env["value"])
+	type outputLine struct {
+		text   string
+		stderr bool
+	}
+	lines := make(chan outputLine)
+	scan := func(r io.Reader, stderr bool, wg *sync.WaitGroup) {
+		defer wg.Done()
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			lines <- outputLine{text: s.Text(), stderr: stderr}
 		}
+	}
 
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		err := cmd.Run()
+	go func() {
+		start := time.Now()
+		cmd := utils.NewShellCommand(menu.Command, m.envs)
+		stdout, err := cmd.StdoutPipe()
+		var stderr io.ReadCloser
+		if err == nil {
+			stderr, err = cmd.StderrPipe()
+		}
+		var wait func() error
+		if err == nil {
+			wait, err = utils.StartKillable(ctx, cmd)
+		}
 		if err != nil {
-			os.Exit(1)
+			finish()
+			pane.AddBody(" [" + escapeMarkup(err.Error()) + "](fg-red)")
+			pane.SetTitle("FAILED - " + menu.Name + " (q: close)")
+			return
 		}
-		os.Exit(0)
-	})
-	return nil
+		wg := new(sync.WaitGroup)
+		wg.Add(2)
+		go scan(stdout, false, wg)
+		go scan(stderr, true, wg)
+		go func() {
+			wg.Wait()
+			close(lines)
+		}()
+		for l := range lines {
+			if l.stderr {
+				pane.AddBody(" [" + strings.NewReplacer("[", "(", "]", ")").Replace(l.text) + "](fg-red)")
+			} else {
+				pane.AddBody(" " + listable.ConvertANSI(l.text))
+			}
+			pane.MoveCursor("bottom")
+		}
+		err = wait()
+		canceledByUser := finish()
+		cancel()
+
+		code := utils.ExitCode(cmd, err)
+		duration := time.Since(start).Round(time.Millisecond).String()
+		result := "exit code " + strconv.Itoa(code) + " in " + duration
+		color := "fg-green"
+		if canceledByUser && code != 0 {
+			result = "canceled after " + duration
+			color = "fg-yellow"
+		} else if code != 0 {
+			color = "fg-red"
+		}
+		pane.AddBody("")
+		pane.AddBody(" [" + strings.Repeat("-", 10) + " " + result + " " + strings.Repeat("-", 10) + "](" + color + ")")
+		pane.MoveCursor("bottom")
+		pane.SetTitle("DONE - " + menu.Name + " (" + result + ", q: close)")
+	}()
 }
 
 func (m *MenuWidget) buildHeader() (header []string) {
@@ -197,6 +298,10 @@ func (m *MenuWidget) GetHeight() int {
 func (m *MenuWidget) Disable() {
 }
 
+// Stop is the implementation of Widget.Stop, nothing runs in the background
+func (m *MenuWidget) Stop() {
+}
+
 // SetOption is
 func (m *MenuWidget) SetOption(opt *AdditionalWidgetOption) {
 }
@@ -112,6 +112,10 @@ func (n *NoteWidget) GetHeight() int {
 func (n *NoteWidget) Disable() {
 }
 
+// Stop is the implementation of Widget.Stop, nothing runs in the background
+func (n *NoteWidget) Stop() {
+}
+
 // SetOption is
 func (n *NoteWidget) SetOption(opt *AdditionalWidgetOption) {
 }
@@ -0,0 +1,498 @@
+package widget
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	humanize "github.com/dustin/go-humanize"
+	ui "github.com/gizak/termui"
+	m2s "github.com/mitchellh/mapstructure"
+	"github.com/qmu/mcc/system"
+	"github.com/qmu/mcc/widget/listable"
+)
+
+const (
+	defaultProcessInterval = 2 * time.Second
+	defaultProcessSort     = "cpu"
+	processExecPath        = "{exec_path}"
+	maxProcessUserWidth    = 12
+)
+
+// processListKeys are bound while the widget is active, "/" is bound by the renderer
+var processListKeys = []string{"o", "O", "s"}
+
+// ProcessListWidget lists the processes like top, they can be sorted, filtered and signaled
+type ProcessListWidget struct {
+	lifecycle
+	options   *Option
+	renderer  *listable.ListWrapper
+	fs        *system.FS
+	content   ProcessList
+	match     *regexp.Regexp
+	interval  time.Duration
+	users     map[int]string
+	lastCPU   *system.CPUTimes
+	lastTicks map[int]uint64 // the CPU time of each process at the previous poll
+	procs     []*processRow  // all of the processes which match
+	shown     []*processRow  // the filtered and sorted ones
+	sortBy    int            // the index of processColumns
+	reverse   bool           // the opposite of the default order of the column
+	filter    string
+	active    bool
+	isReady   bool
+	disabled  bool
+	mutex     sync.Mutex
+}
+
+// processRow is a process with the columns computed from it
+type processRow struct {
+	*system.Process
+	user     string
+	cpu      float64 // the percentage of a CPU since the previous poll
+	measured bool    // the CPU usage isn't known at the first poll
+}
+
+// processColumn is a column of process_list which the processes can be sorted by
+type processColumn struct {
+	name   string
+	header string
+	desc   bool // the larger one first by default
+	less   func(a, b *processRow) bool
+}
+
+var processColumns = []processColumn{
+	{name: "pid", header: "PID", less: func(a, b *processRow) bool { return a.PID < b.PID }},
+	{name: "user", header: "USER", less: func(a, b *processRow) bool { return a.user < b.user }},
+	{name: "cpu", header: "CPU%", desc: true, less: func(a, b *processRow) bool { return a.cpu < b.cpu }},
+	{name: "rss", header: "RSS", desc: true, less: func(a, b *processRow) bool { return a.RSS < b.RSS }},
+	{name: "command", header: "COMMAND", less: func(a, b *processRow) bool { return a.Cmdline < b.Cmdline }},
+}
+
+// IsProcessListColumn tells if the processes of process_list can be sorted by the column
+func IsProcessListColumn(name string) bool {
+	return processColumnIndex(name) > -1
+}
+
+func processColumnIndex(name string) int {
+	for i, c := range processColumns {
+		if c.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// CompileProcessMatch compiles the match of process_list, {exec_path} is replaced with the directory of the config
+func CompileProcessMatch(match string, execPath string) (*regexp.Regexp, error) {
+	return regexp.Compile(strings.Replace(match, processExecPath, regexp.QuoteMeta(execPath), -1))
+}
+
+// NewProcessListWidget constructs a New ProcessListWidget
+func NewProcessListWidget(opt *Option) (n *ProcessListWidget, err error) {
+	n = new(ProcessListWidget)
+	n.options = opt
+	n.fs = system.NewFS("")
+	n.users = map[int]string{}
+	n.interval = defaultProcessInterval
+	if opt.Interval != "" {
+		if n.interval, err = time.ParseDuration(opt.Interval); err != nil {
+			return
+		}
+	}
+	if err = m2s.Decode(opt.Content, &n.content); err != nil {
+		return
+	}
+	if n.content.Match != "" {
+		if n.match, err = CompileProcessMatch(n.content.Match, opt.ExecPath); err != nil {
+			return nil, fmt.Errorf("match %s of process_list is invalid: %v", n.content.Match, err)
+		}
+	}
+	if n.content.Sort == "" {
+		n.content.Sort = defaultProcessSort
+	}
+	if n.sortBy = processColumnIndex(n.content.Sort); n.sortBy < 0 {
+		return nil, errors.New(n.content.Sort + " is not a column of process_list")
+	}
+	return
+}
+
+// Init is the implementation of widget.Init
+func (n *ProcessListWidget) Init() (err error) {
+	n.renderer = listable.NewListWrapper(&listable.ListWrapperOption{
+		Title:         n.options.GetTitle(),
+		RealHeight:    n.options.GetHeight(),
+		Header:        n.buildHeader(len("USER")),
+		LineHighLight: true,
+	})
+	n.isReady = true
+	go n.watch()
+	return
+}
+
+// watch reads the processes every interval until the widget is stopped
+func (n *ProcessListWidget) watch() {
+	ctx := n.context()
+	n.refresh()
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.refresh()
+		}
+	}
+}
+
+// refresh reads the processes and renders them
+func (n *ProcessListWidget) refresh() {
+	err := n.update()
+	n.rearrange(func() {
+		if err != nil {
+			n.procs = nil
+		}
+	})
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if err != nil {
+		n.renderer.SetBody([]string{" [" + escapeMarkup(err.Error()) + "](fg-red)"})
+	}
+	if n.active {
+		n.renderer.Render()
+	} else {
+		n.renderer.Deactivate()
+	}
+}
+
+// update reads the processes and computes the CPU usage of each one from the previous poll
+func (n *ProcessListWidget) update() (err error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	ps, err := n.fs.Processes()
+	if err != nil {
+		return
+	}
+	cpu, err := n.fs.CPU()
+	if err != nil {
+		return
+	}
+	// the ticks of a CPU since the previous poll
+	var elapsed float64
+	if n.lastCPU != nil && cpu.Count > 0 && cpu.Total > n.lastCPU.Total {
+		elapsed = float64(cpu.Total-n.lastCPU.Total) / float64(cpu.Count)
+	}
+	ticks := map[int]uint64{}
+	var rows []*processRow
+	for _, p := range ps {
+		if n.match != nil && !n.match.MatchString(p.Cmdline) && (p.Cwd == "" || !n.match.MatchString(p.Cwd)) {
+			continue
+		}
+		r := &processRow{Process: p, user: n.userName(p.UID)}
+		if last, ok := n.lastTicks[p.PID]; ok && elapsed > 0 && p.Ticks >= last {
+			r.cpu = float64(p.Ticks-last) / elapsed * 100
+			r.measured = true
+		}
+		ticks[p.PID] = p.Ticks
+		rows = append(rows, r)
+	}
+	n.lastCPU, n.lastTicks = cpu, ticks
+	n.procs = rows
+	return
+}
+
+// userName looks up the name of the user, the ID is shown if it's not found
+func (n *ProcessListWidget) userName(uid int) string {
+	if name, ok := n.users[uid]; ok {
+		return name
+	}
+	name := strconv.Itoa(uid)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	n.users[uid] = name
+	return name
+}
+
+// rearrange applies the change and shows the processes again, the cursor stays on the same process
+func (n *ProcessListWidget) rearrange(change func()) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	selected := -1
+	if cursor := n.renderer.GetCursor(); cursor < len(n.shown) {
+		selected = n.shown[cursor].PID
+	}
+	change()
+	n.arrange()
+
+	title := n.options.GetTitle()
+	if n.filter != "" {
+		title += " (filter: " + n.filter + ")"
+	}
+	n.renderer.SetTitle(title)
+	header, body := n.buildBody()
+	n.renderer.SetHeader(header)
+	if len(body) == 0 {
+		body = []string{" no process"}
+	}
+	n.renderer.SetBody(body)
+	for i, r := range n.shown {
+		if r.PID == selected {
+			n.renderer.SetCursor(i)
+		}
+	}
+}
+
+// arrange filters and sorts the processes into shown, the mutex should be locked
+func (n *ProcessListWidget) arrange() {
+	filter := strings.ToLower(n.filter)
+	var shown []*processRow
+	for _, r := range n.procs {
+		if filter == "" || strings.Contains(strings.ToLower(r.Cmdline), filter) ||
+			strings.Contains(strings.ToLower(r.user), filter) || strconv.Itoa(r.PID) == filter {
+			shown = append(shown, r)
+		}
+	}
+	c := processColumns[n.sortBy]
+	sort.SliceStable(shown, func(i, j int) bool {
+		a, b := shown[i], shown[j]
+		if c.desc != n.reverse {
+			a, b = b, a
+		}
+		if c.less(a, b) {
+			return true
+		}
+		if c.less(b, a) {
+			return false
+		}
+		return shown[i].PID < shown[j].PID
+	})
+	n.shown = shown
+}
+
+// buildHeader shows the columns with the mark of the sort order
+func (n *ProcessListWidget) buildHeader(userWidth int) []string {
+	headers := make([]string, len(processColumns))
+	for i, c := range processColumns {
+		headers[i] = c.header
+		if i == n.sortBy {
+			if c.desc != n.reverse {
+				headers[i] += "▼"
+			} else {
+				headers[i] += "▲"
+			}
+		}
+	}
+	return []string{
+		" [" + alignRight(headers[0], 7) + " " + fitWidth(headers[1], userWidth+1) + alignRight(headers[2], 6) +
+			" " + alignRight(headers[3], 8) + " " + headers[4] + "](fg-blue)",
+		" [" + strings.Repeat("-", 500) + "](fg-blue)",
+	}
+}
+
+// buildBody builds the lines of the shown processes and the header aligned with them
+func (n *ProcessListWidget) buildBody() (header []string, body []string) {
+	userWidth := len("USER")
+	for _, r := range n.shown {
+		if w := utf8.RuneCountInString(r.user); w > userWidth {
+			userWidth = w
+		}
+	}
+	if userWidth > maxProcessUserWidth {
+		userWidth = maxProcessUserWidth
+	}
+	for _, r := range n.shown {
+		cpu := "-"
+		if r.measured {
+			cpu = strconv.FormatFloat(r.cpu, 'f', 1, 64)
+		}
+		if r.cpu >= 50 {
+			cpu = "[" + alignRight(cpu, 6) + "](fg-red)"
+		} else {
+			cpu = alignRight(cpu, 6)
+		}
+		body = append(body, " "+alignRight(strconv.Itoa(r.PID), 7)+
+			" "+fitWidth(escapeMarkup(r.user), userWidth)+
+			" "+cpu+
+			" "+alignRight(humanize.Bytes(r.RSS), 8)+
+			" "+escapeMarkup(r.Cmdline))
+	}
+	return n.buildHeader(userWidth), body
+}
+
+// alignRight pads the string to the width on the left
+func alignRight(s string, width int) string {
+	if w := utf8.RuneCountInString(s); w < width {
+		return strings.Repeat(" ", width-w) + s
+	}
+	return s
+}
+
+// selectedProcess returns the process under the cursor
+func (n *ProcessListWidget) selectedProcess() *processRow {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	cursor := n.renderer.GetCursor()
+	if cursor >= len(n.shown) {
+		return nil
+	}
+	return n.shown[cursor]
+}
+
+// setFilter shows only the processes whose command line or user has the text, or the pid is it
+func (n *ProcessListWidget) setFilter(filter string) {
+	n.rearrange(func() {
+		n.filter = filter
+	})
+}
+
+// sortNext sorts the processes by the next column in its default order
+func (n *ProcessListWidget) sortNext() {
+	n.rearrange(func() {
+		n.sortBy = (n.sortBy + 1) % len(processColumns)
+		n.reverse = false
+	})
+}
+
+// reverseOrder reverses the order of the sort
+func (n *ProcessListWidget) reverseOrder() {
+	n.rearrange(func() {
+		n.reverse = !n.reverse
+	})
+}
+
+// openFilter opens the input of the filter, the list is filtered while typing
+func (n *ProcessListWidget) openFilter() {
+	in := listable.NewInput(&listable.InputOption{
+		Title: "FILTER",
+		Text:  n.filter,
+		Width: "60%",
+	})
+	in.Open(func(text string) {
+		n.setFilter(text)
+		n.renderer.Render()
+	}, n.Activate)
+}
+
+// openSignals asks which signal to send to the process under the cursor
+func (n *ProcessListWidget) openSignals() {
+	r := n.selectedProcess()
+	if r == nil {
+		return
+	}
+	var body []string
+	for _, s := range system.Signals {
+		body = append(body, " SIG"+s.Name)
+	}
+	p := listable.NewPopup(&listable.PopupOption{
+		Title:         "SIGNAL (Enter: send)",
+		Body:          body,
+		LineHighLight: true,
+		Width:         "30%",
+		Height:        "40%",
+	})
+	p.Handle("<enter>", func() {
+		s := system.Signals[p.GetCursor()]
+		p.Close()
+		question := "Send SIG" + s.Name + " to " + strconv.Itoa(r.PID) + " " + escapeMarkup(fitWidth(r.Cmdline, 40)) + "?"
+		openConfirm("SIGNAL", question, func() {
+			// the process may have exited and its pid may be reused while the popups were opened
+			if err := n.fs.Signal(r.Process, s.Signal); err != nil {
+				openError(err, n.Activate)
+				return
+			}
+			n.refresh()
+		}, n.Activate)
+	})
+	p.Open(n.Activate)
+}
+
+// Activate is the implementation of Widget.Activate
+func (n *ProcessListWidget) Activate() {
+	n.mutex.Lock()
+	n.active = true
+	n.mutex.Unlock()
+	n.setKeyBindings()
+	n.renderer.Activate()
+}
+
+// Deactivate is the implementation of Widget.Deactivate
+func (n *ProcessListWidget) Deactivate() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.active = false
+	// termui can't remove a handler, the keys do nothing in the other widgets
+	for _, key := range processListKeys {
+		ui.Handle("/sys/kbd/"+key, func(ui.Event) {})
+	}
+	n.renderer.Deactivate()
+}
+
+func (n *ProcessListWidget) setKeyBindings() {
+	n.renderer.HandleKey("/", func() {
+		n.mutex.Lock()
+		active := n.active
+		n.mutex.Unlock()
+		if active {
+			n.openFilter()
+		}
+	})
+	ui.Handle("/sys/kbd/o", func(ui.Event) {
+		n.sortNext()
+		n.renderer.Render()
+	})
+	ui.Handle("/sys/kbd/O", func(ui.Event) {
+		n.reverseOrder()
+		n.renderer.Render()
+	})
+	ui.Handle("/sys/kbd/s", func(ui.Event) {
+		n.openSignals()
+	})
+}
+
+// IsDisabled is the implementation of Widget.IsDisabled
+func (n *ProcessListWidget) IsDisabled() bool {
+	return n.disabled
+}
+
+// IsReady is the implementation of Widget.IsReady
+func (n *ProcessListWidget) IsReady() bool {
+	return n.isReady
+}
+
+// GetHighlightenPos is the implementation of Widget.GetHighlightenPos
+func (n *ProcessListWidget) GetHighlightenPos() int {
+	return n.renderer.GetCursor()
+}
+
+// GetGridBufferers is the implementation of Widget.GetGridBufferers
+func (n *ProcessListWidget) GetGridBufferers() []ui.GridBufferer {
+	return []ui.GridBufferer{n.renderer.GetWidget()}
+}
+
+// GetWidth is the implementation of widget.Init
+func (n *ProcessListWidget) GetWidth() int {
+	return n.renderer.GetWidth()
+}
+
+// GetHeight is the implementation of widget.Init
+func (n *ProcessListWidget) GetHeight() int {
+	return n.renderer.GetHeight()
+}
+
+// Disable is the implementation of Widget.Disable
+func (n *ProcessListWidget) Disable() {
+	n.disabled = true
+}
+
+// SetOption is
+func (n *ProcessListWidget) SetOption(opt *AdditionalWidgetOption) {
+}
@@ -0,0 +1,134 @@
+package widget
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/qmu/mcc/system"
+)
+
+func shownPIDs(n *ProcessListWidget) (pids []int) {
+	for _, r := range n.shown {
+		pids = append(pids, r.PID)
+	}
+	return
+}
+
+func TestProcessListWidgetUpdate(t *testing.T) {
+	n, err := NewProcessListWidget(&Option{ExecPath: "/home/dev/app", Content: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.fs = system.NewFS("../system/testdata/proc")
+	n.users = map[int]string{0: "root", 1000: "dev"}
+	if err = n.update(); err != nil {
+		t.Fatal(err)
+	}
+	n.arrange()
+	// the CPU usage is not measured yet, sorted by the pid
+	if pids := shownPIDs(n); len(pids) != 3 || pids[0] != 1 || pids[1] != 77 || pids[2] != 4242 {
+		t.Fatalf("all the processes should be shown but %v", pids)
+	}
+	_, body := n.buildBody()
+	if !strings.HasPrefix(body[0], "       1 root      -") {
+		t.Fatalf("the CPU usage should be measured from the next poll but %q", body[0])
+	}
+
+	// 1000 ticks of a CPU elapsed, the dev server used 500 of them
+	n.lastCPU = &system.CPUTimes{Total: 8000, Count: 2}
+	n.lastTicks = map[int]uint64{1: 200, 77: 5, 4242: 500}
+	if err = n.update(); err != nil {
+		t.Fatal(err)
+	}
+	n.arrange()
+	if pids := shownPIDs(n); pids[0] != 4242 {
+		t.Fatalf("the busiest process should be the first but %v", pids)
+	}
+	header, body := n.buildBody()
+	if header[0] != " [    PID USER  CPU%▼      RSS COMMAND](fg-blue)" {
+		t.Fatalf("the header should mark the sorted column but %q", header[0])
+	}
+	if !strings.HasPrefix(body[0], "    4242 dev  [  50.0](fg-red)") || !strings.HasSuffix(body[0], " node server.js --port 3000") {
+		t.Fatalf("the dev server should use 50%% but %q", body[0])
+	}
+	if !strings.Contains(body[1], "   0.0 ") {
+		t.Fatalf("an idle process should use 0%% but %q", body[1])
+	}
+}
+
+func TestProcessListWidgetSortAndFilter(t *testing.T) {
+	n, err := NewProcessListWidget(&Option{ExecPath: "/home/dev/app", Content: map[string]interface{}{"sort": "rss"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.fs = system.NewFS("../system/testdata/proc")
+	n.users = map[int]string{0: "root", 1000: "dev"}
+	if err = n.update(); err != nil {
+		t.Fatal(err)
+	}
+	n.arrange()
+	if pids := shownPIDs(n); pids[0] != 4242 || pids[1] != 1 || pids[2] != 77 {
+		t.Fatalf("the processes should be sorted by the RSS but %v", pids)
+	}
+	n.reverse = true
+	n.arrange()
+	if pids := shownPIDs(n); pids[0] != 77 || pids[2] != 4242 {
+		t.Fatalf("the order should be reversed but %v", pids)
+	}
+	n.sortBy = processColumnIndex("command")
+	n.reverse = false
+	n.arrange()
+	if pids := shownPIDs(n); pids[0] != 1 || pids[1] != 77 || pids[2] != 4242 {
+		t.Fatalf("the processes should be sorted by the command but %v", pids)
+	}
+
+	for filter, expected := range map[string]int{"SERVER": 4242, "root": 2, "77": 77, "7": 0} {
+		n.filter = filter
+		n.arrange()
+		pids := shownPIDs(n)
+		switch {
+		case expected == 2 && len(pids) != 2:
+			t.Fatalf("the processes of root should be shown but %v", pids)
+		case expected == 0 && len(pids) != 0:
+			t.Fatalf("the pid should be matched exactly but %v", pids)
+		case expected > 2 && (len(pids) != 1 || pids[0] != expected):
+			t.Fatalf("%s should show %d but %v", filter, expected, pids)
+		}
+	}
+}
+
+func TestProcessListWidgetMatch(t *testing.T) {
+	cases := []struct {
+		content  map[string]interface{}
+		expected []int
+		invalid  bool
+	}{
+		// the working directory of the dev server is under the directory of the config
+		{map[string]interface{}{"match": "^{exec_path}"}, []int{4242}, false},
+		// the command line is matched
+		{map[string]interface{}{"match": "init"}, []int{1}, false},
+		{map[string]interface{}{"match": "node["}, nil, true},
+		{map[string]interface{}{"sort": "mem"}, nil, true},
+	}
+	for _, c := range cases {
+		n, err := NewProcessListWidget(&Option{ExecPath: "/home/dev/app", Content: c.content})
+		if c.invalid {
+			if err == nil {
+				t.Fatalf("%v should be an error", c.content)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.fs = system.NewFS("../system/testdata/proc")
+		if err = n.update(); err != nil {
+			t.Fatal(err)
+		}
+		n.arrange()
+		if pids := shownPIDs(n); !reflect.DeepEqual(pids, c.expected) {
+			t.Fatalf("%v should show %v but %v", c.content, c.expected, pids)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package widget
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	ui "github.com/gizak/termui"
+	m2s "github.com/mitchellh/mapstructure"
+	"github.com/qmu/mcc/system"
+	"github.com/qmu/mcc/widget/listable"
+)
+
+const (
+	defaultSystemInterval = time.Second
+	defaultSystemDiskPath = "/"
+)
+
+// SystemStatsWidget shows the usage of the CPUs, the memory, the disks and the network of the host
+type SystemStatsWidget struct {
+	lifecycle
+	options  *Option
+	fs       *system.FS
+	stack    *gaugeStack
+	gauges   []*gaugeModel
+	stats    []SystemStat
+	isReady  bool
+	interval time.Duration
+	lastCPU  *system.CPUTimes // the CPU times of the previous poll to compute the usage
+}
+
+var systemMetrics = map[string]gaugeMetric{
+	"cpu":    {label: "CPU Usage", color: ui.ColorGreen},
+	"memory": {label: "Memory Usage", color: ui.ColorRed},
+	"load":   {label: "Load Average", color: ui.ColorMagenta},
+	"disk":   {label: "Disk Usage", color: ui.ColorYellow},
+	"net_rx": {label: "Network RX", color: ui.ColorCyan, counter: true},
+	"net_tx": {label: "Network TX", color: ui.ColorCyan, counter: true},
+}
+
+// IsSystemStatsMetrics tells if the metrics is available for system_stats
+func IsSystemStatsMetrics(metrics string) bool {
+	_, ok := systemMetrics[metrics]
+	return ok
+}
+
+// IsSystemStatsCounter tells if the metrics of system_stats accepts the mode "rate" or "total"
+func IsSystemStatsCounter(metrics string) bool {
+	return systemMetrics[metrics].counter
+}
+
+// systemSample reads each file of procfs at most once in a poll
+type systemSample struct {
+	fs      *system.FS
+	cpu     *system.CPUTimes
+	cpuErr  error
+	mem     *system.Memory
+	memErr  error
+	load    *system.LoadAvg
+	loadErr error
+	net     map[string]system.NetDev
+	netErr  error
+	read    map[string]bool
+}
+
+func (s *systemSample) CPU() (*system.CPUTimes, error) {
+	if !s.read["cpu"] {
+		s.read["cpu"] = true
+		s.cpu, s.cpuErr = s.fs.CPU()
+	}
+	return s.cpu, s.cpuErr
+}
+
+func (s *systemSample) Memory() (*system.Memory, error) {
+	if !s.read["memory"] {
+		s.read["memory"] = true
+		s.mem, s.memErr = s.fs.Memory()
+	}
+	return s.mem, s.memErr
+}
+
+func (s *systemSample) LoadAvg() (*system.LoadAvg, error) {
+	if !s.read["load"] {
+		s.read["load"] = true
+		s.load, s.loadErr = s.fs.LoadAvg()
+	}
+	return s.load, s.loadErr
+}
+
+func (s *systemSample) NetDev() (map[string]system.NetDev, error) {
+	if !s.read["net"] {
+		s.read["net"] = true
+		s.net, s.netErr = s.fs.NetDev()
+	}
+	return s.net, s.netErr
+}
+
+// NewSystemStatsWidget constructs a New SystemStatsWidget
+func NewSystemStatsWidget(opt *Option) (n *SystemStatsWidget, err error) {
+	n = new(SystemStatsWidget)
+	n.options = opt
+	n.fs = system.NewFS("")
+	n.stack = newGaugeStack(opt.GetWidth(), opt.GetHeight())
+	n.interval = defaultSystemInterval
+	if opt.Interval != "" {
+		if n.interval, err = time.ParseDuration(opt.Interval); err != nil {
+			return
+		}
+	}
+	err = m2s.Decode(opt.Content, &n.stats)
+	return
+}
+
+// Init is the implementation of widget.Init
+func (n *SystemStatsWidget) Init() (err error) {
+	if err = n.buildGauges(); err != nil {
+		return
+	}
+	n.isReady = true
+	go n.watch()
+	return
+}
+
+// watch polls the stats every interval until the widget is stopped
+func (n *SystemStatsWidget) watch() {
+	ctx := n.context()
+	n.poll()
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.poll()
+		}
+	}
+}
+
+func (n *SystemStatsWidget) poll() {
+	n.update()
+	listable.RenderBody()
+}
+
+// update reads the stats into the gauges, an error is shown only on the gauges which need the file
+func (n *SystemStatsWidget) update() {
+	s := &systemSample{fs: n.fs, read: map[string]bool{}}
+	now := time.Now()
+	for i, g := range n.gauges {
+		if err := n.render(g, &n.stats[i], s, now); err != nil {
+			g.gauge.Percent = 0
+			g.gauge.Label = err.Error() + " "
+			g.record(0)
+		}
+	}
+	if s.cpu != nil {
+		n.lastCPU = s.cpu
+	}
+}
+
+// render shows the metrics of the gauge in the sample
+func (n *SystemStatsWidget) render(g *gaugeModel, st *SystemStat, s *systemSample, now time.Time) (err error) {
+	switch g.metrics {
+	case "cpu":
+		c, err := s.CPU()
+		if err != nil {
+			return err
+		}
+		if n.lastCPU == nil {
+			g.gauge.Percent = 0
+			g.gauge.Label = "measuring... "
+			return nil
+		}
+		u := c.Usage(n.lastCPU)
+		g.record(u)
+		g.gauge.Percent = int(u)
+		g.gauge.Label = strconv.FormatFloat(u, 'f', 2, 64) + "% "
+	case "memory":
+		m, err := s.Memory()
+		if err != nil {
+			return err
+		}
+		used := m.Used()
+		r := 0.0
+		if m.Total > 0 {
+			r = float64(used) / float64(m.Total) * 100
+		}
+		g.record(r)
+		g.gauge.Percent = int(r)
+		g.gauge.Label = "{{percent}}% (" + humanize.Bytes(used) + " / " + humanize.Bytes(m.Total) + ") "
+	case "load":
+		l, err := s.LoadAvg()
+		if err != nil {
+			return err
+		}
+		if g.max == 0 {
+			// a load as high as the CPUs is full
+			if c, err := s.CPU(); err == nil && c.Count > 0 {
+				g.max = float64(c.Count)
+			}
+		}
+		g.show(l.Load1, fmt.Sprintf("%.2f %.2f %.2f", l.Load1, l.Load5, l.Load15))
+	case "disk":
+		path := st.Path
+		if path == "" {
+			path = defaultSystemDiskPath
+		}
+		d, err := system.Disk(path)
+		if err != nil {
+			return err
+		}
+		r := d.Percent()
+		g.record(r)
+		g.gauge.Percent = int(r)
+		g.gauge.Label = "{{percent}}% (" + humanize.Bytes(d.Used) + " / " + humanize.Bytes(d.Total) + ") "
+	case "net_rx", "net_tx":
+		devs, err := s.NetDev()
+		if err != nil {
+			return err
+		}
+		v, err := netBytes(devs, g.metrics, st.Interface)
+		if err != nil {
+			return err
+		}
+		g.renderCounter(v, now)
+	}
+	return
+}
+
+// netBytes sums up the bytes of the interface, or all of the interfaces except the virtual ones
+func netBytes(devs map[string]system.NetDev, metrics string, iface string) (v uint64, err error) {
+	found := false
+	for name, d := range devs {
+		if iface == "" && d.Virtual || iface != "" && name != iface {
+			continue
+		}
+		found = true
+		if metrics == "net_rx" {
+			v += d.RxBytes
+		} else {
+			v += d.TxBytes
+		}
+	}
+	if iface != "" && !found {
+		err = errors.New("interface " + iface + " is not found")
+	}
+	return
+}
+
+func (n *SystemStatsWidget) buildGauges() (err error) {
+	for _, v := range n.stats {
+		m, ok := systemMetrics[v.Metrics]
+		if !ok {
+			return errors.New(v.Metrics + " is not available for the type of system_stats widget")
+		}
+		label := m.label
+		if v.Name != "" {
+			label = v.Name + " - " + m.label
+		}
+		switch {
+		case v.Metrics == "disk" && v.Path != "":
+			label += " (" + v.Path + ")"
+		case m.counter && v.Interface != "":
+			label += " (" + v.Interface + ")"
+		}
+		g := newGaugeModel(n.options, label, m)
+		g.metrics = v.Metrics
+		g.mode = v.Mode
+		if v.Max != "" {
+			var full uint64
+			if full, err = humanize.ParseBytes(v.Max); err != nil {
+				return fmt.Errorf("max %s of system_stats is invalid: %v", v.Max, err)
+			}
+			g.max = float64(full)
+		}
+		if v.Sparkline {
+			if v.Window == "" {
+				v.Window = defaultDockerWindow
+			}
+			var window time.Duration
+			if window, err = time.ParseDuration(v.Window); err != nil {
+				return fmt.Errorf("window %s of system_stats is invalid: %v", v.Window, err)
+			}
+			g.showHistory(v.Window, window, n.interval)
+		}
+		n.gauges = append(n.gauges, g)
+	}
+	rows := make([]stackRow, len(n.gauges))
+	for i, g := range n.gauges {
+		rows[i] = stackRow{gauge: g.gauge, spark: g.spark}
+	}
+	n.stack.SetRows(rows)
+	return
+}
+
+// Activate is the implementation of Widget.Activate
+func (n *SystemStatsWidget) Activate() {
+}
+
+// Deactivate is the implementation of Widget.Deactivate
+func (n *SystemStatsWidget) Deactivate() {
+}
+
+// IsDisabled is the implementation of Widget.IsDisabled
+func (n *SystemStatsWidget) IsDisabled() bool {
+	return true
+}
+
+// IsReady is the implementation of Widget.IsReady
+func (n *SystemStatsWidget) IsReady() bool {
+	return n.isReady
+}
+
+// GetHighlightenPos is the implementation of Widget.GetHighlightenPos
+func (n *SystemStatsWidget) GetHighlightenPos() int {
+	return 0
+}
+
+// GetGridBufferers is the implementation of widget.Activate
+func (n *SystemStatsWidget) GetGridBufferers() []ui.GridBufferer {
+	return []ui.GridBufferer{n.stack}
+}
+
+// GetWidth is the implementation of widget.Init
+func (n *SystemStatsWidget) GetWidth() int {
+	return n.options.GetWidth()
+}
+
+// GetHeight is the implementation of widget.Init
+func (n *SystemStatsWidget) GetHeight() int {
+	return n.options.GetHeight()
+}
+
+// Disable is
+func (n *SystemStatsWidget) Disable() {
+}
+
+// SetOption is
+func (n *SystemStatsWidget) SetOption(opt *AdditionalWidgetOption) {
+}
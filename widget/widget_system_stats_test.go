@@ -0,0 +1,123 @@
+package widget
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qmu/mcc/system"
+)
+
+func TestSystemStatsWidgetUpdate(t *testing.T) {
+	n, err := NewSystemStatsWidget(&Option{
+		Height: 10,
+		Content: []map[string]interface{}{
+			{"metrics": "cpu", "sparkline": true},
+			{"metrics": "memory"},
+			{"metrics": "load"},
+			{"metrics": "disk", "path": "."},
+			{"metrics": "net_rx", "mode": "total"},
+			{"metrics": "net_tx", "mode": "total", "interface": "eth0"},
+			{"metrics": "net_tx", "interface": "eth9"},
+			{"metrics": "net_rx"},
+			{"metrics": "disk", "path": "./not_found"},
+			{"metrics": "net_rx", "mode": "total", "interface": "docker0"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.fs = system.NewFS("../system/testdata/proc")
+	if err = n.buildGauges(); err != nil {
+		t.Fatal(err)
+	}
+	if l := n.gauges[3].gauge.BorderLabel; l != "Disk Usage (.)" {
+		t.Fatalf("the label should have the path but %s", l)
+	}
+
+	n.update()
+	if g := n.gauges[0].gauge; g.Label != "measuring... " {
+		t.Fatalf("the CPU usage should be measured from the next poll but %s", g.Label)
+	}
+	if n.lastCPU == nil || n.lastCPU.Total != 10000 || n.lastCPU.Idle != 8500 {
+		t.Fatalf("the CPU times should be kept for the next poll but %+v", n.lastCPU)
+	}
+	if g := n.gauges[1].gauge; g.Percent != 75 || g.Label != "{{percent}}% (6.1 GB / 8.2 GB) " {
+		t.Fatalf("the memory should be 75%% but %d %s", g.Percent, g.Label)
+	}
+	// scaled by the 2 CPUs
+	if g := n.gauges[2].gauge; g.Percent != 26 || g.Label != "0.52 0.78 1.05 " {
+		t.Fatalf("the load should be 26%% but %d %s", g.Percent, g.Label)
+	}
+	if g := n.gauges[3].gauge; !strings.HasPrefix(g.Label, "{{percent}}% (") {
+		t.Fatalf("the disk usage should be shown but %s", g.Label)
+	}
+	// lo, docker0 and the veth have no device in sysfs and are not counted
+	if g := n.gauges[4].gauge; g.Label != "6.0 MB " {
+		t.Fatalf("the received bytes should be 6.0 MB but %s", g.Label)
+	}
+	if g := n.gauges[5].gauge; g.Label != "2.0 MB " {
+		t.Fatalf("the sent bytes of eth0 should be 2.0 MB but %s", g.Label)
+	}
+	if g := n.gauges[6].gauge; g.Label != "interface eth9 is not found " {
+		t.Fatalf("the missing interface should be shown but %s", g.Label)
+	}
+	if g := n.gauges[7].gauge; g.Label != "measuring... " {
+		t.Fatalf("the rate should be measured from the next poll but %s", g.Label)
+	}
+	if g := n.gauges[8].gauge; g.Percent != 0 || !strings.Contains(g.Label, "no such file or directory") {
+		t.Fatalf("the error should be shown on the gauge but %s", g.Label)
+	}
+	if g := n.gauges[9].gauge; g.Label != "3.0 MB " {
+		t.Fatalf("the virtual interface should be counted when it's specified but %s", g.Label)
+	}
+
+	n.lastCPU = &system.CPUTimes{Total: 9000, Idle: 8000}
+	n.update()
+	if g := n.gauges[0].gauge; g.Percent != 50 || g.Label != "50.00% " {
+		t.Fatalf("the CPU usage should be 50%% but %d %s", g.Percent, g.Label)
+	}
+	if g := n.gauges[7].gauge; g.Label != "0 B/s " {
+		t.Fatalf("the rate should be 0 B/s but %s", g.Label)
+	}
+	if vs := n.gauges[0].spark.history.values(); len(vs) != 1 || vs[0] != 50 {
+		t.Fatalf("the CPU usage should be recorded after it's measured but %v", vs)
+	}
+}
+
+func TestSystemStatsWidgetError(t *testing.T) {
+	n, err := NewSystemStatsWidget(&Option{
+		Height: 10,
+		Content: []map[string]interface{}{
+			{"metrics": "memory", "sparkline": true},
+			{"metrics": "load", "sparkline": true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.fs = system.NewFS("../system/testdata/proc2")
+	if err = n.buildGauges(); err != nil {
+		t.Fatal(err)
+	}
+	n.update()
+	// MemFree, Buffers and Cached are available without MemAvailable
+	if g := n.gauges[0].gauge; g.Percent != 50 {
+		t.Fatalf("the memory should be 50%% but %d %s", g.Percent, g.Label)
+	}
+	if g := n.gauges[1].gauge; g.Percent != 0 || !strings.Contains(g.Label, "loadavg") {
+		t.Fatalf("the error should be shown on the gauge but %s", g.Label)
+	}
+	if vs := n.gauges[1].spark.history.values(); len(vs) != 1 || vs[0] != 0 {
+		t.Fatalf("the error should be recorded as 0 but %v", vs)
+	}
+}
+
+func TestSystemStatsWidgetInvalidMetrics(t *testing.T) {
+	n, err := NewSystemStatsWidget(&Option{Content: []map[string]interface{}{{"metrics": "pids"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.buildGauges(); err == nil {
+		t.Fatal("pids should not be available")
+	}
+}
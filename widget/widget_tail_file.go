@@ -11,6 +11,7 @@ import (
 
 // TailFileWidget is a command launcher
 type TailFileWidget struct {
+	lifecycle
 	options  *Option
 	renderer *listable.ListWrapper
 	isReady  bool
@@ -75,6 +76,10 @@ func (n *TailFileWidget) tailActually() (err error) {
 	if err != nil {
 		return
 	}
+	// the lines are closed when the tail is stopped
+	if !n.keep(closerFunc(t.Stop)) {
+		return
+	}
 	first := true
 	for line := range t.Lines {
 		// if the Location option is enable
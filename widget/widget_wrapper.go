@@ -21,6 +21,11 @@ type WrapperWidget struct {
 	IssueRegex  string
 	Type        string
 	Path        string
+	Command     string
+	Interval    string
+	Timeout     string
+	Repo        string
+	DockerHost  string
 	widgetter   Widgetter
 	initialized bool
 }
@@ -75,6 +80,11 @@ func (w *WrapperWidget) SetOption(opt *AdditionalWidgetOption) {
 	w.widgetter.SetOption(opt)
 }
 
+// Stop stops the goroutines and the watchers of the widget, it's called when the layout is replaced
+func (w *WrapperWidget) Stop() {
+	w.widgetter.Stop()
+}
+
 // GetNeighborIndex is
 func (w *WrapperWidget) GetNeighborIndex(direction string) (idx int) {
 	if direction == "top" {
@@ -91,6 +101,14 @@ func (w *WrapperWidget) GetNeighborIndex(direction string) (idx int) {
 	return idx
 }
 
+// GetRepoPath returns the path of the git repository of the widget
+func (w *WrapperWidget) GetRepoPath() string {
+	if w.Repo != "" {
+		return w.Repo
+	}
+	return w.ExecPath
+}
+
 // Is is
 func (w *WrapperWidget) Is(wType string) bool {
 	return w.WidgetType == wType
@@ -110,6 +128,11 @@ func (w *WrapperWidget) Vary() (err error) {
 		Title:      w.Title,
 		Type:       w.Type,
 		Path:       w.Path,
+		Command:    w.Command,
+		Interval:   w.Interval,
+		Timeout:    w.Timeout,
+		Repo:       w.Repo,
+		DockerHost: w.DockerHost,
 	}
 	switch w.WidgetType {
 	case "menu":
@@ -122,10 +145,24 @@ func (w *WrapperWidget) Vary() (err error) {
 		wi, err = NewNoteWidget(opt)
 	case "git_status":
 		wi, err = NewGitStatusWidget(opt)
+	case "git_log":
+		wi, err = NewGitLogWidget(opt)
+	case "git_branches":
+		wi, err = NewGitBranchesWidget(opt)
 	case "tail_file":
 		wi, err = NewTailFileWidget(opt)
 	case "docker_status":
 		wi, err = NewDockerStatusWidget(opt)
+	case "docker_logs":
+		wi, err = NewDockerLogsWidget(opt)
+	case "system_stats":
+		wi, err = NewSystemStatsWidget(opt)
+	case "process_list":
+		wi, err = NewProcessListWidget(opt)
+	case "github_pull_requests":
+		wi, err = NewGithubPullRequestsWidget(opt)
+	case "command":
+		wi, err = NewCommandWidget(opt)
 	}
 	if err != nil {
 		return